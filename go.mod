@@ -3,7 +3,7 @@ module github.com/cmpsoares91/awsweeper
 go 1.12
 
 require (
-	github.com/aws/aws-sdk-go v1.19.11
+	github.com/aws/aws-sdk-go v1.25.43
 	github.com/golang/mock v1.3.1 // indirect
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/sirupsen/logrus v1.3.0
@@ -16,4 +16,5 @@ require (
 	golang.org/x/tools v0.0.0-20190606124116-d0a3d012864b // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -1,33 +1,11 @@
 package main
 
 import (
-	"fmt"
+	"os"
 
-	"github.com/cmpsoares91/awsweeper/pkg/config"
-	"github.com/cmpsoares91/awsweeper/pkg/wipe"
-	"github.com/sirupsen/logrus"
+	"github.com/cmpsoares91/awsweeper/pkg/command"
 )
 
 func main() {
-	logrus.SetLevel(logrus.DebugLevel)
-	yamlFilePath := "config.yaml"
-	config, err := config.Load(yamlFilePath)
-	if err != nil {
-		logrus.WithError(err).Fatal("Failed to open config file")
-	}
-
-	wiper := wipe.Wiper{
-		Config: config,
-	}
-
-	resources, warnings, err := wiper.Run()
-	if err != nil {
-		logrus.WithError(err).Fatal("Failed to wipe resources")
-	}
-
-	if len(warnings) > 0 {
-		logrus.WithField("Warnings:", warnings).Warn("Unable to perform as expected because of these warnings")
-	}
-
-	fmt.Println(resources)
+	os.Exit(command.Run(os.Args[1:]))
 }
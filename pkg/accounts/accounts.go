@@ -0,0 +1,146 @@
+package accounts
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// Account is an AWS account to be swept.
+type Account struct {
+	ID        string
+	Name      string
+	Partition string
+}
+
+// Resolver resolves the accounts selected by the accounts section of a config.
+type Resolver struct {
+	Organizations organizationsiface.OrganizationsAPI
+	STS           stsiface.STSAPI
+}
+
+// NewResolver creates a Resolver using the credentials of the given session.
+func NewResolver(p client.ConfigProvider, cfgs ...*aws.Config) *Resolver {
+	return &Resolver{
+		Organizations: organizations.New(p, cfgs...),
+		STS:           sts.New(p, cfgs...),
+	}
+}
+
+// CallerAccount returns the ID of the account the current credentials belong to.
+func (r *Resolver) CallerAccount() (string, error) {
+	output, err := r.CallerIdentity()
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.Account), nil
+}
+
+// CallerIdentity returns the account, ARN and user ID of the current credentials.
+func (r *Resolver) CallerIdentity() (*sts.GetCallerIdentityOutput, error) {
+	return r.STS.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+}
+
+// Resolve returns the explicitly listed accounts followed by all active accounts of the
+// organizational units (including nested ones), without duplicates and excluded accounts.
+func (r *Resolver) Resolve(cfg config.Accounts) ([]Account, error) {
+	var accounts []Account
+	seen := make(map[string]bool)
+	for _, id := range cfg.Exclude {
+		seen[id] = true
+	}
+
+	add := func(a Account) {
+		if !seen[a.ID] {
+			seen[a.ID] = true
+			accounts = append(accounts, a)
+		}
+	}
+
+	for _, id := range cfg.IDs {
+		add(Account{ID: id})
+	}
+
+	for _, ou := range cfg.OrganizationalUnits {
+		ouAccounts, err := r.listAccounts(ou)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range ouAccounts {
+			add(a)
+		}
+	}
+
+	return accounts, nil
+}
+
+func (r *Resolver) listAccounts(parentID string) (accounts []Account, err error) {
+	logrus.WithField("parent", parentID).Debug("Listing accounts of organizational unit")
+
+	err = r.Organizations.ListAccountsForParentPages(&organizations.ListAccountsForParentInput{
+		ParentId: aws.String(parentID),
+	}, func(page *organizations.ListAccountsForParentOutput, lastPage bool) bool {
+		for _, a := range page.Accounts {
+			if aws.StringValue(a.Status) != organizations.AccountStatusActive {
+				logrus.WithField(logging.Account, aws.StringValue(a.Id)).Debug("Account is not active. Skipping")
+				continue
+			}
+
+			accounts = append(accounts, Account{ID: aws.StringValue(a.Id), Name: aws.StringValue(a.Name)})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var children []string
+	err = r.Organizations.ListOrganizationalUnitsForParentPages(&organizations.ListOrganizationalUnitsForParentInput{
+		ParentId: aws.String(parentID),
+	}, func(page *organizations.ListOrganizationalUnitsForParentOutput, lastPage bool) bool {
+		for _, ou := range page.OrganizationalUnits {
+			children = append(children, aws.StringValue(ou.Id))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range children {
+		childAccounts, err := r.listAccounts(child)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, childAccounts...)
+	}
+
+	return accounts, nil
+}
+
+// RoleARN renders the role template for the given account.
+func RoleARN(roleTemplate string, a Account) (string, error) {
+	tmpl, err := template.New("role").Parse(roleTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, a); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
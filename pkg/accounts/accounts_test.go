@@ -0,0 +1,144 @@
+package accounts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+)
+
+type fakeOrganizations struct {
+	organizationsiface.OrganizationsAPI
+	accounts map[string][]*organizations.Account
+	children map[string][]string
+}
+
+func (f *fakeOrganizations) ListAccountsForParentPages(input *organizations.ListAccountsForParentInput, fn func(*organizations.ListAccountsForParentOutput, bool) bool) error {
+	// one page per account to exercise pagination
+	accounts := f.accounts[*input.ParentId]
+	for i, a := range accounts {
+		if !fn(&organizations.ListAccountsForParentOutput{Accounts: []*organizations.Account{a}}, i == len(accounts)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeOrganizations) ListOrganizationalUnitsForParentPages(input *organizations.ListOrganizationalUnitsForParentInput, fn func(*organizations.ListOrganizationalUnitsForParentOutput, bool) bool) error {
+	var ous []*organizations.OrganizationalUnit
+	for _, id := range f.children[*input.ParentId] {
+		ous = append(ous, &organizations.OrganizationalUnit{Id: aws.String(id)})
+	}
+	fn(&organizations.ListOrganizationalUnitsForParentOutput{OrganizationalUnits: ous}, true)
+	return nil
+}
+
+type fakeSTS struct {
+	stsiface.STSAPI
+	account string
+}
+
+func (f *fakeSTS) GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Account: aws.String(f.account)}, nil
+}
+
+func account(id, name, status string) *organizations.Account {
+	return &organizations.Account{Id: aws.String(id), Name: aws.String(name), Status: aws.String(status)}
+}
+
+func newResolver() *Resolver {
+	return &Resolver{
+		Organizations: &fakeOrganizations{
+			accounts: map[string][]*organizations.Account{
+				"ou-sandbox": {
+					account("111111111111", "sandbox-1", organizations.AccountStatusActive),
+					account("222222222222", "sandbox-2", organizations.AccountStatusSuspended),
+				},
+				"ou-sandbox-team": {
+					account("333333333333", "team-1", organizations.AccountStatusActive),
+					account("444444444444", "team-2", organizations.AccountStatusActive),
+				},
+			},
+			children: map[string][]string{
+				"ou-sandbox": {"ou-sandbox-team"},
+			},
+		},
+		STS: &fakeSTS{account: "999999999999"},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts config.Accounts
+		expected []Account
+	}{
+		{
+			name:     "explicit ids",
+			accounts: config.Accounts{IDs: []string{"555555555555", "666666666666"}},
+			expected: []Account{{ID: "555555555555"}, {ID: "666666666666"}},
+		},
+		{
+			name:     "nested organizational units without inactive accounts",
+			accounts: config.Accounts{OrganizationalUnits: []string{"ou-sandbox"}},
+			expected: []Account{
+				{ID: "111111111111", Name: "sandbox-1"},
+				{ID: "333333333333", Name: "team-1"},
+				{ID: "444444444444", Name: "team-2"},
+			},
+		},
+		{
+			name: "duplicates and excludes",
+			accounts: config.Accounts{
+				IDs:                 []string{"333333333333"},
+				OrganizationalUnits: []string{"ou-sandbox-team"},
+				Exclude:             []string{"444444444444"},
+			},
+			expected: []Account{{ID: "333333333333"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			accounts, err := newResolver().Resolve(tc.accounts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(accounts, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, accounts)
+			}
+		})
+	}
+}
+
+func TestCallerAccount(t *testing.T) {
+	caller, err := newResolver().CallerAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caller != "999999999999" {
+		t.Errorf("expected caller account 999999999999, got %s", caller)
+	}
+}
+
+func TestRoleARN(t *testing.T) {
+	role, err := RoleARN(config.DefaultRoleTemplate, Account{ID: "111111111111", Partition: "aws-cn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if role != "arn:aws-cn:iam::111111111111:role/OrganizationAccountAccessRole" {
+		t.Errorf("unexpected role %s", role)
+	}
+
+	if _, err := RoleARN("{{ .Unknown }}", Account{ID: "111111111111"}); err == nil {
+		t.Error("expected an error for an unknown template field")
+	}
+}
@@ -0,0 +1,287 @@
+// Package audit appends what a run deleted, and why, to tamper-evident logs.
+//
+// A log has one JSON record per line. Each record holds an entry and the hash of the previous record, and is hashed
+// itself, so that changing, removing or inserting a record breaks the chain of hashes, which Verify detects.
+// Removing the last records leaves an intact chain, though. To detect that, the hash of the last record of a run, its
+// head, is to be kept elsewhere, e.g. in the logs of the run, and passed to Verify.
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+)
+
+// Kinds of entries.
+const (
+	// KindStart is the first entry of a run, identifying who runs it with which config.
+	KindStart = "start"
+	// KindResource records a resource selected for deletion, once before deleting it and once with the result.
+	KindResource = "resource"
+	// KindEnd is the last entry of a run.
+	KindEnd = "end"
+)
+
+// Results of the deletion of a resource.
+const (
+	// ResultDeleting is recorded before deleting a resource, so that no resource is deleted without a record.
+	ResultDeleting = "deleting"
+	ResultDeleted  = "deleted"
+	ResultFailed   = "failed"
+	ResultDryRun   = "dry-run"
+)
+
+// Caller is the identity of the credentials of a run, as returned by STS GetCallerIdentity.
+type Caller struct {
+	Account string `json:"account"`
+	ARN     string `json:"arn"`
+	UserID  string `json:"user_id"`
+}
+
+// Resource is a resource selected for deletion: its attributes and tags before the deletion, why it was selected
+// and the result of the deletion.
+type Resource struct {
+	inventory.Item
+
+	// Filter is the filter which matched the resource.
+	Filter string `json:"filter,omitempty"`
+	// Reason is why the resource was selected if not by a filter, e.g. because it owns resources matched by one.
+	Reason string `json:"reason,omitempty"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Entry is an event of a run.
+type Entry struct {
+	Time  time.Time `json:"time"`
+	RunID string    `json:"run_id"`
+	Kind  string    `json:"kind"`
+
+	// Command, Caller, ConfigHash and DryRun are set on start entries.
+	Command    string  `json:"command,omitempty"`
+	Caller     *Caller `json:"caller,omitempty"`
+	ConfigHash string  `json:"config_hash,omitempty"`
+	DryRun     bool    `json:"dry_run,omitempty"`
+
+	// Resource is set on resource entries.
+	Resource *Resource `json:"resource,omitempty"`
+
+	// Deleted, Failed and Error are set on end entries.
+	Deleted int    `json:"deleted,omitempty"`
+	Failed  int    `json:"failed,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// record is a line of a log. The entry is kept as written, so that its hash can be verified.
+type record struct {
+	Entry    json.RawMessage `json:"entry"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+// hash returns the hash of an entry following the record with the given hash.
+func hash(prevHash string, entry []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte{'\n'})
+	h.Write(entry)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sink stores the lines of a log.
+type Sink interface {
+	// Read returns the lines written so far, empty if the log does not exist yet.
+	Read() ([]byte, error)
+	// Append appends a line, including its newline.
+	Append(line []byte) error
+	// Close persists the appended lines and releases the sink.
+	Close() error
+}
+
+// chain is a sink with the hash of its last record.
+type chain struct {
+	sink Sink
+	last string
+}
+
+// Log records the entries of a run to sinks. All methods of a nil Log are no-ops, so that auditing can be disabled.
+type Log struct {
+	mu     sync.Mutex
+	runID  string
+	chains []*chain
+	// heads are the hashes of the last records of the closed chains
+	heads []string
+	// results counts the recorded resources by result
+	results map[string]int
+}
+
+// Open returns a log appending the entries of a run to the sinks, each continuing its own chain of hashes.
+func Open(runID string, sinks ...Sink) (*Log, error) {
+	l := &Log{runID: runID, results: make(map[string]int)}
+	for _, s := range sinks {
+		data, err := s.Read()
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+
+		c := &chain{sink: s}
+		if lines := bytes.Split(bytes.TrimSpace(data), []byte("\n")); len(lines[0]) > 0 {
+			var last record
+			if err := json.Unmarshal(lines[len(lines)-1], &last); err != nil {
+				l.Close()
+				return nil, fmt.Errorf("failed to read the last record of the audit log: %v", err)
+			}
+			c.last = last.Hash
+		}
+		l.chains = append(l.chains, c)
+	}
+
+	return l, nil
+}
+
+// Record appends an entry of the run to all sinks.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.RunID = l.runID
+	if e.Kind == KindResource && e.Resource != nil {
+		l.results[e.Resource.Result]++
+	}
+
+	entry, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range l.chains {
+		h := hash(c.last, entry)
+		line, err := json.Marshal(record{Entry: entry, PrevHash: c.last, Hash: h})
+		if err != nil {
+			return err
+		}
+
+		if err := c.sink.Append(append(line, '\n')); err != nil {
+			return err
+		}
+		c.last = h
+	}
+
+	return nil
+}
+
+// End records the end of the run with the number of deleted and failed resources and closes the sinks.
+func (l *Log) End(runErr error) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	e := Entry{Kind: KindEnd, Deleted: l.results[ResultDeleted], Failed: l.results[ResultFailed]}
+	l.mu.Unlock()
+	if runErr != nil {
+		e.Error = runErr.Error()
+	}
+
+	if err := l.Record(e); err != nil {
+		l.Close()
+		return err
+	}
+	return l.Close()
+}
+
+// Close closes all sinks, returning the first error.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var first error
+	for _, c := range l.chains {
+		if err := c.sink.Close(); err != nil && first == nil {
+			first = err
+		}
+		l.heads = append(l.heads, c.last)
+	}
+	l.chains = nil
+	return first
+}
+
+// Heads returns the hashes of the last records written to the sinks, in the order of the sinks. Once the log is
+// closed, they are the heads to verify the logs with.
+func (l *Log) Heads() []string {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.chains == nil {
+		return l.heads
+	}
+
+	var heads []string
+	for _, c := range l.chains {
+		heads = append(heads, c.last)
+	}
+	return heads
+}
+
+// Verify checks the chain of hashes of a log and returns its entries. The error names the first line which was
+// modified, removed or inserted. The log must also contain the records with the given heads, as returned by Heads
+// at the end of runs; otherwise the records up to them have been removed from the end of the log.
+func Verify(data []byte, heads ...string) ([]Entry, error) {
+	var entries []Entry
+	hashes := make(map[string]bool)
+	prevHash := ""
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return entries, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if r.PrevHash != prevHash {
+			return entries, fmt.Errorf("line %d: previous hash does not match, a record before was modified, removed or inserted", i+1)
+		}
+		if r.Hash != hash(r.PrevHash, r.Entry) {
+			return entries, fmt.Errorf("line %d: hash does not match, the entry was modified", i+1)
+		}
+
+		var e Entry
+		if err := json.Unmarshal(r.Entry, &e); err != nil {
+			return entries, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		entries = append(entries, e)
+		prevHash = r.Hash
+		hashes[r.Hash] = true
+	}
+
+	for _, head := range heads {
+		if !hashes[head] {
+			return entries, fmt.Errorf("record %s not found, the last records were removed", head)
+		}
+	}
+
+	return entries, nil
+}
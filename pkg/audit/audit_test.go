@@ -0,0 +1,248 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/spf13/afero"
+)
+
+// writeRun appends the entries of a run deleting a resource to the sinks.
+func writeRun(t *testing.T, runID string, sinks ...Sink) {
+	l, err := Open(runID, sinks...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Record(Entry{Kind: KindStart, Command: "wipe", Caller: &Caller{Account: "123456789012"}, ConfigHash: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	err = l.Record(Entry{Kind: KindResource, Resource: &Resource{
+		Item:   inventory.Item{Region: "eu-west-1", ResourceType: "s3_bucket", ID: "bucket-" + runID, Tags: map[string]string{"team": "ci"}},
+		Filter: "IDS:[^bucket-]",
+		Result: ResultDeleted,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.End(errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	config.AppFs = afero.NewMemMapFs()
+	defer func() { config.AppFs = afero.NewOsFs() }()
+
+	writeRun(t, "first", &FileSink{Path: "audit.jsonl"})
+	writeRun(t, "second", &FileSink{Path: "audit.jsonl"})
+
+	data, err := afero.ReadFile(config.AppFs, "audit.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Verify(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 entries, got %d", len(entries))
+	}
+
+	end := entries[5]
+	if end.RunID != "second" || end.Kind != KindEnd || end.Deleted != 1 || end.Error != "failed" {
+		t.Errorf("unexpected end entry %+v", end)
+	}
+	if r := entries[4].Resource; r == nil || r.ID != "bucket-second" || r.Tags["team"] != "ci" || r.Filter != "IDS:[^bucket-]" {
+		t.Errorf("unexpected resource entry %+v", entries[4])
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	api := &fakeS3{}
+	writeRun(t, "first", &S3Sink{API: api, Bucket: "bucket", Key: "audit.jsonl"})
+	writeRun(t, "second", &S3Sink{API: api, Bucket: "bucket", Key: "audit.jsonl"})
+	lines := strings.SplitAfter(string(api.objects["audit.jsonl"]), "\n")
+
+	for name, tc := range map[string]struct {
+		lines []string
+		err   string
+	}{
+		"modified": {
+			lines: append(append(append([]string{}, lines[:1]...), strings.Replace(lines[1], "bucket-first", "bucket-other", 1)), lines[2:]...),
+			err:   "line 2: hash does not match",
+		},
+		"removed": {
+			lines: append(append([]string{}, lines[:1]...), lines[2:]...),
+			err:   "line 2: previous hash does not match",
+		},
+		"rehashed": {
+			// an attacker recomputing the hash of a modified entry breaks the chain at the next line
+			lines: append(append(append([]string{}, lines[:4]...), rehash(t, lines[4], "bucket-second", "bucket-other")), lines[5:]...),
+			err:   "line 6: previous hash does not match",
+		},
+		"not a record": {
+			lines: append(append([]string{}, lines...), "{}\n"),
+			err:   "line 7: previous hash does not match",
+		},
+	} {
+		_, err := Verify([]byte(strings.Join(tc.lines, "")))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error %q, got %v", name, tc.err, err)
+		}
+	}
+}
+
+// rehash replaces old by new in the entry of a line and updates its hash.
+func rehash(t *testing.T, line, old, new string) string {
+	var r record
+	if err := json.Unmarshal([]byte(line), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Entry = json.RawMessage(strings.Replace(string(r.Entry), old, new, 1))
+	r.Hash = hash(r.PrevHash, r.Entry)
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b) + "\n"
+}
+
+// fakeS3 stores objects in memory.
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string][]byte
+}
+
+func (f *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	data, ok := f.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)
+	}
+
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(data)), ETag: etag(data)}, nil
+}
+
+func (f *fakeS3) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	data, ok := f.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+
+	return &s3.HeadObjectOutput{ETag: etag(data)}, nil
+}
+
+// etag returns the ETag of an object uploaded in a single part, the quoted MD5 hash of its content.
+func etag(data []byte) *string {
+	return aws.String(fmt.Sprintf("%q", fmt.Sprintf("%x", md5.Sum(data))))
+}
+
+func (f *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.objects == nil {
+		f.objects = make(map[string][]byte)
+	}
+	f.objects[aws.StringValue(input.Key)] = data
+	return &s3.PutObjectOutput{ETag: etag(data)}, nil
+}
+
+func TestS3Sink(t *testing.T) {
+	api := &fakeS3{}
+	writeRun(t, "first", &S3Sink{API: api, Bucket: "bucket", Key: "audit.jsonl"})
+	writeRun(t, "second", &S3Sink{API: api, Bucket: "bucket", Key: "audit.jsonl"})
+
+	entries, err := Verify(api.objects["audit.jsonl"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 || entries[0].RunID != "first" || entries[3].RunID != "second" {
+		t.Errorf("expected the entries of both runs, got %+v", entries)
+	}
+}
+
+func TestS3Sink_Concurrent(t *testing.T) {
+	api := &fakeS3{}
+	first, err := Open("first", &S3Sink{API: api, Bucket: "bucket", Key: "audit.jsonl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Open("second", &S3Sink{API: api, Bucket: "bucket", Key: "audit.jsonl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// records are written right away, not when the log is closed
+	if err := first.Record(Entry{Kind: KindStart, Command: "wipe"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(api.objects["audit.jsonl"]); err != nil || len(api.objects["audit.jsonl"]) == 0 {
+		t.Errorf("expected the record to be written, got %q: %v", api.objects["audit.jsonl"], err)
+	}
+
+	if err := second.Record(Entry{Kind: KindStart, Command: "wipe"}); err == nil || !strings.Contains(err.Error(), "changed by another run") {
+		t.Errorf("expected the records of the first run not to be overwritten, got %v", err)
+	}
+}
+
+func TestVerify_Truncated(t *testing.T) {
+	sink := &S3Sink{API: &fakeS3{}, Bucket: "bucket", Key: "audit.jsonl"}
+	l, err := Open("run", sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record(Entry{Kind: KindStart, Command: "wipe"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.End(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	heads := l.Heads()
+	if len(heads) != 1 {
+		t.Fatalf("expected the head of the sink, got %v", heads)
+	}
+
+	data := sink.data
+	if _, err := Verify(data, heads...); err != nil {
+		t.Errorf("expected the log to contain its head, got %v", err)
+	}
+
+	// without the end of the run, the chain is intact but the head is missing
+	truncated := data[:bytes.IndexByte(data, '\n')+1]
+	if _, err := Verify(truncated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(truncated, heads...); err == nil || !strings.Contains(err.Error(), "last records were removed") {
+		t.Errorf("expected the truncation to be detected, got %v", err)
+	}
+}
+
+func TestSplitS3(t *testing.T) {
+	if bucket, key, err := SplitS3("s3://bucket/audit/log.jsonl"); err != nil || bucket != "bucket" || key != "audit/log.jsonl" {
+		t.Errorf("unexpected bucket %q and key %q: %v", bucket, key, err)
+	}
+
+	for _, location := range []string{"s3://bucket", "s3:///key", "bucket/key"} {
+		if _, _, err := SplitS3(location); err == nil {
+			t.Errorf("expected an error for %s", location)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/spf13/afero"
+)
+
+// FileSink appends to a local file.
+type FileSink struct {
+	Path string
+	f    afero.File
+}
+
+// Read returns the content of the file.
+func (s *FileSink) Read() ([]byte, error) {
+	data, err := afero.ReadFile(config.AppFs, s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Append appends a line to the file, creating it if needed.
+func (s *FileSink) Append(line []byte) error {
+	if s.f == nil {
+		f, err := config.AppFs.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		s.f = f
+	}
+
+	_, err := s.f.Write(line)
+	return err
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	if s.f == nil {
+		return nil
+	}
+
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// S3Sink appends to an S3 object. As objects cannot be appended to, the object is read when opening the log and
+// written again with every appended line, so that a crashed run loses no records. Before writing, the ETag of the
+// object is compared to the one read or written last, so that a run fails instead of overwriting the records of a
+// concurrent one. Runs writing at the very same time may still overwrite each other; use a file sink or run one at a
+// time to rule that out.
+type S3Sink struct {
+	API    s3iface.S3API
+	Bucket string
+	Key    string
+
+	data []byte
+	// etag is the ETag of the object as read or written last, empty if it does not exist
+	etag string
+}
+
+// Read returns the content of the object.
+func (s *S3Sink) Read() ([]byte, error) {
+	output, err := s.API.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		s.data, s.etag = nil, ""
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	s.etag = aws.StringValue(output.ETag)
+	s.data, err = ioutil.ReadAll(output.Body)
+	return s.data, err
+}
+
+// Append appends a line and writes the object, unless it was changed since it was read or written last.
+func (s *S3Sink) Append(line []byte) error {
+	etag, err := s.currentETag()
+	if err != nil {
+		return err
+	}
+	if etag != s.etag {
+		return fmt.Errorf("s3://%s/%s was changed by another run since it was read", s.Bucket, s.Key)
+	}
+
+	data := append(s.data, line...)
+	output, err := s.API.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.data, s.etag = data, aws.StringValue(output.ETag)
+	return nil
+}
+
+// currentETag returns the ETag of the object, empty if it does not exist.
+func (s *S3Sink) currentETag() (string, error) {
+	output, err := s.API.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.ETag), nil
+}
+
+// Close does nothing, the object is written with every line.
+func (s *S3Sink) Close() error {
+	return nil
+}
+
+// NewSink returns the sink of a local path or an S3 object (s3://bucket/key). Objects are accessed with clients of the
+// region of their bucket, created with the given session.
+func NewSink(location string, p client.ConfigProvider, cfgs ...*aws.Config) (Sink, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		return &FileSink{Path: location}, nil
+	}
+
+	bucket, key, err := SplitS3(location)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := s3manager.GetBucketRegionWithClient(aws.BackgroundContext(), s3.New(p, cfgs...), bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Sink{
+		API:    s3.New(p, append(cfgs, &aws.Config{Region: aws.String(region)})...),
+		Bucket: bucket,
+		Key:    key,
+	}, nil
+}
+
+// SplitS3 returns the bucket and key of an S3 location s3://bucket/key.
+func SplitS3(location string) (bucket, key string, err error) {
+	bucketAndKey := strings.SplitN(strings.TrimPrefix(location, "s3://"), "/", 2)
+	if !strings.HasPrefix(location, "s3://") || len(bucketAndKey) != 2 || bucketAndKey[0] == "" || bucketAndKey[1] == "" {
+		return "", "", fmt.Errorf("expected s3://bucket/key, got %q", location)
+	}
+
+	return bucketAndKey[0], bucketAndKey[1], nil
+}
@@ -1,42 +1,224 @@
 package aws
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/cmpsoares91/awsweeper/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
 // FirstSeenDateTimeMarker tagKey to be used for resources that do not have creation date
 const FirstSeenDateTimeMarker = "aws-janitor:first-seen-date"
 
-// New ...
-func New(region string, maxRetries int, roleToAssume string) {
+// SessionOptions configures the AWS session and the credentials used to sweep a region.
+type SessionOptions struct {
+	MaxRetries           int
+	Profile              string
+	RoleToAssume         string
+	ExternalID           string
+	SessionName          string
+	Duration             time.Duration
+	MFASerial            string
+	WebIdentityTokenFile string
+	// AccountRole is assumed using the credentials above (role chaining), e.g. to sweep accounts of an organization,
+	// with its own external ID and session name.
+	AccountRole        string
+	AccountExternalID  string
+	AccountSessionName string
+
+	// Endpoints overrides the endpoint of a service by its endpoint ID (e.g. s3, ec2, dynamodb).
+	// The endpoint with the ID "default" is used for all services without an override.
+	Endpoints          map[string]string
+	S3ForcePathStyle   bool
+	DisableSSL         bool
+	InsecureSkipVerify bool
+
+	// BulkTags fetches the tags of all resources of a type with the Resource Groups Tagging API when listing them.
+	BulkTags bool
+
+	// PageSize is the number of resources requested per page when listing, 0 for the default of each API.
+	PageSize int64
+
+	// Record appends the requests and responses of the session to a cassette file.
+	Record string
+	// Replay serves the responses recorded in a cassette file instead of sending requests to AWS.
+	Replay string
+}
+
+// credentialsKey identifies the credentials created for a set of session options.
+type credentialsKey struct {
+	profile              string
+	roleToAssume         string
+	externalID           string
+	sessionName          string
+	duration             time.Duration
+	mfaSerial            string
+	webIdentityTokenFile string
+	accountRole          string
+	accountExternalID    string
+	accountSessionName   string
+}
+
+func (opts SessionOptions) credentialsKey() credentialsKey {
+	return credentialsKey{
+		profile:              opts.Profile,
+		roleToAssume:         opts.RoleToAssume,
+		externalID:           opts.ExternalID,
+		sessionName:          opts.SessionName,
+		mfaSerial:            opts.MFASerial,
+		webIdentityTokenFile: opts.WebIdentityTokenFile,
+		accountRole:          opts.AccountRole,
+		accountExternalID:    opts.AccountExternalID,
+		accountSessionName:   opts.AccountSessionName,
+		duration:             opts.Duration,
+	}
+}
+
+// assumedCredentials caches assumed role credentials across regions, so that e.g. an MFA token is only prompted for once.
+var assumedCredentials = make(map[credentialsKey]*credentials.Credentials)
+
+// endpointResolver resolves the endpoints overridden in the options and falls back to the default resolver.
+func endpointResolver(overrides map[string]string) endpoints.Resolver {
+	return endpoints.ResolverFunc(func(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		url, ok := overrides[service]
+		if !ok {
+			url, ok = overrides["default"]
+		}
+
+		if ok {
+			return endpoints.ResolvedEndpoint{
+				URL:           url,
+				SigningRegion: region,
+			}, nil
+		}
+
+		return endpoints.DefaultResolver().EndpointFor(service, region, optFns...)
+	})
+}
+
+// NewSession creates a session for the given region and the config to be used by service clients.
+// Assumed role credentials are set in the returned config rather than in the session.
+func NewSession(region string, opts SessionOptions) (*session.Session, *aws.Config, error) {
 	config := &aws.Config{
-		Region:     &region,
-		MaxRetries: &maxRetries,
+		Region:           &region,
+		MaxRetries:       &opts.MaxRetries,
+		S3ForcePathStyle: aws.Bool(opts.S3ForcePathStyle),
+		DisableSSL:       aws.Bool(opts.DisableSSL),
+	}
+
+	if len(opts.Endpoints) > 0 {
+		config.EndpointResolver = endpointResolver(opts.Endpoints)
 	}
 
-	sess, err := session.NewSession(config)
+	if opts.InsecureSkipVerify {
+		config.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:                  *config,
+		Profile:                 opts.Profile,
+		SharedConfigState:       session.SharedConfigEnable,
+		AssumeRoleTokenProvider: stscreds.StdinTokenProvider,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	metrics.Instrument(&sess.Handlers)
+	tracing.Instrument(&sess.Handlers)
+
+	if opts.Replay != "" {
+		player, err := playerFor(opts.Replay)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load cassette: %v", err)
+		}
+		player.Attach(&sess.Handlers)
+		config.Credentials = credentials.NewStaticCredentials("REPLAY", "REPLAY", "")
+		return sess, config, nil
+	}
+
+	if opts.Record != "" {
+		recorder, err := recorderFor(opts.Record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create cassette: %v", err)
+		}
+		recorder.Attach(&sess.Handlers)
+	}
+
+	if creds, ok := assumedCredentials[opts.credentialsKey()]; ok {
+		config.Credentials = creds
+	} else if opts.WebIdentityTokenFile != "" {
+		logrus.WithFields(logrus.Fields{
+			"role":       opts.RoleToAssume,
+			"token_file": opts.WebIdentityTokenFile,
+		}).Info("Assuming Role with web identity")
+		config.Credentials = stscreds.NewWebIdentityCredentials(sess, opts.RoleToAssume, opts.SessionName, opts.WebIdentityTokenFile)
+	} else if opts.RoleToAssume != "" {
+		logrus.WithField("role", opts.RoleToAssume).Info("Assuming Role")
+		config.Credentials = stscreds.NewCredentials(sess, opts.RoleToAssume, func(p *stscreds.AssumeRoleProvider) {
+			if opts.ExternalID != "" {
+				p.ExternalID = aws.String(opts.ExternalID)
+			}
+			if opts.SessionName != "" {
+				p.RoleSessionName = opts.SessionName
+			}
+			if opts.Duration != 0 {
+				p.Duration = opts.Duration
+			}
+			if opts.MFASerial != "" {
+				p.SerialNumber = aws.String(opts.MFASerial)
+				p.TokenProvider = stscreds.StdinTokenProvider
+			}
+		})
+	}
+
+	if _, ok := assumedCredentials[opts.credentialsKey()]; !ok && opts.AccountRole != "" {
+		logrus.WithField("role", opts.AccountRole).Info("Assuming Account Role")
+		base := sess.Copy(&aws.Config{Credentials: config.Credentials})
+		// the external ID and session name of the base role are not forwarded, the account role has its own
+		config.Credentials = stscreds.NewCredentials(base, opts.AccountRole, func(p *stscreds.AssumeRoleProvider) {
+			if opts.AccountExternalID != "" {
+				p.ExternalID = aws.String(opts.AccountExternalID)
+			}
+			if opts.AccountSessionName != "" {
+				p.RoleSessionName = opts.AccountSessionName
+			}
+		})
+	}
+
+	if config.Credentials != nil {
+		assumedCredentials[opts.credentialsKey()] = config.Credentials
+	}
+
+	return sess, config, nil
+}
+
+// New registers the resource types of a region with clients created for the session options.
+func New(region string, opts SessionOptions) error {
+	sess, config, err := NewSession(region, opts)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 
-	if roleToAssume != "" {
-		logrus.WithField("Role", roleToAssume).Info("Assuming Role")
-		config.Credentials = stscreds.NewCredentials(sess, roleToAssume)
+	clients := NewClients(sess, config)
+	if opts.BulkTags {
+		clients.ResourceGroupsTagging = newTaggingClient(sess, config)
 	}
 
-	register(sess, config, &EC2API{})
-	register(sess, config, &S3BucketAPI{})
-	register(sess, config, &DynamoDbTableApi{})
-	register(sess, config, &ElasticSearchDomainApi{})
-	register(sess, config, &KinesisDataStreamAPI{})
-	register(sess, config, &FirehoseAPI{})
-	register(sess, config, &RDSInstanceAPI{})
-	register(sess, config, &RDSClusterAPI{})
-	register(sess, config, &MediaLiveInputAPI{})
-	register(sess, config, &MediaLiveChannelAPI{})
+	pageSize = opts.PageSize
+	NewWithClients(clients)
+	return nil
 }
@@ -4,14 +4,11 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 )
 
 type XYZAPI struct {
-	api *s3.S3
+	api s3iface.S3API
 }
 
 func (a *XYZAPI) getType() ResourceType {
@@ -22,8 +19,8 @@ func (a *XYZAPI) getPriority() int64 {
 	return -1
 }
 
-func (a *XYZAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = s3.New(s, cfg)
+func (a *XYZAPI) new(c *Clients) {
+	a.api = c.S3
 }
 
 func (a *XYZAPI) list() (resources IResources, err error) {
@@ -35,7 +32,7 @@ type XYZ Resource
 
 // Delete ...
 func (r *XYZ) Delete() error {
-	logrus.WithField("XYZ", *r.Name).Info("Deleting XYZ")
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting XYZ")
 	return nil
 }
 
@@ -70,4 +67,4 @@ func (r *XYZ) GetTags() *Tags { return &r.Tags }
 func (r *XYZ) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *XYZ) EnsureLazyLoaded() {}
+func (r *XYZ) EnsureLazyLoaded() error { return nil }
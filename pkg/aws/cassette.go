@@ -0,0 +1,297 @@
+package aws
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/sirupsen/logrus"
+)
+
+// SanitizedAccountID replaces the account IDs in recorded AWS traffic.
+const SanitizedAccountID = "123456789012"
+
+// ErrCodeCassetteMiss is the error code of requests without a recorded response when replaying a cassette.
+const ErrCodeCassetteMiss = "CassetteMiss"
+
+// Interaction is a request to AWS and its response, recorded in a cassette.
+type Interaction struct {
+	Region    string            `json:"region"`
+	Service   string            `json:"service"`
+	Operation string            `json:"operation"`
+	Method    string            `json:"method"`
+	Host      string            `json:"host"`
+	Path      string            `json:"path"`
+	Body      string            `json:"body,omitempty"`
+	Status    int               `json:"status"`
+	Header    map[string]string `json:"header,omitempty"`
+	Response  string            `json:"response,omitempty"`
+}
+
+var (
+	numbers       = regexp.MustCompile(`\d+`)
+	accessKeyIDs  = regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`)
+	accountIDSize = len(SanitizedAccountID)
+
+	// secrets of STS requests and responses, e.g. of assuming roles: in XML elements, JSON fields and query
+	// parameters
+	secretElements = regexp.MustCompile(`(<(SecretAccessKey|SessionToken)>)[^<]*(</)`)
+	secretFields   = regexp.MustCompile(`("(SecretAccessKey|SessionToken)"\s*:\s*")[^"]*(")`)
+	secretParams   = regexp.MustCompile(`\b((WebIdentityToken|TokenCode|SecretAccessKey|SessionToken)=)[^&]*`)
+)
+
+// Redacted replaces secrets in recorded AWS traffic.
+const Redacted = "REDACTED"
+
+// sanitize replaces account IDs (numbers of 12 digits) and access key IDs and redacts secret access keys, session
+// tokens, web identity tokens and MFA codes, e.g. of assuming roles. Request headers are never recorded.
+func sanitize(s string) string {
+	s = secretElements.ReplaceAllString(s, "${1}"+Redacted+"${3}")
+	s = secretFields.ReplaceAllString(s, "${1}"+Redacted+"${3}")
+	s = secretParams.ReplaceAllString(s, "${1}"+Redacted)
+
+	s = numbers.ReplaceAllStringFunc(s, func(n string) string {
+		if len(n) == accountIDSize {
+			return SanitizedAccountID
+		}
+		return n
+	})
+
+	return accessKeyIDs.ReplaceAllString(s, "${1}XXXXXXXXXXXXXXXX")
+}
+
+// newInteraction returns the sanitized interaction of a request, without its response.
+func newInteraction(r *request.Request) *Interaction {
+	var body []byte
+	if r.Body != nil {
+		if _, err := r.Body.Seek(0, io.SeekStart); err == nil {
+			body, _ = ioutil.ReadAll(r.Body)
+			r.Body.Seek(0, io.SeekStart)
+		}
+	}
+
+	return &Interaction{
+		Region:    aws.StringValue(r.Config.Region),
+		Service:   r.ClientInfo.ServiceName,
+		Operation: r.Operation.Name,
+		Method:    r.HTTPRequest.Method,
+		Host:      sanitize(r.HTTPRequest.URL.Host),
+		Path:      sanitize(r.HTTPRequest.URL.RequestURI()),
+		Body:      sanitize(string(body)),
+	}
+}
+
+// Recorder appends the AWS traffic of sessions to a cassette, one JSON interaction per line.
+type Recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder returns a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Attach records the requests sent with the handlers, e.g. of a session.
+func (rec *Recorder) Attach(h *request.Handlers) {
+	h.Send.PushBackNamed(request.NamedHandler{Name: "awsweeper.Recorder", Fn: rec.record})
+}
+
+func (rec *Recorder) record(r *request.Request) {
+	if r.HTTPResponse == nil {
+		return
+	}
+
+	response, err := ioutil.ReadAll(r.HTTPResponse.Body)
+	r.HTTPResponse.Body.Close()
+	r.HTTPResponse.Body = ioutil.NopCloser(bytes.NewReader(response))
+	if err != nil {
+		logrus.WithError(err).WithField("operation", r.Operation.Name).Warn("Failed to record a response")
+		return
+	}
+
+	i := newInteraction(r)
+	i.Status = r.HTTPResponse.StatusCode
+	i.Response = sanitize(string(response))
+	i.Header = make(map[string]string)
+	for k := range r.HTTPResponse.Header {
+		i.Header[k] = sanitize(r.HTTPResponse.Header.Get(k))
+	}
+
+	b, err := json.Marshal(i)
+	if err != nil {
+		logrus.WithError(err).WithField("operation", r.Operation.Name).Warn("Failed to record a response")
+		return
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if _, err := rec.w.Write(append(b, '\n')); err != nil {
+		logrus.WithError(err).WithField("operation", r.Operation.Name).Warn("Failed to record a response")
+	}
+}
+
+// Player serves the responses recorded in a cassette instead of sending requests to AWS.
+type Player struct {
+	mu           sync.Mutex
+	interactions []*Interaction
+	played       []bool
+}
+
+// NewPlayer reads the interactions of a cassette.
+func NewPlayer(r io.Reader) (*Player, error) {
+	p := &Player{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		i := &Interaction{}
+		if err := json.Unmarshal(scanner.Bytes(), i); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		p.interactions = append(p.interactions, i)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	p.played = make([]bool, len(p.interactions))
+	return p, nil
+}
+
+// Attach replaces sending the requests of the handlers, e.g. of a session, with the recorded responses.
+func (p *Player) Attach(h *request.Handlers) {
+	h.Send.Clear()
+	h.Send.PushBackNamed(request.NamedHandler{Name: "awsweeper.Player", Fn: p.play})
+}
+
+// Unplayed returns the recorded interactions which have not been played.
+func (p *Player) Unplayed() (unplayed []*Interaction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for n, i := range p.interactions {
+		if !p.played[n] {
+			unplayed = append(unplayed, i)
+		}
+	}
+	return unplayed
+}
+
+// match returns the first unplayed interaction for the same request. Requests which differ in their body only,
+// e.g. because of timestamps, match in the order they were recorded.
+// The caller must hold the lock.
+func (p *Player) match(req *Interaction) *Interaction {
+	similar := -1
+	for n, i := range p.interactions {
+		if p.played[n] || i.Region != req.Region || i.Service != req.Service || i.Operation != req.Operation ||
+			i.Method != req.Method || i.Host != req.Host || i.Path != req.Path {
+			continue
+		}
+
+		if i.Body == req.Body {
+			p.played[n] = true
+			return i
+		}
+
+		if similar < 0 {
+			similar = n
+		}
+	}
+
+	if similar < 0 {
+		return nil
+	}
+
+	p.played[similar] = true
+	return p.interactions[similar]
+}
+
+func (p *Player) play(r *request.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	req := newInteraction(r)
+	i := p.match(req)
+	if i == nil {
+		r.Error = awserr.New(ErrCodeCassetteMiss, fmt.Sprintf("no recorded response for %s %s in %s", req.Service, req.Operation, req.Region), nil)
+		r.Retryable = aws.Bool(false)
+		return
+	}
+
+	header := make(http.Header)
+	for k, v := range i.Header {
+		header.Set(k, v)
+	}
+
+	r.HTTPResponse = &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(i.Response)),
+		ContentLength: int64(len(i.Response)),
+	}
+}
+
+var (
+	cassettesMu sync.Mutex
+	recorders   = make(map[string]*Recorder)
+	players     = make(map[string]*Player)
+)
+
+// recorderFor returns the recorder writing to a cassette file, truncating the file the first time.
+func recorderFor(path string) (*Recorder, error) {
+	cassettesMu.Lock()
+	defer cassettesMu.Unlock()
+
+	if rec, ok := recorders[path]; ok {
+		return rec, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	recorders[path] = NewRecorder(f)
+	return recorders[path], nil
+}
+
+// playerFor returns the player of a cassette file, shared by all sessions replaying it.
+func playerFor(path string) (*Player, error) {
+	cassettesMu.Lock()
+	defer cassettesMu.Unlock()
+
+	if p, ok := players[path]; ok {
+		return p, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := NewPlayer(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	players[path] = p
+	return p, nil
+}
@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elasticsearchservice"
+	"github.com/aws/aws-sdk-go/service/elasticsearchservice/elasticsearchserviceiface"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/aws/aws-sdk-go/service/medialive"
+	"github.com/aws/aws-sdk-go/service/medialive/medialiveiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// Clients are the service clients of a region used by the resource types.
+// They can be replaced by fakes implementing the same interfaces, see NewWithClients.
+type Clients struct {
+	Region string
+
+	CloudFormation       cloudformationiface.CloudFormationAPI
+	DynamoDB             dynamodbiface.DynamoDBAPI
+	EC2                  ec2iface.EC2API
+	ElasticsearchService elasticsearchserviceiface.ElasticsearchServiceAPI
+	Firehose             firehoseiface.FirehoseAPI
+	Kinesis              kinesisiface.KinesisAPI
+	MediaLive            medialiveiface.MediaLiveAPI
+	RDS                  rdsiface.RDSAPI
+	S3                   s3iface.S3API
+
+	// ResourceGroupsTagging fetches tags in bulk if set.
+	ResourceGroupsTagging resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+}
+
+// NewClients creates the service clients for the region of the config.
+func NewClients(s *session.Session, cfg *aws.Config) *Clients {
+	k := kinesis.New(s, cfg)
+
+	// Addressing https://github.com/aws/aws-sdk-go/issues/1376
+	k.Handlers.Retry.PushBack(func(r *request.Request) {
+		err, ok := r.Error.(awserr.Error)
+		if !ok || err == nil {
+			return
+		}
+		if err.Code() == kinesis.ErrCodeLimitExceededException {
+			r.Retryable = aws.Bool(true)
+		}
+	})
+
+	return &Clients{
+		Region:               aws.StringValue(cfg.Region),
+		CloudFormation:       cloudformation.New(s, cfg),
+		DynamoDB:             dynamodb.New(s, cfg),
+		EC2:                  ec2.New(s, cfg),
+		ElasticsearchService: elasticsearchservice.New(s, cfg),
+		Firehose:             firehose.New(s, cfg),
+		Kinesis:              k,
+		MediaLive:            medialive.New(s, cfg),
+		RDS:                  rds.New(s, cfg),
+		S3:                   s3.New(s, cfg),
+	}
+}
+
+// NewWithClients registers all supported resource types using the given clients.
+func NewWithClients(clients *Clients) {
+	for _, r := range resourceTypes() {
+		register(clients, r)
+	}
+
+	taggingAPI = clients.ResourceGroupsTagging
+}
+
+// newTaggingClient creates the client of the Resource Groups Tagging API.
+func newTaggingClient(s *session.Session, cfg *aws.Config) resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
+	return resourcegroupstaggingapi.New(s, cfg)
+}
@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CloudFormationStackType is the type of CloudFormation stacks.
+	CloudFormationStackType ResourceType = "cloudformation_stack"
+
+	// CloudFormationStackNameTag is the tag CloudFormation adds to the resources of a stack.
+	CloudFormationStackNameTag = "aws:cloudformation:stack-name"
+)
+
+type CloudFormationStackAPI struct {
+	api cloudformationiface.CloudFormationAPI
+}
+
+func (a *CloudFormationStackAPI) getType() ResourceType {
+	return CloudFormationStackType
+}
+
+func (a *CloudFormationStackAPI) getPriority() int64 {
+	return 9990
+}
+
+func (a *CloudFormationStackAPI) new(c *Clients) {
+	a.api = c.CloudFormation
+}
+
+func (a *CloudFormationStackAPI) list() (resources IResources, err error) {
+	err = a.api.DescribeStacksPages(&cloudformation.DescribeStacksInput{}, func(page *cloudformation.DescribeStacksOutput, lastPage bool) bool {
+		for _, stack := range page.Stacks {
+			if aws.StringValue(stack.StackStatus) == cloudformation.StackStatusDeleteComplete {
+				continue
+			}
+
+			r := &CloudFormationStack{
+				Name:         stack.StackName,
+				ID:           stack.StackName,
+				Tags:         make(Tags),
+				CreationDate: stack.CreationTime,
+				Status:       stack.StackStatus,
+				ResourceType: a.getType(),
+				api:          a.api,
+			}
+			for _, tag := range stack.Tags {
+				r.Tags[*tag.Key] = *tag.Value
+			}
+			resources = append(resources, r)
+		}
+		return true
+	})
+
+	return resources, err
+}
+
+// CloudFormationStack ...
+type CloudFormationStack Resource
+
+// Delete deletes the stack with all its resources. A stack whose deletion failed before is deleted
+// retaining the resources which failed to be deleted, which are logged to be cleaned up by hand.
+func (r *CloudFormationStack) Delete() error {
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting CloudFormationStack")
+	api := r.api.(cloudformationiface.CloudFormationAPI)
+
+	input := &cloudformation.DeleteStackInput{StackName: r.ID}
+	if aws.StringValue(r.Status) == cloudformation.StackStatusDeleteFailed {
+		resources, err := api.DescribeStackResources(&cloudformation.DescribeStackResourcesInput{StackName: r.ID})
+		if err != nil {
+			return err
+		}
+
+		for _, resource := range resources.StackResources {
+			if aws.StringValue(resource.ResourceStatus) != cloudformation.ResourceStatusDeleteFailed {
+				continue
+			}
+
+			logResource(r.ResourceType, r.ID, "delete").WithFields(logrus.Fields{
+				"logical_resource_id":  aws.StringValue(resource.LogicalResourceId),
+				"physical_resource_id": aws.StringValue(resource.PhysicalResourceId),
+				"reason":               aws.StringValue(resource.ResourceStatusReason),
+			}).Warn("Retaining resource which failed to be deleted with its stack")
+			input.RetainResources = append(input.RetainResources, resource.LogicalResourceId)
+		}
+	}
+
+	if _, err := api.DeleteStack(input); err != nil {
+		return err
+	}
+
+	logResource(r.ResourceType, r.ID, "delete").Info("CloudFormationStack deletion started")
+	return nil
+}
+
+// String ...
+func (r *CloudFormationStack) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// GetID ...
+func (r *CloudFormationStack) GetID() string {
+	if r.ID != nil {
+		return *r.ID
+	}
+
+	return ""
+}
+
+// GetName ...
+func (r *CloudFormationStack) GetName() string {
+	if r.Name != nil {
+		return *r.Name
+	}
+
+	return ""
+}
+
+// GetTags ...
+func (r *CloudFormationStack) GetTags() *Tags { return &r.Tags }
+
+// GetCreationDate ...
+func (r *CloudFormationStack) GetCreationDate() *time.Time { return r.CreationDate }
+
+// EnsureLazyLoaded ...
+func (r *CloudFormationStack) EnsureLazyLoaded() error { return nil }
@@ -2,16 +2,20 @@ package aws
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/sirupsen/logrus"
 )
 
 type DynamoDbTableApi struct {
-	api *dynamodb.DynamoDB
+	api dynamodbiface.DynamoDBAPI
 }
 
 func (a *DynamoDbTableApi) getType() ResourceType {
@@ -22,39 +26,22 @@ func (a *DynamoDbTableApi) getPriority() int64 {
 	return 9710
 }
 
-func (a *DynamoDbTableApi) new(s *session.Session, cfg *aws.Config) {
-	a.api = dynamodb.New(s, cfg)
+func (a *DynamoDbTableApi) new(c *Clients) {
+	a.api = c.DynamoDB
 }
 
-func (a *DynamoDbTableApi) listAll(lastEvaluatedTableName *string) (tables []*string, err error) {
-	if lastEvaluatedTableName != nil {
-		logrus.WithField("lastEvaluatedTableName", *lastEvaluatedTableName).Warn("Listing DDB tables")
-	}
-
-	listTableOutput, err := a.api.ListTables(&dynamodb.ListTablesInput{
-		ExclusiveStartTableName: lastEvaluatedTableName,
+func (a *DynamoDbTableApi) listAll() (tables []*string, err error) {
+	input := &dynamodb.ListTablesInput{Limit: limit(1, 100)}
+	err = a.api.ListTablesPages(input, func(page *dynamodb.ListTablesOutput, lastPage bool) bool {
+		tables = append(tables, page.TableNames...)
+		return true
 	})
 
-	if err != nil {
-		return tables, err
-	}
-
-	tables = append(tables, listTableOutput.TableNames...)
-
-	if listTableOutput.LastEvaluatedTableName != nil {
-		nextPageTables, err := a.listAll(listTableOutput.LastEvaluatedTableName)
-		if err != nil {
-			return tables, err
-		} else {
-			tables = append(tables, nextPageTables...)
-		}
-	}
-
 	return tables, err
 }
 
 func (a *DynamoDbTableApi) list() (IResources, error) {
-	tables, err := a.listAll(nil)
+	tables, err := a.listAll()
 	if err != nil {
 		return nil, err
 	}
@@ -79,20 +66,158 @@ type DynamoDbTable Resource
 
 // Delete ...
 func (r *DynamoDbTable) Delete() error {
-	logrus.WithField("DDB Table", *r.Name).Info("Deleting a DDB Table")
-	api := r.api.(*dynamodb.DynamoDB)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting a DDB Table")
+	api := r.api.(dynamodbiface.DynamoDBAPI)
 	result, err := api.DeleteTable(&dynamodb.DeleteTableInput{TableName: r.ID})
 	if err != nil {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("DDB Table deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("DDB Table deleted")
 	return nil
 }
 
+// Stop ...
+func (r *DynamoDbTable) Stop(target ScaleDown) (ResourceState, error) {
+	api := r.api.(dynamodbiface.DynamoDBAPI)
+	tableDesc, err := api.DescribeTable(&dynamodb.DescribeTableInput{TableName: r.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	table := tableDesc.Table
+	if table.BillingModeSummary != nil && aws.StringValue(table.BillingModeSummary.BillingMode) == dynamodb.BillingModePayPerRequest {
+		logResource(r.ResourceType, r.ID, "stop").Info("DDB Table is on-demand. Nothing to scale down")
+		return ResourceState{"billing_mode": dynamodb.BillingModePayPerRequest}, nil
+	}
+
+	readCapacity := aws.Int64Value(table.ProvisionedThroughput.ReadCapacityUnits)
+	writeCapacity := aws.Int64Value(table.ProvisionedThroughput.WriteCapacityUnits)
+	state := ResourceState{
+		"billing_mode":   dynamodb.BillingModeProvisioned,
+		"read_capacity":  strconv.FormatInt(readCapacity, 10),
+		"write_capacity": strconv.FormatInt(writeCapacity, 10),
+	}
+
+	input := &dynamodb.UpdateTableInput{TableName: r.ID}
+	if readCapacity != target.ReadCapacity || writeCapacity != target.WriteCapacity {
+		input.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(target.ReadCapacity),
+			WriteCapacityUnits: aws.Int64(target.WriteCapacity),
+		}
+	}
+
+	// global secondary indexes have a provisioned capacity of their own
+	for _, index := range table.GlobalSecondaryIndexes {
+		if index.ProvisionedThroughput == nil {
+			continue
+		}
+
+		name := aws.StringValue(index.IndexName)
+		indexRead := aws.Int64Value(index.ProvisionedThroughput.ReadCapacityUnits)
+		indexWrite := aws.Int64Value(index.ProvisionedThroughput.WriteCapacityUnits)
+		state[indexCapacityKey(name, "read_capacity")] = strconv.FormatInt(indexRead, 10)
+		state[indexCapacityKey(name, "write_capacity")] = strconv.FormatInt(indexWrite, 10)
+
+		if indexRead != target.ReadCapacity || indexWrite != target.WriteCapacity {
+			input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates,
+				updateIndexCapacity(name, target.ReadCapacity, target.WriteCapacity))
+		}
+	}
+
+	if input.ProvisionedThroughput == nil && len(input.GlobalSecondaryIndexUpdates) == 0 {
+		logResource(r.ResourceType, r.ID, "stop").Info("DDB Table is already scaled down")
+		return state, nil
+	}
+
+	logResource(r.ResourceType, r.ID, "stop").WithFields(logrus.Fields{
+		"read_capacity":  target.ReadCapacity,
+		"write_capacity": target.WriteCapacity,
+		"indexes":        len(input.GlobalSecondaryIndexUpdates),
+	}).Info("Scaling down a DDB Table")
+	if _, err := api.UpdateTable(input); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Start ...
+func (r *DynamoDbTable) Start(state ResourceState) error {
+	if state["billing_mode"] != dynamodb.BillingModeProvisioned {
+		logResource(r.ResourceType, r.ID, "start").Info("DDB Table was not scaled down. Nothing to restore")
+		return nil
+	}
+
+	readCapacity, err := strconv.ParseInt(state["read_capacity"], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	writeCapacity, err := strconv.ParseInt(state["write_capacity"], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UpdateTableInput{
+		TableName: r.ID,
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(readCapacity),
+			WriteCapacityUnits: aws.Int64(writeCapacity),
+		},
+	}
+
+	var indexes []string
+	for key := range state {
+		if strings.HasPrefix(key, indexCapacityPrefix) && strings.HasSuffix(key, "/read_capacity") {
+			indexes = append(indexes, strings.TrimSuffix(strings.TrimPrefix(key, indexCapacityPrefix), "/read_capacity"))
+		}
+	}
+	sort.Strings(indexes)
+
+	for _, name := range indexes {
+		indexRead, err := strconv.ParseInt(state[indexCapacityKey(name, "read_capacity")], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		indexWrite, err := strconv.ParseInt(state[indexCapacityKey(name, "write_capacity")], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, updateIndexCapacity(name, indexRead, indexWrite))
+	}
+
+	logResource(r.ResourceType, r.ID, "start").WithFields(logrus.Fields{
+		"read_capacity":  readCapacity,
+		"write_capacity": writeCapacity,
+		"indexes":        len(indexes),
+	}).Info("Restoring capacity of a DDB Table")
+	api := r.api.(dynamodbiface.DynamoDBAPI)
+	_, err = api.UpdateTable(input)
+	return err
+}
+
+// indexCapacityPrefix prefixes the keys of the state which hold the capacity of a global secondary index.
+const indexCapacityPrefix = "index/"
+
+func indexCapacityKey(index, capacity string) string {
+	return indexCapacityPrefix + index + "/" + capacity
+}
+
+func updateIndexCapacity(index string, readCapacity, writeCapacity int64) *dynamodb.GlobalSecondaryIndexUpdate {
+	return &dynamodb.GlobalSecondaryIndexUpdate{
+		Update: &dynamodb.UpdateGlobalSecondaryIndexAction{
+			IndexName: aws.String(index),
+			ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+				ReadCapacityUnits:  aws.Int64(readCapacity),
+				WriteCapacityUnits: aws.Int64(writeCapacity),
+			},
+		},
+	}
+}
+
 // String ...
 func (r *DynamoDbTable) String() string {
 	b, _ := json.Marshal(r)
@@ -124,24 +249,27 @@ func (r *DynamoDbTable) GetTags() *Tags { return &r.Tags }
 func (r *DynamoDbTable) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *DynamoDbTable) EnsureLazyLoaded() {
-	if !r.lazyLoadPerformed {
-		logrus.WithField("resource", r).Debug("Performing a lazyload on a ddb table")
-		api := r.api.(*dynamodb.DynamoDB)
-		if tableDesc, err := api.DescribeTable(&dynamodb.DescribeTableInput{TableName: r.ID}); err == nil {
-			r.CreationDate = tableDesc.Table.CreationDateTime
-			logrus.WithField("tableDesc", tableDesc).Debug("tableDesc")
-			if listTagsOutput, err := api.ListTagsOfResource(&dynamodb.ListTagsOfResourceInput{ResourceArn: tableDesc.Table.TableArn}); err == nil {
-				for _, tag := range listTagsOutput.Tags {
-					r.Tags[*tag.Key] = *tag.Value
-				}
-			} else {
-				logrus.WithError(err).Fatal("Failed to load ddb table Tags")
+func (r *DynamoDbTable) EnsureLazyLoaded() error {
+	return (*Resource)(r).lazyLoad(func() error {
+		logResource(r.ResourceType, r.ID, "lazy-load").Debug("Performing a lazyload on a ddb table")
+		api := r.api.(dynamodbiface.DynamoDBAPI)
+		tableDesc, err := api.DescribeTable(&dynamodb.DescribeTableInput{TableName: r.ID})
+		if err != nil {
+			return fmt.Errorf("failed to load ddb table description: %v", err)
+		}
+
+		r.CreationDate = tableDesc.Table.CreationDateTime
+		if !r.tagsLoaded {
+			listTagsOutput, err := api.ListTagsOfResource(&dynamodb.ListTagsOfResourceInput{ResourceArn: tableDesc.Table.TableArn})
+			if err != nil {
+				return fmt.Errorf("failed to load ddb table tags: %v", err)
+			}
+
+			for _, tag := range listTagsOutput.Tags {
+				r.Tags[*tag.Key] = *tag.Value
 			}
-		} else {
-			logrus.WithError(err).Fatal("Failed to load ddb table descriptions")
 		}
 
-		r.lazyLoadPerformed = true
-	}
+		return nil
+	})
 }
@@ -5,14 +5,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 )
 
 // EC2API ...
 type EC2API struct {
-	api *ec2.EC2
+	api ec2iface.EC2API
 }
 
 func (a *EC2API) getType() ResourceType {
@@ -23,12 +22,12 @@ func (a *EC2API) getPriority() int64 {
 	return 9980
 }
 
-func (a *EC2API) new(s *session.Session, cfg *aws.Config) {
-	a.api = ec2.New(s, cfg)
+func (a *EC2API) new(c *Clients) {
+	a.api = c.EC2
 }
 
-func (a *EC2API) list() (IResources, error) {
-	output, err := a.api.DescribeInstances(&ec2.DescribeInstancesInput{
+func (a *EC2API) list() (resources IResources, err error) {
+	input := &ec2.DescribeInstancesInput{
 		Filters: []*ec2.Filter{
 			{
 				Name: aws.String("instance-state-name"),
@@ -38,35 +37,38 @@ func (a *EC2API) list() (IResources, error) {
 				},
 			},
 		},
-	})
-
-	if err != nil {
-		return nil, err
+		MaxResults: limit(5, 1000),
 	}
 
-	var resources IResources
-	for _, rsv := range output.Reservations {
-		for _, instance := range rsv.Instances {
-			var resource = &Instance{
-				Name:         nil,
-				ID:           instance.InstanceId,
-				Tags:         make(Tags),
-				CreationDate: instance.LaunchTime,
-				ResourceType: a.getType(),
-				api:          a.api,
-			}
+	err = a.api.DescribeInstancesPages(input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, rsv := range page.Reservations {
+			for _, instance := range rsv.Instances {
+				var resource = &Instance{
+					Name:         nil,
+					ID:           instance.InstanceId,
+					Tags:         make(Tags),
+					CreationDate: instance.LaunchTime,
+					Status:       instance.State.Name,
+					ResourceType: a.getType(),
+					api:          a.api,
+				}
 
-			for _, tag := range instance.Tags {
-				resource.Tags[*tag.Key] = *tag.Value
+				for _, tag := range instance.Tags {
+					resource.Tags[*tag.Key] = *tag.Value
 
-				if *tag.Key == "Name" {
-					resource.Name = tag.Value
+					if *tag.Key == "Name" {
+						resource.Name = tag.Value
+					}
 				}
-			}
 
-			resources = append(resources, resource)
+				resources = append(resources, resource)
+			}
 		}
+		return true
+	})
 
+	if err != nil {
+		return nil, err
 	}
 
 	return resources, nil
@@ -101,8 +103,8 @@ func (r *Instance) GetCreationDate() *time.Time { return r.CreationDate }
 
 // Delete ...
 func (r *Instance) Delete() error {
-	logrus.WithField("EC2", *r.Name).Info("Deleting an EC2")
-	api := r.api.(*ec2.EC2)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting an EC2")
+	api := r.api.(ec2iface.EC2API)
 
 	result, err := api.TerminateInstances(&ec2.TerminateInstancesInput{
 		InstanceIds: []*string{r.ID},
@@ -114,17 +116,50 @@ func (r *Instance) Delete() error {
 
 	if result.TerminatingInstances != nil {
 		for _, ti := range result.TerminatingInstances {
-			logrus.WithFields(logrus.Fields{
-				"CurrentState":  *ti.CurrentState,
-				"PreviousState": *ti.PreviousState,
-				"InstanceId":    *ti.InstanceId,
-			}).Info("Instance is terminating")
+			logger := logResource(r.ResourceType, ti.InstanceId, "delete")
+			if ti.CurrentState != nil {
+				logger = logger.WithField("current_state", aws.StringValue(ti.CurrentState.Name))
+			}
+			if ti.PreviousState != nil {
+				logger = logger.WithField("previous_state", aws.StringValue(ti.PreviousState.Name))
+			}
+			logger.Info("Instance is terminating")
 		}
 	}
 
 	return nil
 }
 
+// Stop ...
+func (r *Instance) Stop(ScaleDown) (ResourceState, error) {
+	state := ResourceState{"status": aws.StringValue(r.Status)}
+	if aws.StringValue(r.Status) != ec2.InstanceStateNameRunning {
+		logResource(r.ResourceType, r.ID, "stop").Info("Instance is not running. Nothing to stop")
+		return state, nil
+	}
+
+	logResource(r.ResourceType, r.ID, "stop").Info("Stopping an EC2")
+	api := r.api.(ec2iface.EC2API)
+	if _, err := api.StopInstances(&ec2.StopInstancesInput{InstanceIds: []*string{r.ID}}); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Start ...
+func (r *Instance) Start(state ResourceState) error {
+	if state["status"] != ec2.InstanceStateNameRunning {
+		logResource(r.ResourceType, r.ID, "start").Info("Instance was not running before it was stopped. Not starting it")
+		return nil
+	}
+
+	logResource(r.ResourceType, r.ID, "start").Info("Starting an EC2")
+	api := r.api.(ec2iface.EC2API)
+	_, err := api.StartInstances(&ec2.StartInstancesInput{InstanceIds: []*string{r.ID}})
+	return err
+}
+
 // String ...
 func (r *Instance) String() string {
 	b, _ := json.Marshal(r)
@@ -132,4 +167,4 @@ func (r *Instance) String() string {
 }
 
 // EnsureLazyLoaded ...
-func (r *Instance) EnsureLazyLoaded() {}
+func (r *Instance) EnsureLazyLoaded() error { return nil }
@@ -2,16 +2,15 @@ package aws
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/elasticsearchservice"
-	"github.com/sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/elasticsearchservice/elasticsearchserviceiface"
 )
 
 type ElasticSearchDomainApi struct {
-	api *elasticsearchservice.ElasticsearchService
+	api elasticsearchserviceiface.ElasticsearchServiceAPI
 }
 
 func (a *ElasticSearchDomainApi) getType() ResourceType {
@@ -19,14 +18,15 @@ func (a *ElasticSearchDomainApi) getType() ResourceType {
 }
 
 func (a *ElasticSearchDomainApi) getPriority() int64 {
-	return -1
+	return 9700
 }
 
-func (a *ElasticSearchDomainApi) new(s *session.Session, cfg *aws.Config) {
-	a.api = elasticsearchservice.New(s, cfg)
+func (a *ElasticSearchDomainApi) new(c *Clients) {
+	a.api = c.ElasticsearchService
 }
 
 func (a *ElasticSearchDomainApi) list() (resources IResources, err error) {
+	// ListDomainNames returns all domains at once
 	listDomainNamesOutput, err := a.api.ListDomainNames(&elasticsearchservice.ListDomainNamesInput{})
 	if err != nil {
 		return resources, err
@@ -52,17 +52,14 @@ type ElasticSearchDomain Resource
 
 // Delete ...
 func (r *ElasticSearchDomain) Delete() error {
-	logrus.WithField("ElasticSearchDomain", *r.Name).Info("Deleting ElasticSearchDomain")
-	api := r.api.(*elasticsearchservice.ElasticsearchService)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting ElasticSearchDomain")
+	api := r.api.(elasticsearchserviceiface.ElasticsearchServiceAPI)
 	result, err := api.DeleteElasticsearchDomain(&elasticsearchservice.DeleteElasticsearchDomainInput{DomainName: r.ID})
 	if err != nil {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("ElasticSearchDomain deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("ElasticSearchDomain deleted")
 	return nil
 }
 
@@ -97,33 +94,35 @@ func (r *ElasticSearchDomain) GetTags() *Tags { return &r.Tags }
 func (r *ElasticSearchDomain) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *ElasticSearchDomain) EnsureLazyLoaded() {
-	if !r.lazyLoadPerformed {
-		logrus.WithField("resource", r).Debug("Performing a lazyload on a elastic search domain")
-		api := r.api.(*elasticsearchservice.ElasticsearchService)
+func (r *ElasticSearchDomain) EnsureLazyLoaded() error {
+	return (*Resource)(r).lazyLoad(func() error {
+		logResource(r.ResourceType, r.ID, "lazy-load").Debug("Performing a lazyload on a elastic search domain")
+		api := r.api.(elasticsearchserviceiface.ElasticsearchServiceAPI)
 		domainDesc, err := api.DescribeElasticsearchDomain(&elasticsearchservice.DescribeElasticsearchDomainInput{DomainName: r.ID})
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load ESD description")
+			return fmt.Errorf("failed to load ESD description: %v", err)
 		}
 
 		configOutput, err := api.DescribeElasticsearchDomainConfig(&elasticsearchservice.DescribeElasticsearchDomainConfigInput{DomainName: r.ID})
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load ESD config")
+			return fmt.Errorf("failed to load ESD config: %v", err)
 		}
 
 		r.CreationDate = configOutput.DomainConfig.AdvancedOptions.Status.CreationDate
 
-		tagsOutput, err := api.ListTags(&elasticsearchservice.ListTagsInput{ARN: domainDesc.DomainStatus.ARN})
-		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load ESD tags")
-		}
+		if !r.tagsLoaded {
+			tagsOutput, err := api.ListTags(&elasticsearchservice.ListTagsInput{ARN: domainDesc.DomainStatus.ARN})
+			if err != nil {
+				return fmt.Errorf("failed to load ESD tags: %v", err)
+			}
 
-		if tagsOutput.TagList != nil {
-			for _, tag := range tagsOutput.TagList {
-				r.Tags[*tag.Key] = *tag.Value
+			if tagsOutput.TagList != nil {
+				for _, tag := range tagsOutput.TagList {
+					r.Tags[*tag.Key] = *tag.Value
+				}
 			}
 		}
 
-		r.lazyLoadPerformed = true
-	}
+		return nil
+	})
 }
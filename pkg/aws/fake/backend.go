@@ -0,0 +1,347 @@
+// Package fake is an in-memory stand-in for the AWS services swept by awsweeper, to run sweeps end-to-end without
+// network. It keeps the state of resources (tags, creation dates, dependencies between resources and pending
+// deletions) and can throttle operations.
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsweeper "github.com/cmpsoares91/awsweeper/pkg/aws"
+)
+
+// AccountID is the ID of the account in the ARNs of fake resources.
+const AccountID = "123456789012"
+
+// Kind is the kind of a resource, named like the resource type filters of the Resource Groups Tagging API.
+type Kind string
+
+const (
+	EC2Instance            Kind = "ec2:instance"
+	S3Bucket               Kind = "s3"
+	DynamoDBTable          Kind = "dynamodb:table"
+	ElasticsearchDomain    Kind = "es:domain"
+	KinesisStream          Kind = "kinesis:stream"
+	FirehoseDeliveryStream Kind = "firehose:deliverystream"
+	RDSInstance            Kind = "rds:db"
+	RDSCluster             Kind = "rds:cluster"
+	MediaLiveInput         Kind = "medialive:input"
+	MediaLiveChannel       Kind = "medialive:channel"
+)
+
+// errorCodes are the error codes returned by the service of a kind of resources.
+type errorCodes struct {
+	// notFound is returned for resources which don't exist
+	notFound string
+	// inUse is returned when deleting a resource others depend on or which is already being deleted
+	inUse string
+}
+
+var kinds = map[Kind]errorCodes{
+	EC2Instance:            {notFound: "InvalidInstanceID.NotFound", inUse: "DependencyViolation"},
+	S3Bucket:               {notFound: "NoSuchBucket", inUse: "BucketNotEmpty"},
+	DynamoDBTable:          {notFound: "ResourceNotFoundException", inUse: "ResourceInUseException"},
+	ElasticsearchDomain:    {notFound: "ResourceNotFoundException", inUse: "ConflictException"},
+	KinesisStream:          {notFound: "ResourceNotFoundException", inUse: "ResourceInUseException"},
+	FirehoseDeliveryStream: {notFound: "ResourceNotFoundException", inUse: "ResourceInUseException"},
+	RDSInstance:            {notFound: "DBInstanceNotFound", inUse: "InvalidDBInstanceState"},
+	RDSCluster:             {notFound: "DBClusterNotFoundFault", inUse: "InvalidDBClusterStateFault"},
+	MediaLiveInput:         {notFound: "NotFoundException", inUse: "ConflictException"},
+	MediaLiveChannel:       {notFound: "NotFoundException", inUse: "ConflictException"},
+}
+
+// Resource is a resource of the backend.
+type Resource struct {
+	Kind   Kind
+	Region string
+	ID     string
+	Tags   map[string]string
+	// Created is the creation date, reported by the services which have one.
+	Created time.Time
+	// DependsOn are the IDs of the resources which cannot be deleted while this one exists, e.g. the cluster of
+	// an RDS instance, the input of a MediaLive channel or the source stream of a Firehose delivery stream.
+	DependsOn []string
+	// Stopped is whether an EC2 instance is stopped.
+	Stopped bool
+
+	// deletedAt is the clock of the backend when the deletion started, 0 if the resource is not being deleted
+	deletedAt int
+}
+
+// Deleting returns whether the resource is being deleted.
+func (r *Resource) Deleting() bool {
+	return r.deletedAt > 0
+}
+
+// ARN returns the ARN of the resource.
+func (r *Resource) ARN() string {
+	switch r.Kind {
+	case S3Bucket:
+		return "arn:aws:s3:::" + r.ID
+	case EC2Instance:
+		return r.arn("ec2", "instance/")
+	case DynamoDBTable:
+		return r.arn("dynamodb", "table/")
+	case ElasticsearchDomain:
+		return r.arn("es", "domain/")
+	case KinesisStream:
+		return r.arn("kinesis", "stream/")
+	case FirehoseDeliveryStream:
+		return r.arn("firehose", "deliverystream/")
+	case RDSInstance:
+		return r.arn("rds", "db:")
+	case RDSCluster:
+		return r.arn("rds", "cluster:")
+	case MediaLiveInput:
+		return r.arn("medialive", "input:")
+	default:
+		return r.arn("medialive", "channel:")
+	}
+}
+
+func (r *Resource) arn(service, prefix string) string {
+	return fmt.Sprintf("arn:aws:%s:%s:%s:%s%s", service, r.Region, AccountID, prefix, r.ID)
+}
+
+// Backend holds the resources of all regions of an account. It is safe for concurrent use.
+type Backend struct {
+	// DeleteDelay is the number of calls to the backend a deleted resource stays in a deleting state before it is gone.
+	// S3 buckets are deleted immediately.
+	DeleteDelay int
+
+	// BulkTags provides the Resource Groups Tagging API to the clients, which fetches tags in bulk when listing.
+	BulkTags bool
+
+	mu        sync.Mutex
+	clock     int
+	resources []*Resource
+	throttled map[string]int
+	calls     []string
+}
+
+// New returns an empty backend.
+func New() *Backend {
+	return &Backend{throttled: make(map[string]int)}
+}
+
+// Add adds a resource to the backend and returns it. Resources without a creation date are created now.
+func (b *Backend) Add(r Resource) *Resource {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := kinds[r.Kind]; !ok {
+		panic(fmt.Sprintf("unknown kind of resource %s", r.Kind))
+	}
+	if r.Tags == nil {
+		r.Tags = make(map[string]string)
+	}
+	if r.Created.IsZero() {
+		r.Created = time.Now().UTC().Truncate(time.Second)
+	}
+
+	b.resources = append(b.resources, &r)
+	return &r
+}
+
+// Throttle fails the next calls of an operation (e.g. DeleteTable) with a throttling error.
+func (b *Backend) Throttle(operation string, times int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.throttled[operation] += times
+}
+
+// Calls returns the operations called so far, in order. Operations on a resource are followed by its ID,
+// e.g. "DeleteTable my-table".
+func (b *Backend) Calls() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]string(nil), b.calls...)
+}
+
+// IDs returns the sorted IDs of the resources of a kind in a region, including the ones being deleted.
+func (b *Backend) IDs(kind Kind, region string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var ids []string
+	for _, r := range b.list(kind, region) {
+		ids = append(ids, r.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Clients returns the clients of the services of a region, backed by b.
+func (b *Backend) Clients(region string) *awsweeper.Clients {
+	c := &awsweeper.Clients{
+		Region:               region,
+		DynamoDB:             &dynamoDB{backend: b, region: region},
+		EC2:                  &ec2API{backend: b, region: region},
+		ElasticsearchService: &elasticsearchService{backend: b, region: region},
+		Firehose:             &firehoseAPI{backend: b, region: region},
+		Kinesis:              &kinesisAPI{backend: b, region: region},
+		MediaLive:            &mediaLive{backend: b, region: region},
+		RDS:                  &rdsAPI{backend: b, region: region},
+		S3:                   &s3API{backend: b, region: region},
+	}
+
+	if b.BulkTags {
+		c.ResourceGroupsTagging = &resourceGroupsTagging{backend: b, region: region}
+	}
+
+	return c
+}
+
+// call records a call of an operation and advances the clock of the backend, completing the deletions which
+// took long enough. It returns a throttling error if the operation is throttled.
+// The caller must hold the lock.
+func (b *Backend) call(operation string, id *string) error {
+	b.clock++
+	if id != nil {
+		b.calls = append(b.calls, operation+" "+*id)
+	} else {
+		b.calls = append(b.calls, operation)
+	}
+
+	var remaining []*Resource
+	for _, r := range b.resources {
+		if !r.Deleting() || b.clock-r.deletedAt < b.DeleteDelay {
+			remaining = append(remaining, r)
+		}
+	}
+	b.resources = remaining
+
+	if b.throttled[operation] > 0 {
+		b.throttled[operation]--
+		return awserr.New("ThrottlingException", "Rate exceeded", nil)
+	}
+
+	return nil
+}
+
+// list returns the resources of a kind in a region in the order they were added.
+// The caller must hold the lock.
+func (b *Backend) list(kind Kind, region string) (resources []*Resource) {
+	for _, r := range b.resources {
+		if r.Kind == kind && (r.Region == region || kind == S3Bucket && region == "") {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// get returns a resource of a kind in a region or the service's error if it doesn't exist.
+// The caller must hold the lock.
+func (b *Backend) get(kind Kind, region string, id *string) (*Resource, error) {
+	for _, r := range b.list(kind, region) {
+		if id != nil && r.ID == *id {
+			return r, nil
+		}
+	}
+
+	return nil, awserr.New(kinds[kind].notFound, fmt.Sprintf("%s %s not found", kind, aws.StringValue(id)), nil)
+}
+
+// byARN returns the resource with the given ARN.
+// The caller must hold the lock.
+func (b *Backend) byARN(arn *string) (*Resource, error) {
+	for _, r := range b.resources {
+		if arn != nil && r.ARN() == *arn {
+			return r, nil
+		}
+	}
+
+	return nil, awserr.New("ResourceNotFoundException", fmt.Sprintf("%s not found", aws.StringValue(arn)), nil)
+}
+
+// dependents returns the resources depending on r.
+// The caller must hold the lock.
+func (b *Backend) dependents(r *Resource) (dependents []*Resource) {
+	for _, d := range b.resources {
+		for _, id := range d.DependsOn {
+			if id == r.ID && d.Region == r.Region {
+				dependents = append(dependents, d)
+			}
+		}
+	}
+	return dependents
+}
+
+// delete starts the deletion of a resource. It fails if the resource is already being deleted or other resources
+// depend on it.
+// The caller must hold the lock.
+func (b *Backend) delete(kind Kind, region string, id *string) (*Resource, error) {
+	r, err := b.get(kind, region, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Deleting() {
+		return nil, awserr.New(kinds[kind].inUse, fmt.Sprintf("%s %s is already being deleted", kind, r.ID), nil)
+	}
+
+	if dependents := b.dependents(r); len(dependents) > 0 {
+		return nil, awserr.New(kinds[kind].inUse, fmt.Sprintf("%s %s is in use by %s %s", kind, r.ID, dependents[0].Kind, dependents[0].ID), nil)
+	}
+
+	r.deletedAt = b.clock
+	if b.DeleteDelay == 0 || kind == S3Bucket {
+		b.remove(r)
+	}
+
+	return r, nil
+}
+
+// remove removes a resource from the backend.
+// The caller must hold the lock.
+func (b *Backend) remove(r *Resource) {
+	for i, candidate := range b.resources {
+		if candidate == r {
+			b.resources = append(b.resources[:i], b.resources[i+1:]...)
+			return
+		}
+	}
+}
+
+// after returns the index of the resource following the one with the given ID, 0 if id is nil.
+func after(resources []*Resource, id *string) int {
+	for i, r := range resources {
+		if id != nil && r.ID == *id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// at returns the index of the first resource of the page with the given token, 0 if token is nil.
+func at(token *string) int {
+	first, _ := strconv.Atoi(aws.StringValue(token))
+	return first
+}
+
+// page returns the resources of the page starting at index first and the token of the next page, nil on the
+// last page. A size of 0 returns all remaining resources.
+func page(resources []*Resource, first int, size int64) ([]*Resource, *string) {
+	if first > len(resources) {
+		first = len(resources)
+	}
+
+	last := len(resources)
+	if size > 0 && first+int(size) < last {
+		last = first + int(size)
+	}
+
+	var next *string
+	if last < len(resources) {
+		next = aws.String(strconv.Itoa(last))
+	}
+
+	return resources[first:last], next
+}
@@ -0,0 +1,109 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+type dynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	backend *Backend
+	region  string
+}
+
+func tableStatus(r *Resource) string {
+	if r.Deleting() {
+		return dynamodb.TableStatusDeleting
+	}
+	return dynamodb.TableStatusActive
+}
+
+func (s *dynamoDB) ListTablesPages(input *dynamodb.ListTablesInput, fn func(*dynamodb.ListTablesOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	start := input.ExclusiveStartTableName
+	for {
+		if err := s.backend.call("ListTables", nil); err != nil {
+			return err
+		}
+
+		tables := s.backend.list(DynamoDBTable, s.region)
+		resources, next := page(tables, after(tables, start), aws.Int64Value(input.Limit))
+
+		output := &dynamodb.ListTablesOutput{}
+		for _, r := range resources {
+			output.TableNames = append(output.TableNames, aws.String(r.ID))
+		}
+		if next != nil {
+			output.LastEvaluatedTableName = output.TableNames[len(output.TableNames)-1]
+		}
+
+		if !fn(output, next == nil) || next == nil {
+			return nil
+		}
+		start = output.LastEvaluatedTableName
+	}
+}
+
+func (s *dynamoDB) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DescribeTable", input.TableName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(DynamoDBTable, s.region, input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+		TableName:          aws.String(r.ID),
+		TableArn:           aws.String(r.ARN()),
+		TableStatus:        aws.String(tableStatus(r)),
+		CreationDateTime:   aws.Time(r.Created),
+		BillingModeSummary: &dynamodb.BillingModeSummary{BillingMode: aws.String(dynamodb.BillingModePayPerRequest)},
+	}}, nil
+}
+
+func (s *dynamoDB) ListTagsOfResource(input *dynamodb.ListTagsOfResourceInput) (*dynamodb.ListTagsOfResourceOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListTagsOfResource", input.ResourceArn); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.byARN(input.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dynamodb.ListTagsOfResourceOutput{}
+	for _, k := range sortedKeys(r.Tags) {
+		output.Tags = append(output.Tags, &dynamodb.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+	}
+	return output, nil
+}
+
+func (s *dynamoDB) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamodb.DeleteTableOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteTable", input.TableName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.delete(DynamoDBTable, s.region, input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.DeleteTableOutput{TableDescription: &dynamodb.TableDescription{
+		TableName:   aws.String(r.ID),
+		TableStatus: aws.String(dynamodb.TableStatusDeleting),
+	}}, nil
+}
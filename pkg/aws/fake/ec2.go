@@ -0,0 +1,133 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+type ec2API struct {
+	ec2iface.EC2API
+	backend *Backend
+	region  string
+}
+
+// instanceState returns the state of an instance, which is shutting down while being terminated.
+func instanceState(r *Resource) string {
+	if r.Deleting() {
+		return ec2.InstanceStateNameShuttingDown
+	}
+	if r.Stopped {
+		return ec2.InstanceStateNameStopped
+	}
+	return ec2.InstanceStateNameRunning
+}
+
+func (s *ec2API) DescribeInstancesPages(input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	states := make(map[string]bool)
+	for _, f := range input.Filters {
+		if aws.StringValue(f.Name) == "instance-state-name" {
+			for _, v := range f.Values {
+				states[aws.StringValue(v)] = true
+			}
+		}
+	}
+
+	var instances []*Resource
+	for _, r := range s.backend.list(EC2Instance, s.region) {
+		if len(states) == 0 || states[instanceState(r)] {
+			instances = append(instances, r)
+		}
+	}
+
+	var token *string
+	for {
+		if err := s.backend.call("DescribeInstances", nil); err != nil {
+			return err
+		}
+
+		var resources []*Resource
+		resources, token = page(instances, at(token), aws.Int64Value(input.MaxResults))
+
+		output := &ec2.DescribeInstancesOutput{NextToken: token}
+		for _, r := range resources {
+			instance := &ec2.Instance{
+				InstanceId: aws.String(r.ID),
+				LaunchTime: aws.Time(r.Created),
+				State:      &ec2.InstanceState{Name: aws.String(instanceState(r))},
+			}
+			for _, k := range sortedKeys(r.Tags) {
+				instance.Tags = append(instance.Tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+			}
+			output.Reservations = append(output.Reservations, &ec2.Reservation{Instances: []*ec2.Instance{instance}})
+		}
+
+		if !fn(output, token == nil) || token == nil {
+			return nil
+		}
+	}
+}
+
+func (s *ec2API) TerminateInstances(input *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	output := &ec2.TerminateInstancesOutput{}
+	for _, id := range input.InstanceIds {
+		if err := s.backend.call("TerminateInstances", id); err != nil {
+			return nil, err
+		}
+
+		r, err := s.backend.get(EC2Instance, s.region, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// terminating an instance which is shutting down succeeds
+		previous := instanceState(r)
+		if !r.Deleting() {
+			if _, err := s.backend.delete(EC2Instance, s.region, id); err != nil {
+				return nil, err
+			}
+		}
+
+		output.TerminatingInstances = append(output.TerminatingInstances, &ec2.InstanceStateChange{
+			InstanceId:    id,
+			PreviousState: &ec2.InstanceState{Name: aws.String(previous)},
+			CurrentState:  &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameShuttingDown)},
+		})
+	}
+
+	return output, nil
+}
+
+func (s *ec2API) StopInstances(input *ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error) {
+	return &ec2.StopInstancesOutput{}, s.setStopped("StopInstances", input.InstanceIds, true)
+}
+
+func (s *ec2API) StartInstances(input *ec2.StartInstancesInput) (*ec2.StartInstancesOutput, error) {
+	return &ec2.StartInstancesOutput{}, s.setStopped("StartInstances", input.InstanceIds, false)
+}
+
+// setStopped stops or starts instances.
+func (s *ec2API) setStopped(operation string, ids []*string, stopped bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	for _, id := range ids {
+		if err := s.backend.call(operation, id); err != nil {
+			return err
+		}
+
+		r, err := s.backend.get(EC2Instance, s.region, id)
+		if err != nil {
+			return err
+		}
+		r.Stopped = stopped
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticsearchservice"
+	"github.com/aws/aws-sdk-go/service/elasticsearchservice/elasticsearchserviceiface"
+)
+
+type elasticsearchService struct {
+	elasticsearchserviceiface.ElasticsearchServiceAPI
+	backend *Backend
+	region  string
+}
+
+func (s *elasticsearchService) ListDomainNames(input *elasticsearchservice.ListDomainNamesInput) (*elasticsearchservice.ListDomainNamesOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListDomainNames", nil); err != nil {
+		return nil, err
+	}
+
+	output := &elasticsearchservice.ListDomainNamesOutput{}
+	for _, r := range s.backend.list(ElasticsearchDomain, s.region) {
+		output.DomainNames = append(output.DomainNames, &elasticsearchservice.DomainInfo{DomainName: aws.String(r.ID)})
+	}
+	return output, nil
+}
+
+func (s *elasticsearchService) DescribeElasticsearchDomain(input *elasticsearchservice.DescribeElasticsearchDomainInput) (*elasticsearchservice.DescribeElasticsearchDomainOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DescribeElasticsearchDomain", input.DomainName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(ElasticsearchDomain, s.region, input.DomainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elasticsearchservice.DescribeElasticsearchDomainOutput{DomainStatus: &elasticsearchservice.ElasticsearchDomainStatus{
+		DomainName: aws.String(r.ID),
+		DomainId:   aws.String(AccountID + "/" + r.ID),
+		ARN:        aws.String(r.ARN()),
+		Deleted:    aws.Bool(r.Deleting()),
+	}}, nil
+}
+
+// DescribeElasticsearchDomainConfig reports the creation date of the domain as the one of its advanced options.
+func (s *elasticsearchService) DescribeElasticsearchDomainConfig(input *elasticsearchservice.DescribeElasticsearchDomainConfigInput) (*elasticsearchservice.DescribeElasticsearchDomainConfigOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DescribeElasticsearchDomainConfig", input.DomainName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(ElasticsearchDomain, s.region, input.DomainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elasticsearchservice.DescribeElasticsearchDomainConfigOutput{DomainConfig: &elasticsearchservice.ElasticsearchDomainConfig{
+		AdvancedOptions: &elasticsearchservice.AdvancedOptionsStatus{
+			Status: &elasticsearchservice.OptionStatus{
+				CreationDate: aws.Time(r.Created),
+				UpdateDate:   aws.Time(r.Created),
+				State:        aws.String(elasticsearchservice.OptionStateActive),
+			},
+		},
+	}}, nil
+}
+
+func (s *elasticsearchService) ListTags(input *elasticsearchservice.ListTagsInput) (*elasticsearchservice.ListTagsOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListTags", input.ARN); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.byARN(input.ARN)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &elasticsearchservice.ListTagsOutput{}
+	for _, k := range sortedKeys(r.Tags) {
+		output.TagList = append(output.TagList, &elasticsearchservice.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+	}
+	return output, nil
+}
+
+func (s *elasticsearchService) DeleteElasticsearchDomain(input *elasticsearchservice.DeleteElasticsearchDomainInput) (*elasticsearchservice.DeleteElasticsearchDomainOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteElasticsearchDomain", input.DomainName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.delete(ElasticsearchDomain, s.region, input.DomainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elasticsearchservice.DeleteElasticsearchDomainOutput{DomainStatus: &elasticsearchservice.ElasticsearchDomainStatus{
+		DomainName: aws.String(r.ID),
+		ARN:        aws.String(r.ARN()),
+		Deleted:    aws.Bool(true),
+	}}, nil
+}
@@ -0,0 +1,97 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
+)
+
+type firehoseAPI struct {
+	firehoseiface.FirehoseAPI
+	backend *Backend
+	region  string
+}
+
+func deliveryStreamStatus(r *Resource) string {
+	if r.Deleting() {
+		return firehose.DeliveryStreamStatusDeleting
+	}
+	return firehose.DeliveryStreamStatusActive
+}
+
+func (s *firehoseAPI) ListDeliveryStreams(input *firehose.ListDeliveryStreamsInput) (*firehose.ListDeliveryStreamsOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListDeliveryStreams", nil); err != nil {
+		return nil, err
+	}
+
+	streams := s.backend.list(FirehoseDeliveryStream, s.region)
+	resources, next := page(streams, after(streams, input.ExclusiveStartDeliveryStreamName), aws.Int64Value(input.Limit))
+
+	output := &firehose.ListDeliveryStreamsOutput{
+		DeliveryStreamNames:    []*string{},
+		HasMoreDeliveryStreams: aws.Bool(next != nil),
+	}
+	for _, r := range resources {
+		output.DeliveryStreamNames = append(output.DeliveryStreamNames, aws.String(r.ID))
+	}
+	return output, nil
+}
+
+func (s *firehoseAPI) ListTagsForDeliveryStream(input *firehose.ListTagsForDeliveryStreamInput) (*firehose.ListTagsForDeliveryStreamOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListTagsForDeliveryStream", input.DeliveryStreamName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(FirehoseDeliveryStream, s.region, input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &firehose.ListTagsForDeliveryStreamOutput{HasMoreTags: aws.Bool(false)}
+	for _, k := range sortedKeys(r.Tags) {
+		output.Tags = append(output.Tags, &firehose.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+	}
+	return output, nil
+}
+
+func (s *firehoseAPI) DescribeDeliveryStream(input *firehose.DescribeDeliveryStreamInput) (*firehose.DescribeDeliveryStreamOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DescribeDeliveryStream", input.DeliveryStreamName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(FirehoseDeliveryStream, s.region, input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &firehose.DescribeDeliveryStreamOutput{DeliveryStreamDescription: &firehose.DeliveryStreamDescription{
+		DeliveryStreamName:   aws.String(r.ID),
+		DeliveryStreamARN:    aws.String(r.ARN()),
+		DeliveryStreamStatus: aws.String(deliveryStreamStatus(r)),
+		CreateTimestamp:      aws.Time(r.Created),
+		HasMoreDestinations:  aws.Bool(false),
+	}}, nil
+}
+
+func (s *firehoseAPI) DeleteDeliveryStream(input *firehose.DeleteDeliveryStreamInput) (*firehose.DeleteDeliveryStreamOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteDeliveryStream", input.DeliveryStreamName); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.backend.delete(FirehoseDeliveryStream, s.region, input.DeliveryStreamName); err != nil {
+		return nil, err
+	}
+	return &firehose.DeleteDeliveryStreamOutput{}, nil
+}
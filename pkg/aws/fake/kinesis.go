@@ -0,0 +1,101 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+)
+
+type kinesisAPI struct {
+	kinesisiface.KinesisAPI
+	backend *Backend
+	region  string
+}
+
+func streamStatus(r *Resource) string {
+	if r.Deleting() {
+		return kinesis.StreamStatusDeleting
+	}
+	return kinesis.StreamStatusActive
+}
+
+func (s *kinesisAPI) ListStreamsPages(input *kinesis.ListStreamsInput, fn func(*kinesis.ListStreamsOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	start := input.ExclusiveStartStreamName
+	for {
+		if err := s.backend.call("ListStreams", nil); err != nil {
+			return err
+		}
+
+		streams := s.backend.list(KinesisStream, s.region)
+		resources, next := page(streams, after(streams, start), aws.Int64Value(input.Limit))
+
+		output := &kinesis.ListStreamsOutput{HasMoreStreams: aws.Bool(next != nil)}
+		for _, r := range resources {
+			output.StreamNames = append(output.StreamNames, aws.String(r.ID))
+		}
+
+		if !fn(output, next == nil) || next == nil {
+			return nil
+		}
+		start = output.StreamNames[len(output.StreamNames)-1]
+	}
+}
+
+func (s *kinesisAPI) ListTagsForStream(input *kinesis.ListTagsForStreamInput) (*kinesis.ListTagsForStreamOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListTagsForStream", input.StreamName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(KinesisStream, s.region, input.StreamName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &kinesis.ListTagsForStreamOutput{HasMoreTags: aws.Bool(false)}
+	for _, k := range sortedKeys(r.Tags) {
+		output.Tags = append(output.Tags, &kinesis.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+	}
+	return output, nil
+}
+
+func (s *kinesisAPI) DescribeStream(input *kinesis.DescribeStreamInput) (*kinesis.DescribeStreamOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DescribeStream", input.StreamName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(KinesisStream, s.region, input.StreamName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kinesis.DescribeStreamOutput{StreamDescription: &kinesis.StreamDescription{
+		StreamName:              aws.String(r.ID),
+		StreamARN:               aws.String(r.ARN()),
+		StreamStatus:            aws.String(streamStatus(r)),
+		StreamCreationTimestamp: aws.Time(r.Created),
+		HasMoreShards:           aws.Bool(false),
+	}}, nil
+}
+
+func (s *kinesisAPI) DeleteStream(input *kinesis.DeleteStreamInput) (*kinesis.DeleteStreamOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteStream", input.StreamName); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.backend.delete(KinesisStream, s.region, input.StreamName); err != nil {
+		return nil, err
+	}
+	return &kinesis.DeleteStreamOutput{}, nil
+}
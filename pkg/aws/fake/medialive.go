@@ -0,0 +1,154 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/medialive"
+	"github.com/aws/aws-sdk-go/service/medialive/medialiveiface"
+)
+
+type mediaLive struct {
+	medialiveiface.MediaLiveAPI
+	backend *Backend
+	region  string
+}
+
+func tags(r *Resource) map[string]*string {
+	tags := make(map[string]*string)
+	for k, v := range r.Tags {
+		tags[k] = aws.String(v)
+	}
+	return tags
+}
+
+func (s *mediaLive) ListInputsPages(input *medialive.ListInputsInput, fn func(*medialive.ListInputsOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	token := input.NextToken
+	for {
+		if err := s.backend.call("ListInputs", nil); err != nil {
+			return err
+		}
+
+		var resources []*Resource
+		resources, token = page(s.backend.list(MediaLiveInput, s.region), at(token), aws.Int64Value(input.MaxResults))
+
+		output := &medialive.ListInputsOutput{NextToken: token}
+		for _, r := range resources {
+			state := medialive.InputStateDetached
+			if r.Deleting() {
+				state = medialive.InputStateDeleting
+			}
+
+			in := &medialive.Input{
+				Id:    aws.String(r.ID),
+				Name:  aws.String(r.ID),
+				Arn:   aws.String(r.ARN()),
+				State: aws.String(state),
+				Tags:  tags(r),
+			}
+			for _, d := range s.backend.dependents(r) {
+				in.AttachedChannels = append(in.AttachedChannels, aws.String(d.ID))
+				in.State = aws.String(medialive.InputStateAttached)
+			}
+			output.Inputs = append(output.Inputs, in)
+		}
+
+		if !fn(output, token == nil) || token == nil {
+			return nil
+		}
+	}
+}
+
+func (s *mediaLive) ListChannelsPages(input *medialive.ListChannelsInput, fn func(*medialive.ListChannelsOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	token := input.NextToken
+	for {
+		if err := s.backend.call("ListChannels", nil); err != nil {
+			return err
+		}
+
+		var resources []*Resource
+		resources, token = page(s.backend.list(MediaLiveChannel, s.region), at(token), aws.Int64Value(input.MaxResults))
+
+		output := &medialive.ListChannelsOutput{NextToken: token}
+		for _, r := range resources {
+			state := medialive.ChannelStateIdle
+			if r.Deleting() {
+				state = medialive.ChannelStateDeleting
+			}
+
+			channel := &medialive.ChannelSummary{
+				Id:    aws.String(r.ID),
+				Name:  aws.String(r.ID),
+				Arn:   aws.String(r.ARN()),
+				State: aws.String(state),
+				Tags:  tags(r),
+			}
+			for _, id := range r.DependsOn {
+				channel.InputAttachments = append(channel.InputAttachments, &medialive.InputAttachment{InputId: aws.String(id)})
+			}
+			output.Channels = append(output.Channels, channel)
+		}
+
+		if !fn(output, token == nil) || token == nil {
+			return nil
+		}
+	}
+}
+
+// CreateTags adds tags to a resource, used by awsweeper to mark when it first saw a resource.
+func (s *mediaLive) CreateTags(input *medialive.CreateTagsInput) (*medialive.CreateTagsOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("CreateTags", input.ResourceArn); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.byARN(input.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range input.Tags {
+		r.Tags[k] = aws.StringValue(v)
+	}
+	return &medialive.CreateTagsOutput{}, nil
+}
+
+func (s *mediaLive) DeleteInput(input *medialive.DeleteInputInput) (*medialive.DeleteInputOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteInput", input.InputId); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.backend.delete(MediaLiveInput, s.region, input.InputId); err != nil {
+		return nil, err
+	}
+	return &medialive.DeleteInputOutput{}, nil
+}
+
+func (s *mediaLive) DeleteChannel(input *medialive.DeleteChannelInput) (*medialive.DeleteChannelOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteChannel", input.ChannelId); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.delete(MediaLiveChannel, s.region, input.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &medialive.DeleteChannelOutput{
+		Id:    aws.String(r.ID),
+		Arn:   aws.String(r.ARN()),
+		State: aws.String(medialive.ChannelStateDeleting),
+	}, nil
+}
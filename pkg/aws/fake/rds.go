@@ -0,0 +1,220 @@
+package fake
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+)
+
+type rdsAPI struct {
+	rdsiface.RDSAPI
+	backend *Backend
+	region  string
+}
+
+func dbStatus(r *Resource) string {
+	if r.Deleting() {
+		return "deleting"
+	}
+	return "available"
+}
+
+// clusterOf returns the ID of the cluster an instance is a member of, nil for standalone instances.
+// The caller must hold the lock.
+func (s *rdsAPI) clusterOf(instance *Resource) *string {
+	for _, id := range instance.DependsOn {
+		if _, err := s.backend.get(RDSCluster, s.region, aws.String(id)); err == nil {
+			return aws.String(id)
+		}
+	}
+	return nil
+}
+
+func (s *rdsAPI) DescribeDBInstancesPages(input *rds.DescribeDBInstancesInput, fn func(*rds.DescribeDBInstancesOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	marker := input.Marker
+	for {
+		if err := s.backend.call("DescribeDBInstances", nil); err != nil {
+			return err
+		}
+
+		var resources []*Resource
+		resources, marker = page(s.backend.list(RDSInstance, s.region), at(marker), aws.Int64Value(input.MaxRecords))
+
+		output := &rds.DescribeDBInstancesOutput{Marker: marker}
+		for _, r := range resources {
+			output.DBInstances = append(output.DBInstances, &rds.DBInstance{
+				DBInstanceIdentifier: aws.String(r.ID),
+				DBInstanceArn:        aws.String(r.ARN()),
+				DBInstanceStatus:     aws.String(dbStatus(r)),
+				DBClusterIdentifier:  s.clusterOf(r),
+				InstanceCreateTime:   aws.Time(r.Created),
+			})
+		}
+
+		if !fn(output, marker == nil) || marker == nil {
+			return nil
+		}
+	}
+}
+
+// cluster returns the description of a cluster.
+// The caller must hold the lock.
+func (s *rdsAPI) cluster(r *Resource) *rds.DBCluster {
+	cluster := &rds.DBCluster{
+		DBClusterIdentifier: aws.String(r.ID),
+		DBClusterArn:        aws.String(r.ARN()),
+		Status:              aws.String(dbStatus(r)),
+		ClusterCreateTime:   aws.Time(r.Created),
+	}
+	for _, d := range s.backend.dependents(r) {
+		if d.Kind == RDSInstance {
+			cluster.DBClusterMembers = append(cluster.DBClusterMembers, &rds.DBClusterMember{DBInstanceIdentifier: aws.String(d.ID)})
+		}
+	}
+	return cluster
+}
+
+func (s *rdsAPI) DescribeDBClustersPages(input *rds.DescribeDBClustersInput, fn func(*rds.DescribeDBClustersOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	marker := input.Marker
+	for {
+		if err := s.backend.call("DescribeDBClusters", nil); err != nil {
+			return err
+		}
+
+		var resources []*Resource
+		resources, marker = page(s.backend.list(RDSCluster, s.region), at(marker), aws.Int64Value(input.MaxRecords))
+
+		output := &rds.DescribeDBClustersOutput{Marker: marker}
+		for _, r := range resources {
+			output.DBClusters = append(output.DBClusters, s.cluster(r))
+		}
+
+		if !fn(output, marker == nil) || marker == nil {
+			return nil
+		}
+	}
+}
+
+func (s *rdsAPI) DescribeDBClusters(input *rds.DescribeDBClustersInput) (*rds.DescribeDBClustersOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DescribeDBClusters", input.DBClusterIdentifier); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(RDSCluster, s.region, input.DBClusterIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	return &rds.DescribeDBClustersOutput{DBClusters: []*rds.DBCluster{s.cluster(r)}}, nil
+}
+
+func (s *rdsAPI) ListTagsForResource(input *rds.ListTagsForResourceInput) (*rds.ListTagsForResourceOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListTagsForResource", input.ResourceName); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.byARN(input.ResourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &rds.ListTagsForResourceOutput{}
+	for _, k := range sortedKeys(r.Tags) {
+		output.TagList = append(output.TagList, &rds.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+	}
+	return output, nil
+}
+
+// modify fails for resources which don't exist or are being deleted.
+// The caller must hold the lock.
+func (s *rdsAPI) modify(kind Kind, id *string) error {
+	r, err := s.backend.get(kind, s.region, id)
+	if err != nil {
+		return err
+	}
+
+	if r.Deleting() {
+		return awserr.New(kinds[kind].inUse, fmt.Sprintf("%s %s is being deleted", kind, r.ID), nil)
+	}
+	return nil
+}
+
+func (s *rdsAPI) ModifyDBInstance(input *rds.ModifyDBInstanceInput) (*rds.ModifyDBInstanceOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ModifyDBInstance", input.DBInstanceIdentifier); err != nil {
+		return nil, err
+	}
+
+	if err := s.modify(RDSInstance, input.DBInstanceIdentifier); err != nil {
+		return nil, err
+	}
+	return &rds.ModifyDBInstanceOutput{}, nil
+}
+
+func (s *rdsAPI) ModifyDBCluster(input *rds.ModifyDBClusterInput) (*rds.ModifyDBClusterOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ModifyDBCluster", input.DBClusterIdentifier); err != nil {
+		return nil, err
+	}
+
+	if err := s.modify(RDSCluster, input.DBClusterIdentifier); err != nil {
+		return nil, err
+	}
+	return &rds.ModifyDBClusterOutput{}, nil
+}
+
+func (s *rdsAPI) DeleteDBInstance(input *rds.DeleteDBInstanceInput) (*rds.DeleteDBInstanceOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteDBInstance", input.DBInstanceIdentifier); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.delete(RDSInstance, s.region, input.DBInstanceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rds.DeleteDBInstanceOutput{DBInstance: &rds.DBInstance{
+		DBInstanceIdentifier: aws.String(r.ID),
+		DBInstanceStatus:     aws.String("deleting"),
+	}}, nil
+}
+
+func (s *rdsAPI) DeleteDBCluster(input *rds.DeleteDBClusterInput) (*rds.DeleteDBClusterOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteDBCluster", input.DBClusterIdentifier); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.delete(RDSCluster, s.region, input.DBClusterIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rds.DeleteDBClusterOutput{DBCluster: &rds.DBCluster{
+		DBClusterIdentifier: aws.String(r.ID),
+		Status:              aws.String("deleting"),
+	}}, nil
+}
@@ -0,0 +1,103 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+type s3API struct {
+	s3iface.S3API
+	backend *Backend
+	region  string
+}
+
+// ListBuckets returns the buckets of all regions, like S3 does.
+func (s *s3API) ListBuckets(input *s3.ListBucketsInput) (*s3.ListBucketsOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("ListBuckets", nil); err != nil {
+		return nil, err
+	}
+
+	output := &s3.ListBucketsOutput{}
+	for _, r := range s.backend.list(S3Bucket, "") {
+		output.Buckets = append(output.Buckets, &s3.Bucket{Name: aws.String(r.ID), CreationDate: aws.Time(r.Created)})
+	}
+	return output, nil
+}
+
+func (s *s3API) GetBucketLocation(input *s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("GetBucketLocation", input.Bucket); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(S3Bucket, "", input.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	// buckets in us-east-1 have no location constraint
+	output := &s3.GetBucketLocationOutput{}
+	if r.Region != "us-east-1" {
+		output.LocationConstraint = aws.String(r.Region)
+	}
+	return output, nil
+}
+
+func (s *s3API) GetBucketTagging(input *s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("GetBucketTagging", input.Bucket); err != nil {
+		return nil, err
+	}
+
+	r, err := s.backend.get(S3Bucket, "", input.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.Tags) == 0 {
+		return nil, awserr.New("NoSuchTagSet", "The TagSet does not exist", nil)
+	}
+
+	output := &s3.GetBucketTaggingOutput{}
+	for _, k := range sortedKeys(r.Tags) {
+		output.TagSet = append(output.TagSet, &s3.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+	}
+	return output, nil
+}
+
+func (s *s3API) DeleteBucketPolicy(input *s3.DeleteBucketPolicyInput) (*s3.DeleteBucketPolicyOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteBucketPolicy", input.Bucket); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.backend.get(S3Bucket, "", input.Bucket); err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketPolicyOutput{}, nil
+}
+
+func (s *s3API) DeleteBucket(input *s3.DeleteBucketInput) (*s3.DeleteBucketOutput, error) {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	if err := s.backend.call("DeleteBucket", input.Bucket); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.backend.delete(S3Bucket, "", input.Bucket); err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketOutput{}, nil
+}
@@ -0,0 +1,69 @@
+package fake
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+)
+
+type resourceGroupsTagging struct {
+	resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+	backend *Backend
+	region  string
+}
+
+// GetResourcesPages returns the tagged resources of the region whose kinds match the resource type filters.
+// S3 buckets of all regions are returned, like the API does for the global S3 service.
+func (s *resourceGroupsTagging) GetResourcesPages(input *resourcegroupstaggingapi.GetResourcesInput, fn func(*resourcegroupstaggingapi.GetResourcesOutput, bool) bool) error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	var resources []*Resource
+	for _, filter := range input.ResourceTypeFilters {
+		kind := Kind(aws.StringValue(filter))
+		region := s.region
+		if kind == S3Bucket {
+			region = ""
+		}
+
+		for _, r := range s.backend.list(kind, region) {
+			if len(r.Tags) > 0 {
+				resources = append(resources, r)
+			}
+		}
+	}
+
+	token := input.PaginationToken
+	for {
+		if err := s.backend.call("GetResources", nil); err != nil {
+			return err
+		}
+
+		var mappings []*Resource
+		mappings, token = page(resources, at(token), aws.Int64Value(input.ResourcesPerPage))
+
+		output := &resourcegroupstaggingapi.GetResourcesOutput{PaginationToken: aws.String(aws.StringValue(token))}
+		for _, r := range mappings {
+			mapping := &resourcegroupstaggingapi.ResourceTagMapping{ResourceARN: aws.String(r.ARN())}
+			for _, k := range sortedKeys(r.Tags) {
+				mapping.Tags = append(mapping.Tags, &resourcegroupstaggingapi.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+			}
+			output.ResourceTagMappingList = append(output.ResourceTagMappingList, mapping)
+		}
+
+		if !fn(output, token == nil) || token == nil {
+			return nil
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
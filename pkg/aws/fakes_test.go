@@ -0,0 +1,493 @@
+package aws_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elasticsearchservice"
+	"github.com/aws/aws-sdk-go/service/elasticsearchservice/elasticsearchserviceiface"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/aws/aws-sdk-go/service/medialive"
+	"github.com/aws/aws-sdk-go/service/medialive/medialiveiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// item is a resource of a fake service.
+type item struct {
+	id      string
+	tags    map[string]string
+	created time.Time
+}
+
+// store holds the items of a fake service and records the items deleted.
+type store struct {
+	items   []item
+	deleted []string
+	// failing is an operation which fails, e.g. "DescribeTable"
+	failing string
+}
+
+// fail returns the error of an operation if it is the failing one.
+func (s *store) fail(operation string) error {
+	if s.failing == operation {
+		return fmt.Errorf("%s failed", operation)
+	}
+	return nil
+}
+
+func (s *store) get(id string) (item, error) {
+	for _, i := range s.items {
+		if i.id == id {
+			return i, nil
+		}
+	}
+
+	return item{}, fmt.Errorf("%s not found", id)
+}
+
+func (s *store) delete(id *string) error {
+	if _, err := s.get(aws.StringValue(id)); err != nil {
+		return err
+	}
+
+	s.deleted = append(s.deleted, aws.StringValue(id))
+	return nil
+}
+
+func arn(service, resource string) *string {
+	return aws.String(fmt.Sprintf("arn:aws:%s:eu-west-1:111111111111:%s", service, resource))
+}
+
+type fakeEC2 struct {
+	ec2iface.EC2API
+	store
+}
+
+func (f *fakeEC2) DescribeInstancesPages(input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	for n, i := range f.items {
+		instance := &ec2.Instance{
+			InstanceId: aws.String(i.id),
+			LaunchTime: aws.Time(i.created),
+			State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			Tags:       []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(i.id)}},
+		}
+		for k, v := range i.tags {
+			instance.Tags = append(instance.Tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+		if !fn(&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{instance}}}}, n == len(f.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeEC2) TerminateInstances(input *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
+	return &ec2.TerminateInstancesOutput{}, f.delete(input.InstanceIds[0])
+}
+
+type fakeS3 struct {
+	s3iface.S3API
+	store
+}
+
+func (f *fakeS3) ListBuckets(*s3.ListBucketsInput) (*s3.ListBucketsOutput, error) {
+	out := &s3.ListBucketsOutput{}
+	for _, i := range f.items {
+		out.Buckets = append(out.Buckets, &s3.Bucket{Name: aws.String(i.id), CreationDate: aws.Time(i.created)})
+	}
+	return out, nil
+}
+
+func (f *fakeS3) GetBucketLocation(input *s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error) {
+	return &s3.GetBucketLocationOutput{LocationConstraint: aws.String("eu-west-1")}, nil
+}
+
+func (f *fakeS3) GetBucketTagging(input *s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error) {
+	if err := f.fail("GetBucketTagging"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.Bucket)
+	out := &s3.GetBucketTaggingOutput{}
+	for k, v := range i.tags {
+		out.TagSet = append(out.TagSet, &s3.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, err
+}
+
+func (f *fakeS3) DeleteBucketPolicy(input *s3.DeleteBucketPolicyInput) (*s3.DeleteBucketPolicyOutput, error) {
+	return &s3.DeleteBucketPolicyOutput{}, nil
+}
+
+func (f *fakeS3) DeleteBucket(input *s3.DeleteBucketInput) (*s3.DeleteBucketOutput, error) {
+	return &s3.DeleteBucketOutput{}, f.delete(input.Bucket)
+}
+
+type fakeDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	store
+	// throughput and indexes describe provisioned tables, which are on-demand without throughput
+	throughput *dynamodb.ProvisionedThroughputDescription
+	indexes    []*dynamodb.GlobalSecondaryIndexDescription
+	updates    []*dynamodb.UpdateTableInput
+}
+
+func (f *fakeDynamoDB) ListTablesPages(input *dynamodb.ListTablesInput, fn func(*dynamodb.ListTablesOutput, bool) bool) error {
+	for n, i := range f.items {
+		if !fn(&dynamodb.ListTablesOutput{TableNames: []*string{aws.String(i.id)}}, n == len(f.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeDynamoDB) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	if err := f.fail("DescribeTable"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.TableName)
+	return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+		TableName:              input.TableName,
+		TableArn:               arn("dynamodb", "table/"+i.id),
+		CreationDateTime:       aws.Time(i.created),
+		BillingModeSummary:     &dynamodb.BillingModeSummary{BillingMode: aws.String(f.billingMode())},
+		ProvisionedThroughput:  f.throughput,
+		GlobalSecondaryIndexes: f.indexes,
+	}}, err
+}
+
+func (f *fakeDynamoDB) billingMode() string {
+	if f.throughput == nil {
+		return dynamodb.BillingModePayPerRequest
+	}
+	return dynamodb.BillingModeProvisioned
+}
+
+func (f *fakeDynamoDB) UpdateTable(input *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	f.updates = append(f.updates, input)
+	return &dynamodb.UpdateTableOutput{}, nil
+}
+
+func (f *fakeDynamoDB) ListTagsOfResource(input *dynamodb.ListTagsOfResourceInput) (*dynamodb.ListTagsOfResourceOutput, error) {
+	if err := f.fail("ListTagsOfResource"); err != nil {
+		return nil, err
+	}
+	var id string
+	fmt.Sscanf(*input.ResourceArn, "arn:aws:dynamodb:eu-west-1:111111111111:table/%s", &id)
+	i, err := f.get(id)
+	out := &dynamodb.ListTagsOfResourceOutput{}
+	for k, v := range i.tags {
+		out.Tags = append(out.Tags, &dynamodb.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, err
+}
+
+func (f *fakeDynamoDB) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamodb.DeleteTableOutput, error) {
+	return &dynamodb.DeleteTableOutput{}, f.delete(input.TableName)
+}
+
+type fakeElasticsearchService struct {
+	elasticsearchserviceiface.ElasticsearchServiceAPI
+	store
+}
+
+func (f *fakeElasticsearchService) ListDomainNames(*elasticsearchservice.ListDomainNamesInput) (*elasticsearchservice.ListDomainNamesOutput, error) {
+	out := &elasticsearchservice.ListDomainNamesOutput{}
+	for _, i := range f.items {
+		out.DomainNames = append(out.DomainNames, &elasticsearchservice.DomainInfo{DomainName: aws.String(i.id)})
+	}
+	return out, nil
+}
+
+func (f *fakeElasticsearchService) DescribeElasticsearchDomain(input *elasticsearchservice.DescribeElasticsearchDomainInput) (*elasticsearchservice.DescribeElasticsearchDomainOutput, error) {
+	if err := f.fail("DescribeElasticsearchDomain"); err != nil {
+		return nil, err
+	}
+	_, err := f.get(*input.DomainName)
+	return &elasticsearchservice.DescribeElasticsearchDomainOutput{DomainStatus: &elasticsearchservice.ElasticsearchDomainStatus{
+		DomainName: input.DomainName,
+		ARN:        aws.String(*input.DomainName),
+	}}, err
+}
+
+func (f *fakeElasticsearchService) DescribeElasticsearchDomainConfig(input *elasticsearchservice.DescribeElasticsearchDomainConfigInput) (*elasticsearchservice.DescribeElasticsearchDomainConfigOutput, error) {
+	if err := f.fail("DescribeElasticsearchDomainConfig"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.DomainName)
+	return &elasticsearchservice.DescribeElasticsearchDomainConfigOutput{DomainConfig: &elasticsearchservice.ElasticsearchDomainConfig{
+		AdvancedOptions: &elasticsearchservice.AdvancedOptionsStatus{
+			Status: &elasticsearchservice.OptionStatus{CreationDate: aws.Time(i.created)},
+		},
+	}}, err
+}
+
+func (f *fakeElasticsearchService) ListTags(input *elasticsearchservice.ListTagsInput) (*elasticsearchservice.ListTagsOutput, error) {
+	if err := f.fail("ListTags"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.ARN)
+	out := &elasticsearchservice.ListTagsOutput{}
+	for k, v := range i.tags {
+		out.TagList = append(out.TagList, &elasticsearchservice.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, err
+}
+
+func (f *fakeElasticsearchService) DeleteElasticsearchDomain(input *elasticsearchservice.DeleteElasticsearchDomainInput) (*elasticsearchservice.DeleteElasticsearchDomainOutput, error) {
+	return &elasticsearchservice.DeleteElasticsearchDomainOutput{}, f.delete(input.DomainName)
+}
+
+type fakeKinesis struct {
+	kinesisiface.KinesisAPI
+	store
+}
+
+func (f *fakeKinesis) ListStreamsPages(input *kinesis.ListStreamsInput, fn func(*kinesis.ListStreamsOutput, bool) bool) error {
+	for n, i := range f.items {
+		if !fn(&kinesis.ListStreamsOutput{StreamNames: []*string{aws.String(i.id)}, HasMoreStreams: aws.Bool(n < len(f.items)-1)}, n == len(f.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeKinesis) ListTagsForStream(input *kinesis.ListTagsForStreamInput) (*kinesis.ListTagsForStreamOutput, error) {
+	if err := f.fail("ListTagsForStream"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.StreamName)
+	out := &kinesis.ListTagsForStreamOutput{}
+	for k, v := range i.tags {
+		out.Tags = append(out.Tags, &kinesis.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, err
+}
+
+func (f *fakeKinesis) DescribeStream(input *kinesis.DescribeStreamInput) (*kinesis.DescribeStreamOutput, error) {
+	if err := f.fail("DescribeStream"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.StreamName)
+	return &kinesis.DescribeStreamOutput{StreamDescription: &kinesis.StreamDescription{
+		StreamCreationTimestamp: aws.Time(i.created),
+	}}, err
+}
+
+func (f *fakeKinesis) DeleteStream(input *kinesis.DeleteStreamInput) (*kinesis.DeleteStreamOutput, error) {
+	return &kinesis.DeleteStreamOutput{}, f.delete(input.StreamName)
+}
+
+type fakeFirehose struct {
+	firehoseiface.FirehoseAPI
+	store
+}
+
+func (f *fakeFirehose) ListDeliveryStreams(input *firehose.ListDeliveryStreamsInput) (*firehose.ListDeliveryStreamsOutput, error) {
+	// one stream per page
+	n := 0
+	if input.ExclusiveStartDeliveryStreamName != nil {
+		for j, i := range f.items {
+			if i.id == *input.ExclusiveStartDeliveryStreamName {
+				n = j + 1
+			}
+		}
+	}
+
+	out := &firehose.ListDeliveryStreamsOutput{HasMoreDeliveryStreams: aws.Bool(n < len(f.items)-1)}
+	if n < len(f.items) {
+		out.DeliveryStreamNames = []*string{aws.String(f.items[n].id)}
+	}
+	return out, nil
+}
+
+func (f *fakeFirehose) ListTagsForDeliveryStream(input *firehose.ListTagsForDeliveryStreamInput) (*firehose.ListTagsForDeliveryStreamOutput, error) {
+	if err := f.fail("ListTagsForDeliveryStream"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.DeliveryStreamName)
+	out := &firehose.ListTagsForDeliveryStreamOutput{}
+	for k, v := range i.tags {
+		out.Tags = append(out.Tags, &firehose.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, err
+}
+
+func (f *fakeFirehose) DescribeDeliveryStream(input *firehose.DescribeDeliveryStreamInput) (*firehose.DescribeDeliveryStreamOutput, error) {
+	if err := f.fail("DescribeDeliveryStream"); err != nil {
+		return nil, err
+	}
+	i, err := f.get(*input.DeliveryStreamName)
+	return &firehose.DescribeDeliveryStreamOutput{DeliveryStreamDescription: &firehose.DeliveryStreamDescription{
+		CreateTimestamp: aws.Time(i.created),
+	}}, err
+}
+
+func (f *fakeFirehose) DeleteDeliveryStream(input *firehose.DeleteDeliveryStreamInput) (*firehose.DeleteDeliveryStreamOutput, error) {
+	return &firehose.DeleteDeliveryStreamOutput{}, f.delete(input.DeliveryStreamName)
+}
+
+// fakeRDS serves DB instances from its store and DB clusters from clusters.
+type fakeRDS struct {
+	rdsiface.RDSAPI
+	store
+	clusters store
+}
+
+func (f *fakeRDS) DescribeDBInstancesPages(input *rds.DescribeDBInstancesInput, fn func(*rds.DescribeDBInstancesOutput, bool) bool) error {
+	for n, i := range f.items {
+		instance := &rds.DBInstance{
+			DBInstanceIdentifier: aws.String(i.id),
+			DBInstanceArn:        arn("rds", "db:"+i.id),
+			InstanceCreateTime:   aws.Time(i.created),
+		}
+		if !fn(&rds.DescribeDBInstancesOutput{DBInstances: []*rds.DBInstance{instance}}, n == len(f.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeRDS) DescribeDBClustersPages(input *rds.DescribeDBClustersInput, fn func(*rds.DescribeDBClustersOutput, bool) bool) error {
+	for n, i := range f.clusters.items {
+		cluster := &rds.DBCluster{
+			DBClusterIdentifier: aws.String(i.id),
+			DBClusterArn:        arn("rds", "cluster:"+i.id),
+			ClusterCreateTime:   aws.Time(i.created),
+		}
+		if !fn(&rds.DescribeDBClustersOutput{DBClusters: []*rds.DBCluster{cluster}}, n == len(f.clusters.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeRDS) DescribeDBClusters(input *rds.DescribeDBClustersInput) (*rds.DescribeDBClustersOutput, error) {
+	_, err := f.clusters.get(*input.DBClusterIdentifier)
+	return &rds.DescribeDBClustersOutput{DBClusters: []*rds.DBCluster{{DBClusterIdentifier: input.DBClusterIdentifier}}}, err
+}
+
+func (f *fakeRDS) ListTagsForResource(input *rds.ListTagsForResourceInput) (*rds.ListTagsForResourceOutput, error) {
+	kind, id := splitARN(*input.ResourceName)
+	s := &f.store
+	if kind == "cluster" {
+		s = &f.clusters
+	}
+
+	if err := s.fail("ListTagsForResource"); err != nil {
+		return nil, err
+	}
+
+	i, err := s.get(id)
+	out := &rds.ListTagsForResourceOutput{}
+	for k, v := range i.tags {
+		out.TagList = append(out.TagList, &rds.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, err
+}
+
+// splitARN returns the kind and ID of an RDS resource given its ARN, e.g. "db" and "sweep".
+func splitARN(arn string) (kind, id string) {
+	parts := strings.Split(arn, ":")
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+func (f *fakeRDS) ModifyDBInstance(input *rds.ModifyDBInstanceInput) (*rds.ModifyDBInstanceOutput, error) {
+	_, err := f.get(*input.DBInstanceIdentifier)
+	return &rds.ModifyDBInstanceOutput{}, err
+}
+
+func (f *fakeRDS) DeleteDBInstance(input *rds.DeleteDBInstanceInput) (*rds.DeleteDBInstanceOutput, error) {
+	return &rds.DeleteDBInstanceOutput{}, f.delete(input.DBInstanceIdentifier)
+}
+
+func (f *fakeRDS) ModifyDBCluster(input *rds.ModifyDBClusterInput) (*rds.ModifyDBClusterOutput, error) {
+	_, err := f.clusters.get(*input.DBClusterIdentifier)
+	return &rds.ModifyDBClusterOutput{}, err
+}
+
+func (f *fakeRDS) DeleteDBCluster(input *rds.DeleteDBClusterInput) (*rds.DeleteDBClusterOutput, error) {
+	return &rds.DeleteDBClusterOutput{}, f.clusters.delete(input.DBClusterIdentifier)
+}
+
+// fakeMediaLive serves inputs from its store and channels from channels.
+type fakeMediaLive struct {
+	medialiveiface.MediaLiveAPI
+	store
+	channels store
+}
+
+func firstSeenTags(i item) map[string]*string {
+	tags := map[string]*string{"aws-janitor:first-seen-date": aws.String(i.created.Format(time.RFC3339))}
+	for k, v := range i.tags {
+		tags[k] = aws.String(v)
+	}
+	return tags
+}
+
+func (f *fakeMediaLive) ListInputsPages(input *medialive.ListInputsInput, fn func(*medialive.ListInputsOutput, bool) bool) error {
+	for n, i := range f.items {
+		in := &medialive.Input{Id: aws.String(i.id), Name: aws.String(i.id), Tags: firstSeenTags(i)}
+		if !fn(&medialive.ListInputsOutput{Inputs: []*medialive.Input{in}}, n == len(f.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeMediaLive) ListChannelsPages(input *medialive.ListChannelsInput, fn func(*medialive.ListChannelsOutput, bool) bool) error {
+	for n, i := range f.channels.items {
+		channel := &medialive.ChannelSummary{Id: aws.String(i.id), Name: aws.String(i.id), Tags: firstSeenTags(i)}
+		if !fn(&medialive.ListChannelsOutput{Channels: []*medialive.ChannelSummary{channel}}, n == len(f.channels.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeMediaLive) DeleteInput(input *medialive.DeleteInputInput) (*medialive.DeleteInputOutput, error) {
+	return &medialive.DeleteInputOutput{}, f.delete(input.InputId)
+}
+
+func (f *fakeMediaLive) DeleteChannel(input *medialive.DeleteChannelInput) (*medialive.DeleteChannelOutput, error) {
+	return &medialive.DeleteChannelOutput{}, f.channels.delete(input.ChannelId)
+}
+
+type fakeCloudFormation struct {
+	cloudformationiface.CloudFormationAPI
+	store
+}
+
+func (f *fakeCloudFormation) DescribeStacksPages(input *cloudformation.DescribeStacksInput, fn func(*cloudformation.DescribeStacksOutput, bool) bool) error {
+	for n, i := range f.items {
+		stack := &cloudformation.Stack{
+			StackName:    aws.String(i.id),
+			StackStatus:  aws.String(cloudformation.StackStatusCreateComplete),
+			CreationTime: aws.Time(i.created),
+		}
+		for k, v := range i.tags {
+			stack.Tags = append(stack.Tags, &cloudformation.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+		if !fn(&cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{stack}}, n == len(f.items)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeCloudFormation) DeleteStack(input *cloudformation.DeleteStackInput) (*cloudformation.DeleteStackOutput, error) {
+	return &cloudformation.DeleteStackOutput{}, f.delete(input.StackName)
+}
@@ -2,16 +2,16 @@ package aws
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/firehose"
-	"github.com/sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
 )
 
 type FirehoseAPI struct {
-	api *firehose.Firehose
+	api firehoseiface.FirehoseAPI
 }
 
 func (a *FirehoseAPI) getType() ResourceType {
@@ -19,31 +19,38 @@ func (a *FirehoseAPI) getType() ResourceType {
 }
 
 func (a *FirehoseAPI) getPriority() int64 {
-	return -1
+	return 9860
 }
 
-func (a *FirehoseAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = firehose.New(s, cfg)
+func (a *FirehoseAPI) new(c *Clients) {
+	a.api = c.Firehose
 }
 
 func (a *FirehoseAPI) list() (resources IResources, err error) {
-	streams, err := a.api.ListDeliveryStreams(&firehose.ListDeliveryStreamsInput{})
-	if err != nil {
-		return nil, err
-	}
+	// the SDK has no pages helper for ListDeliveryStreams
+	input := &firehose.ListDeliveryStreamsInput{Limit: limit(1, 10000)}
+	for {
+		streams, err := a.api.ListDeliveryStreams(input)
+		if err != nil {
+			return nil, err
+		}
 
-	for _, deliveryStreamName := range streams.DeliveryStreamNames {
-		r := &Firehose{
-			Name:         deliveryStreamName,
-			ID:           deliveryStreamName,
-			Tags:         make(Tags),
-			ResourceType: a.getType(),
-			api:          a.api,
+		for _, deliveryStreamName := range streams.DeliveryStreamNames {
+			r := &Firehose{
+				Name:         deliveryStreamName,
+				ID:           deliveryStreamName,
+				Tags:         make(Tags),
+				ResourceType: a.getType(),
+				api:          a.api,
+			}
+			resources = append(resources, r)
 		}
-		resources = append(resources, r)
-	}
 
-	return resources, err
+		if !aws.BoolValue(streams.HasMoreDeliveryStreams) || len(streams.DeliveryStreamNames) == 0 {
+			return resources, nil
+		}
+		input.ExclusiveStartDeliveryStreamName = streams.DeliveryStreamNames[len(streams.DeliveryStreamNames)-1]
+	}
 }
 
 // Firehose ...
@@ -51,17 +58,14 @@ type Firehose Resource
 
 // Delete ...
 func (r *Firehose) Delete() error {
-	logrus.WithField("Firehose", *r.Name).Info("Deleting Firehose")
-	api := r.api.(*firehose.Firehose)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting Firehose")
+	api := r.api.(firehoseiface.FirehoseAPI)
 	result, err := api.DeleteDeliveryStream(&firehose.DeleteDeliveryStreamInput{DeliveryStreamName: r.ID})
 	if err != nil {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("Firehose deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("Firehose deleted")
 
 	return nil
 }
@@ -97,28 +101,30 @@ func (r *Firehose) GetTags() *Tags { return &r.Tags }
 func (r *Firehose) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *Firehose) EnsureLazyLoaded() {
-	if !r.lazyLoadPerformed {
-		logrus.WithField("resource", r).Debug("Performing a lazyload on a Firehose")
-		api := r.api.(*firehose.Firehose)
-
-		tagsOutput, err := api.ListTagsForDeliveryStream(&firehose.ListTagsForDeliveryStreamInput{DeliveryStreamName: r.ID})
-		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load Firehose tags")
-		}
+func (r *Firehose) EnsureLazyLoaded() error {
+	return (*Resource)(r).lazyLoad(func() error {
+		logResource(r.ResourceType, r.ID, "lazy-load").Debug("Performing a lazyload on a Firehose")
+		api := r.api.(firehoseiface.FirehoseAPI)
+
+		if !r.tagsLoaded {
+			tagsOutput, err := api.ListTagsForDeliveryStream(&firehose.ListTagsForDeliveryStreamInput{DeliveryStreamName: r.ID})
+			if err != nil {
+				return fmt.Errorf("failed to load Firehose tags: %v", err)
+			}
 
-		if tagsOutput.Tags != nil {
-			for _, tag := range tagsOutput.Tags {
-				r.Tags[*tag.Key] = *tag.Value
+			if tagsOutput.Tags != nil {
+				for _, tag := range tagsOutput.Tags {
+					r.Tags[*tag.Key] = *tag.Value
+				}
 			}
 		}
 
 		descStream, err := api.DescribeDeliveryStream(&firehose.DescribeDeliveryStreamInput{DeliveryStreamName: r.ID})
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load Firehose description")
+			return fmt.Errorf("failed to load Firehose description: %v", err)
 		}
 
 		r.CreationDate = descStream.DeliveryStreamDescription.CreateTimestamp
-		r.lazyLoadPerformed = true
-	}
+		return nil
+	})
 }
@@ -2,18 +2,15 @@ package aws
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kinesis"
-	"github.com/sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
 )
 
 type KinesisDataStreamAPI struct {
-	api *kinesis.Kinesis
+	api kinesisiface.KinesisAPI
 }
 
 func (a *KinesisDataStreamAPI) getType() ResourceType {
@@ -21,41 +18,33 @@ func (a *KinesisDataStreamAPI) getType() ResourceType {
 }
 
 func (a *KinesisDataStreamAPI) getPriority() int64 {
-	return -1
+	return 9850
 }
 
-func (a *KinesisDataStreamAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = kinesis.New(s, cfg)
+func (a *KinesisDataStreamAPI) new(c *Clients) {
+	a.api = c.Kinesis
+}
 
-	// Addressing https://github.com/aws/aws-sdk-go/issues/1376
-	a.api.Handlers.Retry.PushBack(func(r *request.Request) {
-		err, ok := r.Error.(awserr.Error)
-		if !ok || err == nil {
-			return
-		}
-		if err.Code() == kinesis.ErrCodeLimitExceededException {
-			r.Retryable = aws.Bool(true)
+func (a *KinesisDataStreamAPI) list() (resources IResources, err error) {
+	input := &kinesis.ListStreamsInput{Limit: limit(1, 10000)}
+	err = a.api.ListStreamsPages(input, func(page *kinesis.ListStreamsOutput, lastPage bool) bool {
+		for _, streamName := range page.StreamNames {
+			r := &KinesisDataStream{
+				Name:         streamName,
+				ID:           streamName,
+				Tags:         make(Tags),
+				ResourceType: a.getType(),
+				api:          a.api,
+			}
+			resources = append(resources, r)
 		}
+		return true
 	})
-}
 
-func (a *KinesisDataStreamAPI) list() (resources IResources, err error) {
-	streams, err := a.api.ListStreams(&kinesis.ListStreamsInput{})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, streamName := range streams.StreamNames {
-		r := &KinesisDataStream{
-			Name:         streamName,
-			ID:           streamName,
-			Tags:         make(Tags),
-			ResourceType: a.getType(),
-			api:          a.api,
-		}
-		resources = append(resources, r)
-	}
-
 	return resources, err
 }
 
@@ -64,17 +53,14 @@ type KinesisDataStream Resource
 
 // Delete ...
 func (r *KinesisDataStream) Delete() error {
-	logrus.WithField("KinesisDataStream", *r.Name).Info("Deleting KinesisDataStream")
-	api := r.api.(*kinesis.Kinesis)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting KinesisDataStream")
+	api := r.api.(kinesisiface.KinesisAPI)
 	result, err := api.DeleteStream(&kinesis.DeleteStreamInput{StreamName: r.ID})
 	if err != nil {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("KinesisDataStream deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("KinesisDataStream deleted")
 
 	return nil
 }
@@ -110,28 +96,30 @@ func (r *KinesisDataStream) GetTags() *Tags { return &r.Tags }
 func (r *KinesisDataStream) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *KinesisDataStream) EnsureLazyLoaded() {
-	if !r.lazyLoadPerformed {
-		logrus.WithField("resource", r).Debug("Performing a lazyload on a KinesisDataStream")
-		api := r.api.(*kinesis.Kinesis)
-
-		tagsOutput, err := api.ListTagsForStream(&kinesis.ListTagsForStreamInput{StreamName: r.ID})
-		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load KinesisDataStream tags")
-		}
+func (r *KinesisDataStream) EnsureLazyLoaded() error {
+	return (*Resource)(r).lazyLoad(func() error {
+		logResource(r.ResourceType, r.ID, "lazy-load").Debug("Performing a lazyload on a KinesisDataStream")
+		api := r.api.(kinesisiface.KinesisAPI)
+
+		if !r.tagsLoaded {
+			tagsOutput, err := api.ListTagsForStream(&kinesis.ListTagsForStreamInput{StreamName: r.ID})
+			if err != nil {
+				return fmt.Errorf("failed to load KinesisDataStream tags: %v", err)
+			}
 
-		if tagsOutput.Tags != nil {
-			for _, tag := range tagsOutput.Tags {
-				r.Tags[*tag.Key] = *tag.Value
+			if tagsOutput.Tags != nil {
+				for _, tag := range tagsOutput.Tags {
+					r.Tags[*tag.Key] = *tag.Value
+				}
 			}
 		}
 
 		descStream, err := api.DescribeStream(&kinesis.DescribeStreamInput{StreamName: r.ID})
 		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load KinesisDataStream description")
+			return fmt.Errorf("failed to load KinesisDataStream description: %v", err)
 		}
 
 		r.CreationDate = descStream.StreamDescription.StreamCreationTimestamp
-		r.lazyLoadPerformed = true
-	}
+		return nil
+	})
 }
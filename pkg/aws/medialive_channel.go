@@ -5,13 +5,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/medialive"
+	"github.com/aws/aws-sdk-go/service/medialive/medialiveiface"
 	"github.com/sirupsen/logrus"
 )
 
 type MediaLiveChannelAPI struct {
-	api *medialive.MediaLive
+	api medialiveiface.MediaLiveAPI
 }
 
 func (a *MediaLiveChannelAPI) getType() ResourceType {
@@ -19,25 +19,30 @@ func (a *MediaLiveChannelAPI) getType() ResourceType {
 }
 
 func (a *MediaLiveChannelAPI) getPriority() int64 {
-	return -1
+	return 9900
 }
 
-func (a *MediaLiveChannelAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = medialive.New(s, cfg)
+func (a *MediaLiveChannelAPI) new(c *Clients) {
+	a.api = c.MediaLive
 }
 
 func (a *MediaLiveChannelAPI) list() (resources IResources, err error) {
-	list, err := a.api.ListChannels(&medialive.ListChannelsInput{})
+	var channels []*medialive.ChannelSummary
+	err = a.api.ListChannelsPages(&medialive.ListChannelsInput{MaxResults: limit(1, 1000)}, func(page *medialive.ListChannelsOutput, lastPage bool) bool {
+		channels = append(channels, page.Channels...)
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, channel := range list.Channels {
+	for _, channel := range channels {
 		r := &MediaLiveChannel{
 			Name:         channel.Name,
 			ID:           channel.Id,
 			Tags:         make(Tags),
 			CreationDate: nil,
+			Status:       channel.State,
 			ResourceType: a.getType(),
 			api:          a.api,
 		}
@@ -46,7 +51,7 @@ func (a *MediaLiveChannelAPI) list() (resources IResources, err error) {
 			if r.CreationDate == nil && k == FirstSeenDateTimeMarker {
 				firstSeenDate, err := time.Parse(time.RFC3339, *v)
 				if err != nil {
-					logrus.WithField("TagValue", *v).Warn("Failed to parse marker tag value into DateTime")
+					logrus.WithField("tag_value", *v).Warn("Failed to parse marker tag value into DateTime")
 				} else {
 					r.CreationDate = &firstSeenDate
 				}
@@ -65,11 +70,7 @@ func (a *MediaLiveChannelAPI) list() (resources IResources, err error) {
 			})
 
 			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"Error": err.Error(),
-					"ID":    *channel.Id,
-					"Name":  *channel.Name,
-				}).Warn("Failed to set marker tag for a medialive_channel resource")
+				logResource(a.getType(), channel.Id, "list").WithError(err).Warn("Failed to set marker tag for a medialive_channel resource")
 			}
 		}
 
@@ -84,21 +85,48 @@ type MediaLiveChannel Resource
 
 // Delete ...
 func (r *MediaLiveChannel) Delete() error {
-	logrus.WithField("MediaLiveChannel", *r.Name).Info("Deleting MediaLiveChannel")
-	api := r.api.(*medialive.MediaLive)
-	result, err := api.DeleteInput(&medialive.DeleteInputInput{InputId: r.ID})
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting MediaLiveChannel")
+	api := r.api.(medialiveiface.MediaLiveAPI)
+	result, err := api.DeleteChannel(&medialive.DeleteChannelInput{ChannelId: r.ID})
 	if err != nil {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("MediaLiveChannel deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("MediaLiveChannel deleted")
 
 	return nil
 }
 
+// Stop ...
+func (r *MediaLiveChannel) Stop(ScaleDown) (ResourceState, error) {
+	state := ResourceState{"status": aws.StringValue(r.Status)}
+	if aws.StringValue(r.Status) != medialive.ChannelStateRunning {
+		logResource(r.ResourceType, r.ID, "stop").Info("MediaLiveChannel is not running. Nothing to stop")
+		return state, nil
+	}
+
+	logResource(r.ResourceType, r.ID, "stop").Info("Stopping MediaLiveChannel")
+	api := r.api.(medialiveiface.MediaLiveAPI)
+	if _, err := api.StopChannel(&medialive.StopChannelInput{ChannelId: r.ID}); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Start ...
+func (r *MediaLiveChannel) Start(state ResourceState) error {
+	if state["status"] != medialive.ChannelStateRunning {
+		logResource(r.ResourceType, r.ID, "start").Info("MediaLiveChannel was not running before it was stopped. Not starting it")
+		return nil
+	}
+
+	logResource(r.ResourceType, r.ID, "start").Info("Starting MediaLiveChannel")
+	api := r.api.(medialiveiface.MediaLiveAPI)
+	_, err := api.StartChannel(&medialive.StartChannelInput{ChannelId: r.ID})
+	return err
+}
+
 // String ...
 func (r *MediaLiveChannel) String() string {
 	b, _ := json.Marshal(r)
@@ -130,4 +158,4 @@ func (r *MediaLiveChannel) GetTags() *Tags { return &r.Tags }
 func (r *MediaLiveChannel) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *MediaLiveChannel) EnsureLazyLoaded() {}
+func (r *MediaLiveChannel) EnsureLazyLoaded() error { return nil }
@@ -5,13 +5,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/medialive"
+	"github.com/aws/aws-sdk-go/service/medialive/medialiveiface"
 	"github.com/sirupsen/logrus"
 )
 
 type MediaLiveInputAPI struct {
-	api *medialive.MediaLive
+	api medialiveiface.MediaLiveAPI
 }
 
 func (a *MediaLiveInputAPI) getType() ResourceType {
@@ -19,20 +19,24 @@ func (a *MediaLiveInputAPI) getType() ResourceType {
 }
 
 func (a *MediaLiveInputAPI) getPriority() int64 {
-	return -1
+	return 9890
 }
 
-func (a *MediaLiveInputAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = medialive.New(s, cfg)
+func (a *MediaLiveInputAPI) new(c *Clients) {
+	a.api = c.MediaLive
 }
 
 func (a *MediaLiveInputAPI) list() (resources IResources, err error) {
-	list, err := a.api.ListInputs(&medialive.ListInputsInput{})
+	var inputs []*medialive.Input
+	err = a.api.ListInputsPages(&medialive.ListInputsInput{MaxResults: limit(1, 1000)}, func(page *medialive.ListInputsOutput, lastPage bool) bool {
+		inputs = append(inputs, page.Inputs...)
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, input := range list.Inputs {
+	for _, input := range inputs {
 		r := &MediaLiveInput{
 			Name:         input.Name,
 			ID:           input.Id,
@@ -46,7 +50,7 @@ func (a *MediaLiveInputAPI) list() (resources IResources, err error) {
 			if r.CreationDate == nil && k == FirstSeenDateTimeMarker {
 				firstSeenDate, err := time.Parse(time.RFC3339, *v)
 				if err != nil {
-					logrus.WithField("TagValue", *v).Warn("Failed to parse marker tag value into DateTime")
+					logrus.WithField("tag_value", *v).Warn("Failed to parse marker tag value into DateTime")
 				} else {
 					r.CreationDate = &firstSeenDate
 				}
@@ -65,11 +69,7 @@ func (a *MediaLiveInputAPI) list() (resources IResources, err error) {
 			})
 
 			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"Error": err.Error(),
-					"ID":    *input.Id,
-					"Name":  *input.Name,
-				}).Warn("Failed to set marker tag for a resource")
+				logResource(a.getType(), input.Id, "list").WithError(err).Warn("Failed to set marker tag for a resource")
 			}
 		}
 
@@ -84,17 +84,14 @@ type MediaLiveInput Resource
 
 // Delete ...
 func (r *MediaLiveInput) Delete() error {
-	logrus.WithField("MediaLiveInput", *r.Name).Info("Deleting MediaLiveInput")
-	api := r.api.(*medialive.MediaLive)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting MediaLiveInput")
+	api := r.api.(medialiveiface.MediaLiveAPI)
 	result, err := api.DeleteInput(&medialive.DeleteInputInput{InputId: r.ID})
 	if err != nil {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("MediaLiveInput deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("MediaLiveInput deleted")
 
 	return nil
 }
@@ -130,4 +127,4 @@ func (r *MediaLiveInput) GetTags() *Tags { return &r.Tags }
 func (r *MediaLiveInput) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *MediaLiveInput) EnsureLazyLoaded() {}
+func (r *MediaLiveInput) EnsureLazyLoaded() error { return nil }
@@ -0,0 +1,22 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// pageSize is the number of resources requested per page when listing, 0 for the default of each API.
+var pageSize int64
+
+// limit returns the configured page size within the bounds accepted by an API or nil to use its default.
+func limit(min, max int64) *int64 {
+	switch {
+	case pageSize <= 0:
+		return nil
+	case pageSize < min:
+		return aws.Int64(min)
+	case pageSize > max:
+		return aws.Int64(max)
+	}
+
+	return aws.Int64(pageSize)
+}
@@ -0,0 +1,226 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/medialive"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+// stubSession returns a session whose requests are answered by respond instead of AWS.
+// respond returns the output of the operation given its input.
+func stubSession(t *testing.T, respond func(operation string, input interface{}) interface{}) *session.Session {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+	}))
+
+	sess.Handlers.Send.Clear()
+	sess.Handlers.Unmarshal.Clear()
+	sess.Handlers.UnmarshalMeta.Clear()
+	sess.Handlers.UnmarshalError.Clear()
+	sess.Handlers.ValidateResponse.Clear()
+	sess.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(&bytes.Buffer{})}
+		output := respond(r.Operation.Name, r.Params)
+		if output == nil {
+			t.Fatalf("unexpected operation %s", r.Operation.Name)
+		}
+		reflect.ValueOf(r.Data).Elem().Set(reflect.ValueOf(output).Elem())
+	})
+
+	return sess
+}
+
+const totalResources = 250
+
+func resourceName(i int) string {
+	return fmt.Sprintf("r-%03d", i)
+}
+
+// page returns the names of the resources on the page starting after the given name (or at the given index
+// for numeric tokens) and the token of the next page.
+func page(start *string, size *int64) (names []*string, next *string) {
+	first := 0
+	if start != nil {
+		if i, err := strconv.Atoi(*start); err == nil {
+			first = i
+		} else {
+			fmt.Sscanf(*start, "r-%03d", &first)
+			first++
+		}
+	}
+
+	n := int(aws.Int64Value(size))
+	if n == 0 {
+		n = 100
+	}
+
+	for i := first; i < first+n && i < totalResources; i++ {
+		names = append(names, aws.String(resourceName(i)))
+	}
+
+	if first+n < totalResources {
+		next = aws.String(strconv.Itoa(first + n))
+	}
+
+	return names, next
+}
+
+func TestListAllPages(t *testing.T) {
+	defer func() { pageSize = 0 }()
+	now := aws.String(time.Now().Format(time.RFC3339))
+	firstSeen := map[string]*string{FirstSeenDateTimeMarker: now}
+
+	tests := []struct {
+		resourceType iResourceType
+		respond      func(operation string, input interface{}) interface{}
+	}{
+		{&EC2API{}, func(operation string, input interface{}) interface{} {
+			in := input.(*ec2.DescribeInstancesInput)
+			names, next := page(in.NextToken, in.MaxResults)
+			out := &ec2.DescribeInstancesOutput{NextToken: next}
+			for _, n := range names {
+				out.Reservations = append(out.Reservations, &ec2.Reservation{Instances: []*ec2.Instance{
+					{InstanceId: n, State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+				}})
+			}
+			return out
+		}},
+		{&DynamoDbTableApi{}, func(operation string, input interface{}) interface{} {
+			in := input.(*dynamodb.ListTablesInput)
+			names, next := page(in.ExclusiveStartTableName, in.Limit)
+			out := &dynamodb.ListTablesOutput{TableNames: names}
+			if next != nil {
+				out.LastEvaluatedTableName = names[len(names)-1]
+			}
+			return out
+		}},
+		{&KinesisDataStreamAPI{}, func(operation string, input interface{}) interface{} {
+			in := input.(*kinesis.ListStreamsInput)
+			names, next := page(in.ExclusiveStartStreamName, in.Limit)
+			return &kinesis.ListStreamsOutput{StreamNames: names, HasMoreStreams: aws.Bool(next != nil)}
+		}},
+		{&FirehoseAPI{}, func(operation string, input interface{}) interface{} {
+			in := input.(*firehose.ListDeliveryStreamsInput)
+			names, next := page(in.ExclusiveStartDeliveryStreamName, in.Limit)
+			return &firehose.ListDeliveryStreamsOutput{DeliveryStreamNames: names, HasMoreDeliveryStreams: aws.Bool(next != nil)}
+		}},
+		{&RDSInstanceAPI{}, func(operation string, input interface{}) interface{} {
+			in := input.(*rds.DescribeDBInstancesInput)
+			names, next := page(in.Marker, in.MaxRecords)
+			out := &rds.DescribeDBInstancesOutput{Marker: next}
+			for _, n := range names {
+				out.DBInstances = append(out.DBInstances, &rds.DBInstance{DBInstanceIdentifier: n})
+			}
+			return out
+		}},
+		{&RDSClusterAPI{}, func(operation string, input interface{}) interface{} {
+			in := input.(*rds.DescribeDBClustersInput)
+			names, next := page(in.Marker, in.MaxRecords)
+			out := &rds.DescribeDBClustersOutput{Marker: next}
+			for _, n := range names {
+				out.DBClusters = append(out.DBClusters, &rds.DBCluster{DBClusterIdentifier: n})
+			}
+			return out
+		}},
+		{&MediaLiveInputAPI{}, func(operation string, input interface{}) interface{} {
+			in := input.(*medialive.ListInputsInput)
+			names, next := page(in.NextToken, in.MaxResults)
+			out := &medialive.ListInputsOutput{NextToken: next}
+			for _, n := range names {
+				out.Inputs = append(out.Inputs, &medialive.Input{Id: n, Name: n, Tags: firstSeen})
+			}
+			return out
+		}},
+		{&MediaLiveChannelAPI{}, func(operation string, input interface{}) interface{} {
+			in := input.(*medialive.ListChannelsInput)
+			names, next := page(in.NextToken, in.MaxResults)
+			out := &medialive.ListChannelsOutput{NextToken: next}
+			for _, n := range names {
+				out.Channels = append(out.Channels, &medialive.ChannelSummary{Id: n, Name: n, Tags: firstSeen})
+			}
+			return out
+		}},
+		{&CloudFormationStackAPI{}, func(operation string, input interface{}) interface{} {
+			in := input.(*cloudformation.DescribeStacksInput)
+			names, next := page(in.NextToken, nil)
+			out := &cloudformation.DescribeStacksOutput{NextToken: next}
+			for _, n := range names {
+				out.Stacks = append(out.Stacks, &cloudformation.Stack{StackName: n, StackStatus: aws.String(cloudformation.StackStatusCreateComplete)})
+			}
+			return out
+		}},
+	}
+
+	for _, size := range []int64{0, 50} {
+		pageSize = size
+		for _, tc := range tests {
+			t.Run(fmt.Sprintf("%s/%d", tc.resourceType.getType(), size), func(t *testing.T) {
+				calls := 0
+				sess := stubSession(t, func(operation string, input interface{}) interface{} {
+					calls++
+					return tc.respond(operation, input)
+				})
+				tc.resourceType.new(NewClients(sess, &aws.Config{}))
+
+				resources, err := tc.resourceType.list()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var ids []string
+				for _, r := range resources {
+					ids = append(ids, r.GetID())
+				}
+				sort.Strings(ids)
+
+				if len(ids) != totalResources || ids[0] != resourceName(0) || ids[len(ids)-1] != resourceName(totalResources-1) {
+					t.Errorf("expected all %d resources, got %d", totalResources, len(ids))
+				}
+
+				if calls < 3 {
+					t.Errorf("expected at least 3 pages to be requested, got %d", calls)
+				}
+			})
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	defer func() { pageSize = 0 }()
+
+	tests := []struct {
+		pageSize int64
+		expected *int64
+	}{
+		{0, nil},
+		{10, aws.Int64(20)},
+		{50, aws.Int64(50)},
+		{500, aws.Int64(100)},
+	}
+
+	for _, tc := range tests {
+		pageSize = tc.pageSize
+		if actual := limit(20, 100); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("page size %d: expected %v, got %v", tc.pageSize, aws.Int64Value(tc.expected), aws.Int64Value(actual))
+		}
+	}
+}
@@ -6,14 +6,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/sirupsen/logrus"
 )
 
 type RDSClusterAPI struct {
-	api *rds.RDS
+	api rdsiface.RDSAPI
 }
 
 func (a *RDSClusterAPI) getType() ResourceType {
@@ -21,31 +20,35 @@ func (a *RDSClusterAPI) getType() ResourceType {
 }
 
 func (a *RDSClusterAPI) getPriority() int64 {
-	return -1
+	return 9790
 }
 
-func (a *RDSClusterAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = rds.New(s, cfg)
+func (a *RDSClusterAPI) new(c *Clients) {
+	a.api = c.RDS
 }
 
 func (a *RDSClusterAPI) list() (resources IResources, err error) {
-	output, err := a.api.DescribeDBClusters(&rds.DescribeDBClustersInput{})
+	input := &rds.DescribeDBClustersInput{MaxRecords: limit(20, 100)}
+	err = a.api.DescribeDBClustersPages(input, func(page *rds.DescribeDBClustersOutput, lastPage bool) bool {
+		for _, cluster := range page.DBClusters {
+			r := &RDSCluster{
+				Name:         cluster.DBClusterArn,
+				ID:           cluster.DBClusterIdentifier,
+				CreationDate: cluster.ClusterCreateTime,
+				Status:       cluster.Status,
+				Tags:         make(Tags),
+				ResourceType: a.getType(),
+				api:          a.api,
+			}
+			resources = append(resources, r)
+		}
+		return true
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
-	for _, cluster := range output.DBClusters {
-		r := &RDSCluster{
-			Name:         cluster.DBClusterArn,
-			ID:           cluster.DBClusterIdentifier,
-			CreationDate: cluster.ClusterCreateTime,
-			Tags:         make(Tags),
-			ResourceType: a.getType(),
-			api:          a.api,
-		}
-		resources = append(resources, r)
-	}
-
 	return resources, err
 }
 
@@ -54,8 +57,8 @@ type RDSCluster Resource
 
 // Delete ...
 func (r *RDSCluster) Delete() error {
-	logrus.WithField("RDSCluster", *r.Name).Info("Deleting RDSCluster")
-	api := r.api.(*rds.RDS)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting RDSCluster")
+	api := r.api.(rdsiface.RDSAPI)
 
 	_, err := api.ModifyDBCluster(&rds.ModifyDBClusterInput{
 		ApplyImmediately:    aws.Bool(true),
@@ -72,7 +75,7 @@ func (r *RDSCluster) Delete() error {
 	}
 
 	for _, instance := range output.DBClusters[0].DBClusterMembers {
-		logrus.WithField("ID", *instance.DBInstanceIdentifier).Info("Deleting cluster member")
+		logResource(r.ResourceType, r.ID, "delete").WithField("member", aws.StringValue(instance.DBInstanceIdentifier)).Info("Deleting cluster member")
 		_, err := api.DeleteDBInstance(&rds.DeleteDBInstanceInput{
 			DBInstanceIdentifier: instance.DBInstanceIdentifier,
 			SkipFinalSnapshot:    aws.Bool(true),
@@ -91,14 +94,41 @@ func (r *RDSCluster) Delete() error {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("RDSCluster deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("RDSCluster deleted")
 
 	return nil
 }
 
+// Stop ...
+func (r *RDSCluster) Stop(ScaleDown) (ResourceState, error) {
+	state := ResourceState{"status": aws.StringValue(r.Status)}
+	if aws.StringValue(r.Status) != "available" {
+		logResource(r.ResourceType, r.ID, "stop").Info("RDSCluster is not available. Nothing to stop")
+		return state, nil
+	}
+
+	logResource(r.ResourceType, r.ID, "stop").Info("Stopping RDSCluster")
+	api := r.api.(rdsiface.RDSAPI)
+	if _, err := api.StopDBCluster(&rds.StopDBClusterInput{DBClusterIdentifier: r.ID}); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Start ...
+func (r *RDSCluster) Start(state ResourceState) error {
+	if state["status"] != "available" {
+		logResource(r.ResourceType, r.ID, "start").Info("RDSCluster was not available before it was stopped. Not starting it")
+		return nil
+	}
+
+	logResource(r.ResourceType, r.ID, "start").Info("Starting RDSCluster")
+	api := r.api.(rdsiface.RDSAPI)
+	_, err := api.StartDBCluster(&rds.StartDBClusterInput{DBClusterIdentifier: r.ID})
+	return err
+}
+
 // String ...
 func (r *RDSCluster) String() string {
 	b, _ := json.Marshal(r)
@@ -130,22 +160,24 @@ func (r *RDSCluster) GetTags() *Tags { return &r.Tags }
 func (r *RDSCluster) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *RDSCluster) EnsureLazyLoaded() {
-	if !r.lazyLoadPerformed {
-		logrus.WithField("resource", r).Debug("Performing a lazyload on a RDSCluster")
-		api := r.api.(*rds.RDS)
-
-		tagsOutput, err := api.ListTagsForResource(&rds.ListTagsForResourceInput{ResourceName: r.Name})
-		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load RDSCluster tags")
-		}
+func (r *RDSCluster) EnsureLazyLoaded() error {
+	return (*Resource)(r).lazyLoad(func() error {
+		logResource(r.ResourceType, r.ID, "lazy-load").Debug("Performing a lazyload on a RDSCluster")
+		api := r.api.(rdsiface.RDSAPI)
+
+		if !r.tagsLoaded {
+			tagsOutput, err := api.ListTagsForResource(&rds.ListTagsForResourceInput{ResourceName: r.Name})
+			if err != nil {
+				return fmt.Errorf("failed to load RDSCluster tags: %v", err)
+			}
 
-		if tagsOutput.TagList != nil {
-			for _, tag := range tagsOutput.TagList {
-				r.Tags[*tag.Key] = *tag.Value
+			if tagsOutput.TagList != nil {
+				for _, tag := range tagsOutput.TagList {
+					r.Tags[*tag.Key] = *tag.Value
+				}
 			}
 		}
 
-		r.lazyLoadPerformed = true
-	}
+		return nil
+	})
 }
@@ -6,14 +6,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/sirupsen/logrus"
 )
 
 type RDSInstanceAPI struct {
-	api *rds.RDS
+	api rdsiface.RDSAPI
 }
 
 func (a *RDSInstanceAPI) getType() ResourceType {
@@ -21,33 +20,37 @@ func (a *RDSInstanceAPI) getType() ResourceType {
 }
 
 func (a *RDSInstanceAPI) getPriority() int64 {
-	return -1
+	return 9800
 }
 
-func (a *RDSInstanceAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = rds.New(s, cfg)
+func (a *RDSInstanceAPI) new(c *Clients) {
+	a.api = c.RDS
 }
 
 func (a *RDSInstanceAPI) list() (resources IResources, err error) {
-	output, err := a.api.DescribeDBInstances(&rds.DescribeDBInstancesInput{})
-	if err != nil {
-		return nil, err
-	}
-
-	for _, instance := range output.DBInstances {
-		if instance.DBClusterIdentifier == nil {
-			r := &RDSInstance{
-				Name:         instance.DBInstanceArn,
-				ID:           instance.DBInstanceIdentifier,
-				CreationDate: instance.InstanceCreateTime,
-				Tags:         make(Tags),
-				ResourceType: a.getType(),
-				api:          a.api,
+	input := &rds.DescribeDBInstancesInput{MaxRecords: limit(20, 100)}
+	err = a.api.DescribeDBInstancesPages(input, func(page *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+		for _, instance := range page.DBInstances {
+			if instance.DBClusterIdentifier == nil {
+				r := &RDSInstance{
+					Name:         instance.DBInstanceArn,
+					ID:           instance.DBInstanceIdentifier,
+					CreationDate: instance.InstanceCreateTime,
+					Status:       instance.DBInstanceStatus,
+					Tags:         make(Tags),
+					ResourceType: a.getType(),
+					api:          a.api,
+				}
+				resources = append(resources, r)
+			} else {
+				logResource(a.getType(), instance.DBInstanceIdentifier, "list").WithField("cluster", aws.StringValue(instance.DBClusterIdentifier)).Debug("Ignoring RdsInstance because it is part of cluster")
 			}
-			resources = append(resources, r)
-		} else {
-			logrus.WithField("DBClusterIdentifier", *instance.DBClusterIdentifier).Info("Ignoring RdsInstance because it is part of cluster")
 		}
+		return true
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return resources, err
@@ -58,8 +61,8 @@ type RDSInstance Resource
 
 // Delete ...
 func (r *RDSInstance) Delete() error {
-	logrus.WithField("RDSInstance", *r.Name).Info("Deleting RDSInstance")
-	api := r.api.(*rds.RDS)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting RDSInstance")
+	api := r.api.(rdsiface.RDSAPI)
 
 	_, err := api.ModifyDBInstance(&rds.ModifyDBInstanceInput{
 		ApplyImmediately:     aws.Bool(true),
@@ -79,14 +82,41 @@ func (r *RDSInstance) Delete() error {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"Result": result.String(),
-		"Name":   *r.Name,
-	}).Info("RDSInstance deleted")
+	logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("RDSInstance deleted")
 
 	return nil
 }
 
+// Stop ...
+func (r *RDSInstance) Stop(ScaleDown) (ResourceState, error) {
+	state := ResourceState{"status": aws.StringValue(r.Status)}
+	if aws.StringValue(r.Status) != "available" {
+		logResource(r.ResourceType, r.ID, "stop").Info("RDSInstance is not available. Nothing to stop")
+		return state, nil
+	}
+
+	logResource(r.ResourceType, r.ID, "stop").Info("Stopping RDSInstance")
+	api := r.api.(rdsiface.RDSAPI)
+	if _, err := api.StopDBInstance(&rds.StopDBInstanceInput{DBInstanceIdentifier: r.ID}); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Start ...
+func (r *RDSInstance) Start(state ResourceState) error {
+	if state["status"] != "available" {
+		logResource(r.ResourceType, r.ID, "start").Info("RDSInstance was not available before it was stopped. Not starting it")
+		return nil
+	}
+
+	logResource(r.ResourceType, r.ID, "start").Info("Starting RDSInstance")
+	api := r.api.(rdsiface.RDSAPI)
+	_, err := api.StartDBInstance(&rds.StartDBInstanceInput{DBInstanceIdentifier: r.ID})
+	return err
+}
+
 // String ...
 func (r *RDSInstance) String() string {
 	b, _ := json.Marshal(r)
@@ -118,22 +148,24 @@ func (r *RDSInstance) GetTags() *Tags { return &r.Tags }
 func (r *RDSInstance) GetCreationDate() *time.Time { return r.CreationDate }
 
 // EnsureLazyLoaded ...
-func (r *RDSInstance) EnsureLazyLoaded() {
-	if !r.lazyLoadPerformed {
-		logrus.WithField("resource", r).Debug("Performing a lazyload on a RDSInstance")
-		api := r.api.(*rds.RDS)
-
-		tagsOutput, err := api.ListTagsForResource(&rds.ListTagsForResourceInput{ResourceName: r.Name})
-		if err != nil {
-			logrus.WithError(err).Fatal("Failed to load RdsInstance tags")
-		}
+func (r *RDSInstance) EnsureLazyLoaded() error {
+	return (*Resource)(r).lazyLoad(func() error {
+		logResource(r.ResourceType, r.ID, "lazy-load").Debug("Performing a lazyload on a RDSInstance")
+		api := r.api.(rdsiface.RDSAPI)
+
+		if !r.tagsLoaded {
+			tagsOutput, err := api.ListTagsForResource(&rds.ListTagsForResourceInput{ResourceName: r.Name})
+			if err != nil {
+				return fmt.Errorf("failed to load RdsInstance tags: %v", err)
+			}
 
-		if tagsOutput.TagList != nil {
-			for _, tag := range tagsOutput.TagList {
-				r.Tags[*tag.Key] = *tag.Value
+			if tagsOutput.TagList != nil {
+				for _, tag := range tagsOutput.TagList {
+					r.Tags[*tag.Key] = *tag.Value
+				}
 			}
 		}
 
-		r.lazyLoadPerformed = true
-	}
+		return nil
+	})
 }
@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// AllRegions can be configured instead of a list of regions to sweep all regions enabled in the account.
+const AllRegions = "all"
+
+// defaultRegions are the regions used to reach the global endpoints of a partition. It's also the region
+// of S3 buckets without a location constraint.
+var defaultRegions = map[string]string{
+	endpoints.AwsPartitionID:      endpoints.UsEast1RegionID,
+	endpoints.AwsCnPartitionID:    endpoints.CnNorth1RegionID,
+	endpoints.AwsUsGovPartitionID: endpoints.UsGovWest1RegionID,
+}
+
+// PartitionOf returns the ID of the partition (aws, aws-cn, aws-us-gov) the region belongs to.
+// Unknown regions are considered to be in the commercial partition.
+func PartitionOf(region string) string {
+	if p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		return p.ID()
+	}
+
+	return endpoints.AwsPartitionID
+}
+
+// DefaultRegion returns the default region of a partition.
+func DefaultRegion(partition string) string {
+	if region, ok := defaultRegions[partition]; ok {
+		return region
+	}
+
+	return endpoints.UsEast1RegionID
+}
+
+// ListRegions returns all regions of the partition which are enabled in the account,
+// i.e. which do not require opt-in or have been opted in to.
+func ListRegions(partition string, opts SessionOptions) ([]string, error) {
+	sess, cfg, err := NewSession(DefaultRegion(partition), opts)
+	if err != nil {
+		return nil, err
+	}
+	api := ec2.New(sess, cfg)
+
+	output, err := api.DescribeRegions(&ec2.DescribeRegionsInput{AllRegions: aws.Bool(true)})
+	if err != nil {
+		return nil, err
+	}
+
+	var regions []string
+	for _, r := range output.Regions {
+		if aws.StringValue(r.OptInStatus) == "not-opted-in" {
+			logrus.WithField(logging.Region, aws.StringValue(r.RegionName)).Debug("Region is not opted in. Skipping")
+			continue
+		}
+
+		regions = append(regions, aws.StringValue(r.RegionName))
+	}
+
+	return regions, nil
+}
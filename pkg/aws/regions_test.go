@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestBucketRegion(t *testing.T) {
+	tests := []struct {
+		location *string
+		region   string
+		expected string
+	}{
+		{nil, "eu-west-1", "us-east-1"},
+		{aws.String(""), "us-west-2", "us-east-1"},
+		{nil, "cn-northwest-1", "cn-north-1"},
+		{nil, "us-gov-east-1", "us-gov-west-1"},
+		{aws.String("EU"), "eu-west-1", "eu-west-1"},
+		{aws.String("eu-central-1"), "eu-west-1", "eu-central-1"},
+	}
+
+	for _, tc := range tests {
+		if actual := bucketRegion(tc.location, tc.region); actual != tc.expected {
+			t.Errorf("bucketRegion(%v, %s): expected %s, got %s", aws.StringValue(tc.location), tc.region, tc.expected, actual)
+		}
+	}
+}
+
+func TestPartitionOf(t *testing.T) {
+	tests := map[string]string{
+		"eu-west-1":     "aws",
+		"cn-north-1":    "aws-cn",
+		"us-gov-west-1": "aws-us-gov",
+		"unknown":       "aws",
+	}
+
+	for region, expected := range tests {
+		if actual := PartitionOf(region); actual != expected {
+			t.Errorf("PartitionOf(%s): expected %s, got %s", region, expected, actual)
+		}
+	}
+}
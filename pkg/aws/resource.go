@@ -1,22 +1,64 @@
 package aws
 
 import (
-	"time"
 	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/tracing"
+	"github.com/sirupsen/logrus"
 )
 
 // Region ...
 type Region = string
 
+// Account ...
+type Account = string
+
 // Resource ...
 type Resource struct {
 	Name              *string
 	ID                *string
 	Tags              Tags
 	CreationDate      *time.Time
+	Status            *string
 	ResourceType      ResourceType
 	api               interface{}
 	lazyLoadPerformed bool
+	lazyLoadErr       error
+	tagsLoaded        bool
+}
+
+// startLazyLoad starts the span of loading the attributes of a resource which are not returned when listing it.
+func startLazyLoad(r *Resource) *tracing.Span {
+	return tracing.Start("lazy-load",
+		tracing.String("resource.type", string(r.ResourceType)),
+		tracing.String("resource.id", aws.StringValue(r.ID)),
+	)
+}
+
+// lazyLoad loads the attributes of a resource which are not returned when listing it, once. If that fails, the
+// error is returned by every call, so that the resource is skipped rather than matched on missing attributes.
+func (r *Resource) lazyLoad(load func() error) error {
+	if !r.lazyLoadPerformed {
+		span := startLazyLoad(r)
+		r.lazyLoadErr = load()
+		span.SetError(r.lazyLoadErr)
+		span.End()
+		r.lazyLoadPerformed = true
+	}
+
+	return r.lazyLoadErr
+}
+
+// logResource returns the logger of an action (e.g. delete) on a resource, with the fields identifying it.
+func logResource(resourceType ResourceType, id *string, action string) *logrus.Entry {
+	return logrus.WithFields(logrus.Fields{
+		logging.Type:   resourceType,
+		logging.ID:     aws.StringValue(id),
+		logging.Action: action,
+	})
 }
 
 // Tags ...
@@ -30,7 +72,9 @@ type IResource interface {
 	GetCreationDate() *time.Time
 	Delete() error
 	String() string
-	EnsureLazyLoaded()
+	// EnsureLazyLoaded loads the attributes not returned when listing the resource, e.g. its tags. It returns the
+	// error of loading them, also on later calls.
+	EnsureLazyLoaded() error
 }
 
 // IResources ...
@@ -64,4 +108,31 @@ func (rrtrs *IRegionResourceTypeResources) Len() int {
 	}
 
 	return counter
-}
\ No newline at end of file
+}
+
+// IAccountRegionResourceTypeResources ...
+type IAccountRegionResourceTypeResources map[Account]IRegionResourceTypeResources
+
+func (arrtrs *IAccountRegionResourceTypeResources) String() string {
+	output := ""
+	for account, rrtrs := range *arrtrs {
+		for region, rtrs := range rrtrs {
+			for resourceType, resources := range rtrs {
+				for _, r := range resources {
+					output = output + fmt.Sprintf("- [%s][%s][%s][%s] %s\n", account, region, resourceType, r.GetID(), r.GetName())
+				}
+			}
+		}
+	}
+
+	return output
+}
+
+func (arrtrs *IAccountRegionResourceTypeResources) Len() int {
+	counter := 0
+	for _, rrtrs := range *arrtrs {
+		counter += rrtrs.Len()
+	}
+
+	return counter
+}
@@ -3,15 +3,14 @@ package aws
 import (
 	"fmt"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
 	"github.com/sirupsen/logrus"
 )
 
 type ResourceType string
 
 type iResourceType interface {
-	new(*session.Session, *aws.Config)
+	new(*Clients)
 	list() (IResources, error)
 	getType() ResourceType
 	getPriority() int64
@@ -19,9 +18,47 @@ type iResourceType interface {
 
 var registeredResourceTypes = make(map[ResourceType]iResourceType)
 
+// resourceTypes returns new instances of all supported resource types.
+func resourceTypes() []iResourceType {
+	return []iResourceType{
+		&EC2API{},
+		&S3BucketAPI{},
+		&DynamoDbTableApi{},
+		&ElasticSearchDomainApi{},
+		&KinesisDataStreamAPI{},
+		&FirehoseAPI{},
+		&RDSInstanceAPI{},
+		&RDSClusterAPI{},
+		&MediaLiveInputAPI{},
+		&MediaLiveChannelAPI{},
+		&CloudFormationStackAPI{},
+	}
+}
+
+// SupportedResourceTypes returns the types of all supported resources, whether registered for a region or not.
+func SupportedResourceTypes() (types []ResourceType) {
+	for _, r := range resourceTypes() {
+		types = append(types, r.getType())
+	}
+
+	return types
+}
+
+// Priority returns the deletion priority of a resource type. Resources of types with a higher priority are deleted
+// first, so that resources others depend on (e.g. RDS clusters and their instances) are deleted last.
+func Priority(resourceType ResourceType) int64 {
+	for _, r := range resourceTypes() {
+		if r.getType() == resourceType {
+			return r.getPriority()
+		}
+	}
+
+	return -1
+}
+
 // IsRegistered ...
 func IsRegistered(resourceType ResourceType) bool {
-	logrus.WithField("resourceType", resourceType).Debug("Checking if resourceType is supported")
+	logrus.WithField(logging.Type, resourceType).Debug("Checking if resourceType is supported")
 
 	if _, ok := registeredResourceTypes[resourceType]; ok {
 		return true
@@ -31,9 +68,9 @@ func IsRegistered(resourceType ResourceType) bool {
 }
 
 // Register ...
-func register(s *session.Session, cfg *aws.Config, r iResourceType) {
-	logrus.WithField("ResourceType", r.getType()).Debug("Registering new resource type")
-	r.new(s, cfg)
+func register(clients *Clients, r iResourceType) {
+	logrus.WithField(logging.Type, r.getType()).Debug("Registering new resource type")
+	r.new(clients)
 	registeredResourceTypes[r.getType()] = r
 }
 
@@ -43,5 +80,10 @@ func List(resourceType ResourceType) (IResources, error) {
 		return nil, fmt.Errorf("ResourceType (%v) is not supported", resourceType)
 	}
 
-	return registeredResourceTypes[resourceType].list()
+	resources, err := registeredResourceTypes[resourceType].list()
+	if err == nil && taggingAPI != nil {
+		loadTags(taggingAPI, resourceType, resources)
+	}
+
+	return resources, err
 }
@@ -0,0 +1,266 @@
+package aws_test
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+)
+
+var created = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// items returns a resource tagged team=a to keep and one tagged team=b to sweep.
+func items() []item {
+	return []item{
+		{id: "keep", tags: map[string]string{"team": "a"}, created: created},
+		{id: "sweep", tags: map[string]string{"team": "b"}, created: created},
+	}
+}
+
+func TestResourceTypes(t *testing.T) {
+	rds := &fakeRDS{store: store{items: items()}, clusters: store{items: items()}}
+	medialive := &fakeMediaLive{store: store{items: items()}, channels: store{items: items()}}
+
+	testCases := []struct {
+		resourceType aws.ResourceType
+		clients      *aws.Clients
+		deleted      func() []string
+	}{
+		{resourceType: "ec2"},
+		{resourceType: "s3_bucket"},
+		{resourceType: "dynamodb_table"},
+		{resourceType: "elasticsearch_domain"},
+		{resourceType: "kinesis_data_stream"},
+		{resourceType: "firehose"},
+		{resourceType: "rds_instance", clients: &aws.Clients{RDS: rds}, deleted: func() []string { return rds.deleted }},
+		{resourceType: "rds_cluster", clients: &aws.Clients{RDS: rds}, deleted: func() []string { return rds.clusters.deleted }},
+		{resourceType: "medialive_input", clients: &aws.Clients{MediaLive: medialive}, deleted: func() []string { return medialive.deleted }},
+		{resourceType: "medialive_channel", clients: &aws.Clients{MediaLive: medialive}, deleted: func() []string { return medialive.channels.deleted }},
+		{resourceType: aws.CloudFormationStackType},
+	}
+
+	// the types backed by a single fake
+	for i, tc := range testCases {
+		if tc.clients != nil {
+			continue
+		}
+
+		var s *store
+		switch tc.resourceType {
+		case "ec2":
+			f := &fakeEC2{store: store{items: items()}}
+			tc.clients, s = &aws.Clients{EC2: f}, &f.store
+		case "s3_bucket":
+			f := &fakeS3{store: store{items: items()}}
+			tc.clients, s = &aws.Clients{S3: f}, &f.store
+		case "dynamodb_table":
+			f := &fakeDynamoDB{store: store{items: items()}}
+			tc.clients, s = &aws.Clients{DynamoDB: f}, &f.store
+		case "elasticsearch_domain":
+			f := &fakeElasticsearchService{store: store{items: items()}}
+			tc.clients, s = &aws.Clients{ElasticsearchService: f}, &f.store
+		case "kinesis_data_stream":
+			f := &fakeKinesis{store: store{items: items()}}
+			tc.clients, s = &aws.Clients{Kinesis: f}, &f.store
+		case "firehose":
+			f := &fakeFirehose{store: store{items: items()}}
+			tc.clients, s = &aws.Clients{Firehose: f}, &f.store
+		case aws.CloudFormationStackType:
+			f := &fakeCloudFormation{store: store{items: items()}}
+			tc.clients, s = &aws.Clients{CloudFormation: f}, &f.store
+		}
+		testCases[i].clients = tc.clients
+		testCases[i].deleted = func() []string { return s.deleted }
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.resourceType), func(t *testing.T) {
+			tc.clients.Region = "eu-west-1"
+			aws.NewWithClients(tc.clients)
+
+			resources, err := aws.List(tc.resourceType)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			if len(resources) != 2 {
+				t.Fatalf("List() = %d resources, want 2", len(resources))
+			}
+
+			var ids []string
+			for _, r := range resources {
+				ids = append(ids, r.GetID())
+			}
+			sort.Strings(ids)
+			if ids[0] != "keep" || ids[1] != "sweep" {
+				t.Fatalf("List() ids = %v, want [keep sweep]", ids)
+			}
+
+			for _, r := range resources {
+				r.EnsureLazyLoaded()
+
+				if got := (*r.GetTags())["team"]; got == "" {
+					t.Errorf("%s: tags = %v, want team tag", r.GetID(), *r.GetTags())
+				}
+
+				if r.GetCreationDate() == nil || !r.GetCreationDate().Equal(created) {
+					t.Errorf("%s: creation date = %v, want %v", r.GetID(), r.GetCreationDate(), created)
+				}
+			}
+
+			fs := filters.Filters{{Tags: &filters.Tags{{"team": "^b$"}}}}
+			if err := fs.Compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			matched, err := fs.Apply(resources)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			if len(matched) != 1 || matched[0].GetID() != "sweep" {
+				t.Fatalf("Apply() = %v, want sweep", matched)
+			}
+
+			for _, r := range matched {
+				if err := r.Delete(); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+			}
+
+			if deleted := tc.deleted(); len(deleted) != 1 || deleted[0] != "sweep" {
+				t.Errorf("deleted = %v, want [sweep]", deleted)
+			}
+		})
+	}
+}
+
+func TestDynamoDbTable_StopStart(t *testing.T) {
+	capacity := func(read, write int64) *dynamodb.ProvisionedThroughputDescription {
+		return &dynamodb.ProvisionedThroughputDescription{ReadCapacityUnits: awssdk.Int64(read), WriteCapacityUnits: awssdk.Int64(write)}
+	}
+	f := &fakeDynamoDB{
+		store:      store{items: []item{{id: "table"}}},
+		throughput: capacity(10, 5),
+		indexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{IndexName: awssdk.String("by-name"), ProvisionedThroughput: capacity(20, 4)},
+			{IndexName: awssdk.String("by-date"), ProvisionedThroughput: capacity(1, 1)},
+		},
+	}
+	aws.NewWithClients(&aws.Clients{Region: "eu-west-1", DynamoDB: f})
+
+	resources, err := aws.List("dynamodb_table")
+	if err != nil || len(resources) != 1 {
+		t.Fatalf("List() = %v, %v", resources, err)
+	}
+	table := resources[0].(aws.IStoppable)
+
+	state, err := table.Stop(aws.ScaleDown{ReadCapacity: 1, WriteCapacity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := aws.ResourceState{
+		"billing_mode":                 dynamodb.BillingModeProvisioned,
+		"read_capacity":                "10",
+		"write_capacity":               "5",
+		"index/by-name/read_capacity":  "20",
+		"index/by-name/write_capacity": "4",
+		"index/by-date/read_capacity":  "1",
+		"index/by-date/write_capacity": "1",
+	}
+	if !reflect.DeepEqual(state, expected) {
+		t.Errorf("Stop() state = %v, want %v", state, expected)
+	}
+
+	// indexes already at the target are left as they are
+	if len(f.updates) != 1 || f.updates[0].ProvisionedThroughput == nil ||
+		len(f.updates[0].GlobalSecondaryIndexUpdates) != 1 ||
+		*f.updates[0].GlobalSecondaryIndexUpdates[0].Update.IndexName != "by-name" ||
+		*f.updates[0].GlobalSecondaryIndexUpdates[0].Update.ProvisionedThroughput.ReadCapacityUnits != 1 {
+		t.Errorf("expected the table and index by-name to be scaled down, got %v", f.updates)
+	}
+
+	if err := table.Start(state); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := f.updates[len(f.updates)-1]
+	var indexes []string
+	for _, u := range restored.GlobalSecondaryIndexUpdates {
+		p := u.Update.ProvisionedThroughput
+		indexes = append(indexes, fmt.Sprintf("%s=%d/%d", *u.Update.IndexName, *p.ReadCapacityUnits, *p.WriteCapacityUnits))
+	}
+	if *restored.ProvisionedThroughput.ReadCapacityUnits != 10 || *restored.ProvisionedThroughput.WriteCapacityUnits != 5 ||
+		!reflect.DeepEqual(indexes, []string{"by-date=1/1", "by-name=20/4"}) {
+		t.Errorf("expected the capacity of the table and its indexes to be restored, got %v", restored)
+	}
+}
+
+func TestEnsureLazyLoaded_Failures(t *testing.T) {
+	testCases := []struct {
+		resourceType aws.ResourceType
+		operation    string
+		clients      func(s store) *aws.Clients
+	}{
+		{"s3_bucket", "GetBucketTagging", func(s store) *aws.Clients { return &aws.Clients{S3: &fakeS3{store: s}} }},
+		{"dynamodb_table", "DescribeTable", func(s store) *aws.Clients { return &aws.Clients{DynamoDB: &fakeDynamoDB{store: s}} }},
+		{"dynamodb_table", "ListTagsOfResource", func(s store) *aws.Clients { return &aws.Clients{DynamoDB: &fakeDynamoDB{store: s}} }},
+		{"elasticsearch_domain", "DescribeElasticsearchDomain", func(s store) *aws.Clients {
+			return &aws.Clients{ElasticsearchService: &fakeElasticsearchService{store: s}}
+		}},
+		{"elasticsearch_domain", "DescribeElasticsearchDomainConfig", func(s store) *aws.Clients {
+			return &aws.Clients{ElasticsearchService: &fakeElasticsearchService{store: s}}
+		}},
+		{"elasticsearch_domain", "ListTags", func(s store) *aws.Clients {
+			return &aws.Clients{ElasticsearchService: &fakeElasticsearchService{store: s}}
+		}},
+		{"kinesis_data_stream", "ListTagsForStream", func(s store) *aws.Clients { return &aws.Clients{Kinesis: &fakeKinesis{store: s}} }},
+		{"kinesis_data_stream", "DescribeStream", func(s store) *aws.Clients { return &aws.Clients{Kinesis: &fakeKinesis{store: s}} }},
+		{"firehose", "ListTagsForDeliveryStream", func(s store) *aws.Clients { return &aws.Clients{Firehose: &fakeFirehose{store: s}} }},
+		{"firehose", "DescribeDeliveryStream", func(s store) *aws.Clients { return &aws.Clients{Firehose: &fakeFirehose{store: s}} }},
+		{"rds_instance", "ListTagsForResource", func(s store) *aws.Clients { return &aws.Clients{RDS: &fakeRDS{store: s}} }},
+		{"rds_cluster", "ListTagsForResource", func(s store) *aws.Clients { return &aws.Clients{RDS: &fakeRDS{clusters: s}} }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.resourceType)+"/"+tc.operation, func(t *testing.T) {
+			clients := tc.clients(store{items: items(), failing: tc.operation})
+			clients.Region = "eu-west-1"
+			aws.NewWithClients(clients)
+
+			resources, err := aws.List(tc.resourceType)
+			if err != nil || len(resources) != 2 {
+				t.Fatalf("List() = %v, %v", resources, err)
+			}
+
+			for _, r := range resources {
+				err := r.EnsureLazyLoaded()
+				if err == nil || !strings.Contains(err.Error(), tc.operation+" failed") {
+					t.Errorf("%s: EnsureLazyLoaded() error = %v, want the error of %s", r.GetID(), err, tc.operation)
+				}
+
+				// later calls return the error of the first one
+				if again := r.EnsureLazyLoaded(); again == nil || again.Error() != err.Error() {
+					t.Errorf("%s: EnsureLazyLoaded() error on the second call = %v, want %v", r.GetID(), again, err)
+				}
+			}
+
+			// without the tags, no resource is matched by a filter on them
+			fs := filters.Filters{{Tags: &filters.Tags{{"team": "^b$"}}}}
+			if err := fs.Compile(); err != nil {
+				t.Fatal(err)
+			}
+			if matched, err := fs.Apply(resources); err != nil || len(matched) != 0 {
+				t.Errorf("Apply() = %v, %v, want no resources", matched, err)
+			}
+		})
+	}
+}
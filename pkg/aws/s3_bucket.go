@@ -2,17 +2,19 @@ package aws
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 )
 
 type S3BucketAPI struct {
-	api *s3.S3
+	api    s3iface.S3API
+	region string
 }
 
 func (a *S3BucketAPI) getType() ResourceType {
@@ -23,11 +25,13 @@ func (a *S3BucketAPI) getPriority() int64 {
 	return 9750
 }
 
-func (a *S3BucketAPI) new(s *session.Session, cfg *aws.Config) {
-	a.api = s3.New(s, cfg)
+func (a *S3BucketAPI) new(c *Clients) {
+	a.api = c.S3
+	a.region = c.Region
 }
 
 func (a *S3BucketAPI) list() (resources IResources, err error) {
+	// ListBuckets returns all buckets at once
 	buckets, err := a.api.ListBuckets(&s3.ListBucketsInput{})
 	if err != nil {
 		return nil, err
@@ -49,42 +53,43 @@ func (a *S3BucketAPI) list() (resources IResources, err error) {
 			return nil, err
 		}
 
-		var bucketLocation string
-		if bucketLocationOutput.LocationConstraint == nil {
-			bucketLocation = "us-east-1"
-		} else {
-			bucketLocation = *bucketLocationOutput.LocationConstraint
-		}
+		bucketLocation := bucketRegion(bucketLocationOutput.LocationConstraint, a.region)
 
-		if a.api.SigningRegion == bucketLocation {
+		if a.region == bucketLocation {
 			resources = append(resources, r)
 		} else {
-			logrus.WithFields(logrus.Fields{
-				"Signing Region":  a.api.SigningRegion,
-				"Bucket Location": bucketLocation,
-				"Bucket":          *bucket.Name,
-			}).Debug("Bucket is not in the current region. Skipping")
+			logResource(a.getType(), bucket.Name, "list").WithField("bucket_location", bucketLocation).Debug("Bucket is not in the current region. Skipping")
 		}
 	}
 
 	return resources, err
 }
 
+// bucketRegion returns the region of a bucket given its location constraint and the region it was requested from.
+// Buckets without a location constraint are in the default region of the partition, "EU" is the legacy name of eu-west-1.
+func bucketRegion(locationConstraint *string, region string) string {
+	switch location := aws.StringValue(locationConstraint); location {
+	case "":
+		return DefaultRegion(PartitionOf(region))
+	case s3.BucketLocationConstraintEu:
+		return endpoints.EuWest1RegionID
+	default:
+		return location
+	}
+}
+
 // S3Bucket ...
 type S3Bucket Resource
 
 // Delete ...
 func (r *S3Bucket) Delete() error {
-	logrus.WithField("Bucket", *r.Name).Info("Deleting a Bucket")
-	api := r.api.(*s3.S3)
+	logResource(r.ResourceType, r.ID, "delete").Info("Deleting a Bucket")
+	api := r.api.(s3iface.S3API)
 
 	if dbop, err := api.DeleteBucketPolicy(&s3.DeleteBucketPolicyInput{Bucket: r.ID}); err != nil {
 		return err
 	} else {
-		logrus.WithFields(logrus.Fields{
-			"Result": dbop.String(),
-			"Bucket": *r.Name,
-		}).Info("Bucket policy deleted")
+		logResource(r.ResourceType, r.ID, "delete").WithField("result", dbop.String()).Info("Bucket policy deleted")
 
 		result, err := api.DeleteBucket(&s3.DeleteBucketInput{Bucket: r.ID})
 
@@ -92,10 +97,7 @@ func (r *S3Bucket) Delete() error {
 			return err
 		}
 
-		logrus.WithFields(logrus.Fields{
-			"Result": result.String(),
-			"Name":   *r.Name,
-		}).Info("Bucket deleted")
+		logResource(r.ResourceType, r.ID, "delete").WithField("result", result.String()).Info("Bucket deleted")
 	}
 	return nil
 }
@@ -131,25 +133,26 @@ func (r *S3Bucket) GetTags() *Tags {
 // GetCreationDate ...
 func (r *S3Bucket) GetCreationDate() *time.Time { return r.CreationDate }
 
-func (r *S3Bucket) EnsureLazyLoaded() {
-	if !r.lazyLoadPerformed {
-		logrus.WithField("resource", r).Debug("Performing a lazyload on a bucket")
-		api := r.api.(*s3.S3)
+func (r *S3Bucket) EnsureLazyLoaded() error {
+	return (*Resource)(r).lazyLoad(func() error {
+		logResource(r.ResourceType, r.ID, "lazy-load").Debug("Performing a lazyload on a bucket")
+		api := r.api.(s3iface.S3API)
 
-		if taggingOutput, err := api.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: r.ID}); err == nil {
-			if taggingOutput.TagSet != nil {
-				for _, tag := range taggingOutput.TagSet {
-					r.Tags[*tag.Key] = *tag.Value
-				}
-			}
-		} else {
+		if !r.tagsLoaded {
+			taggingOutput, err := api.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: r.ID})
 			// NoSuchTagSet is an expected error when bucket doesn't have any tag
 			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NoSuchTagSet" {
-			} else {
-				logrus.WithError(err).WithField("Bucket", r.Name).Fatal("Failed to load Tags")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load Tags: %v", err)
+			}
+
+			for _, tag := range taggingOutput.TagSet {
+				r.Tags[*tag.Key] = *tag.Value
 			}
 		}
 
-		r.lazyLoadPerformed = true
-	}
+		return nil
+	})
 }
@@ -0,0 +1,17 @@
+package aws
+
+// ResourceState captures how a resource looked before it was stopped, so that Start can restore it accurately.
+type ResourceState map[string]string
+
+// ScaleDown describes the target a resource is scaled down to when it is stopped.
+// Capacities only apply to resources with provisioned capacity (e.g. DynamoDB tables).
+type ScaleDown struct {
+	ReadCapacity  int64
+	WriteCapacity int64
+}
+
+// IStoppable is implemented by resources that can be stopped off-hours instead of being deleted.
+type IStoppable interface {
+	Stop(ScaleDown) (ResourceState, error)
+	Start(ResourceState) error
+}
@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// taggingResourceTypes maps the resource types whose listers don't return tags to the resource type filters of
+// the Resource Groups Tagging API.
+var taggingResourceTypes = map[ResourceType]string{
+	"s3_bucket":            "s3",
+	"dynamodb_table":       "dynamodb:table",
+	"elasticsearch_domain": "es:domain",
+	"kinesis_data_stream":  "kinesis:stream",
+	"firehose":             "firehose:deliverystream",
+	"rds_instance":         "rds:db",
+	"rds_cluster":          "rds:cluster",
+}
+
+// taggingAPI fetches the tags of all resources of a type in bulk. If nil, tags are loaded per resource.
+var taggingAPI resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+
+// loadTags sets the tags of the resources, fetched with as few calls to the Resource Groups Tagging API as possible.
+// Resources of types not covered by the API, or if the API fails, load their tags lazily one by one.
+func loadTags(api resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI, resourceType ResourceType, resources IResources) {
+	filter, ok := taggingResourceTypes[resourceType]
+	if !ok || len(resources) == 0 {
+		return
+	}
+
+	tags := make(map[string]Tags)
+	input := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: []*string{aws.String(filter)},
+		ResourcesPerPage:    aws.Int64(100),
+	}
+	err := api.GetResourcesPages(input, func(page *resourcegroupstaggingapi.GetResourcesOutput, lastPage bool) bool {
+		for _, mapping := range page.ResourceTagMappingList {
+			id, err := resourceIDOf(aws.StringValue(mapping.ResourceARN))
+			if err != nil {
+				logrus.WithError(err).Debug("Skipping tags of an unexpected ARN")
+				continue
+			}
+
+			t := make(Tags)
+			for _, tag := range mapping.Tags {
+				t[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			tags[id] = t
+		}
+		return true
+	})
+
+	if err != nil {
+		logrus.WithError(err).WithField(logging.Type, resourceType).Warn("Failed to fetch tags in bulk. Loading them per resource")
+		return
+	}
+
+	for _, r := range resources {
+		res := resourceOf(r)
+		if res == nil {
+			continue
+		}
+
+		// resources without tags are not returned by the API
+		if res.Tags == nil {
+			res.Tags = make(Tags)
+		}
+		for k, v := range tags[r.GetID()] {
+			res.Tags[k] = v
+		}
+		res.tagsLoaded = true
+	}
+}
+
+// resourceIDOf returns the ID a resource is listed with given its ARN, e.g. the name of a bucket, table or stream,
+// or the identifier of a database.
+func resourceIDOf(resourceARN string) (string, error) {
+	a, err := arn.Parse(resourceARN)
+	if err != nil {
+		return "", err
+	}
+
+	// arn:aws:rds:eu-west-1:111111111111:db:identifier
+	if a.Service == "rds" {
+		if i := strings.Index(a.Resource, ":"); i >= 0 {
+			return a.Resource[i+1:], nil
+		}
+	}
+
+	// arn:aws:dynamodb:eu-west-1:111111111111:table/name, arn:aws:s3:::name
+	if i := strings.Index(a.Resource, "/"); i >= 0 {
+		return a.Resource[i+1:], nil
+	}
+
+	return a.Resource, nil
+}
+
+// resourceOf returns the resource underlying the resources of all supported types.
+func resourceOf(r IResource) *Resource {
+	switch res := r.(type) {
+	case *S3Bucket:
+		return (*Resource)(res)
+	case *DynamoDbTable:
+		return (*Resource)(res)
+	case *ElasticSearchDomain:
+		return (*Resource)(res)
+	case *KinesisDataStream:
+		return (*Resource)(res)
+	case *Firehose:
+		return (*Resource)(res)
+	case *RDSInstance:
+		return (*Resource)(res)
+	case *RDSCluster:
+		return (*Resource)(res)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+)
+
+type fakeTaggingAPI struct {
+	resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+	mappings map[string][]*resourcegroupstaggingapi.ResourceTagMapping
+	calls    int
+	err      error
+}
+
+func (f *fakeTaggingAPI) GetResourcesPages(input *resourcegroupstaggingapi.GetResourcesInput, fn func(*resourcegroupstaggingapi.GetResourcesOutput, bool) bool) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+
+	// one page per resource to exercise pagination
+	mappings := f.mappings[aws.StringValue(input.ResourceTypeFilters[0])]
+	for i, m := range mappings {
+		if !fn(&resourcegroupstaggingapi.GetResourcesOutput{ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{m}}, i == len(mappings)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func mapping(arn string, tags map[string]string) *resourcegroupstaggingapi.ResourceTagMapping {
+	m := &resourcegroupstaggingapi.ResourceTagMapping{ResourceARN: aws.String(arn)}
+	for k, v := range tags {
+		m.Tags = append(m.Tags, &resourcegroupstaggingapi.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return m
+}
+
+func TestResourceIDOf(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:s3:::logs.example.com":                                 "logs.example.com",
+		"arn:aws:dynamodb:eu-west-1:111111111111:table/orders":          "orders",
+		"arn:aws:rds:eu-west-1:111111111111:db:orders-db":               "orders-db",
+		"arn:aws:rds:eu-west-1:111111111111:cluster:orders-cluster":     "orders-cluster",
+		"arn:aws:firehose:eu-west-1:111111111111:deliverystream/clicks": "clicks",
+		"arn:aws-cn:kinesis:cn-north-1:111111111111:stream/events":      "events",
+		"arn:aws:es:eu-west-1:111111111111:domain/search":               "search",
+	}
+
+	for arn, expected := range tests {
+		id, err := resourceIDOf(arn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expected {
+			t.Errorf("%s: expected %s, got %s", arn, expected, id)
+		}
+	}
+
+	if _, err := resourceIDOf("logs"); err == nil {
+		t.Error("expected an error for an invalid ARN")
+	}
+}
+
+func TestLoadTags(t *testing.T) {
+	api := &fakeTaggingAPI{mappings: map[string][]*resourcegroupstaggingapi.ResourceTagMapping{
+		"s3": {
+			mapping("arn:aws:s3:::tagged", map[string]string{"team": "a", "env": "dev"}),
+			mapping("arn:aws:s3:::other-region", map[string]string{"team": "b"}),
+		},
+	}}
+
+	tagged := &S3Bucket{ID: aws.String("tagged")}
+	untagged := &S3Bucket{ID: aws.String("untagged")}
+	loadTags(api, "s3_bucket", IResources{tagged, untagged})
+
+	if !reflect.DeepEqual(tagged.Tags, Tags{"team": "a", "env": "dev"}) || !tagged.tagsLoaded {
+		t.Errorf("unexpected tags of tagged bucket: %v", tagged.Tags)
+	}
+	if len(untagged.Tags) != 0 || !untagged.tagsLoaded {
+		t.Errorf("expected untagged bucket to have loaded empty tags: %v", untagged.Tags)
+	}
+
+	loadTags(api, "ec2", IResources{&Instance{ID: aws.String("i-1")}})
+	if api.calls != 1 {
+		t.Errorf("expected no call for a type whose lister returns tags, got %d calls", api.calls)
+	}
+
+	failing := &fakeTaggingAPI{err: errors.New("AccessDenied")}
+	fallback := &S3Bucket{ID: aws.String("tagged")}
+	loadTags(failing, "s3_bucket", IResources{fallback})
+	if fallback.tagsLoaded {
+		t.Error("expected tags to be loaded per resource after a failure")
+	}
+}
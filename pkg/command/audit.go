@@ -0,0 +1,153 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/accounts"
+	"github.com/cmpsoares91/awsweeper/pkg/audit"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+func auditCommand(args []string) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprintln(os.Stderr, "Usage: awsweeper audit verify [options] <audit log or s3://bucket/key>")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	applyOptions := optionFlags(fs)
+	var heads listFlag
+	fs.Var(&heads, "head", "hash of the last record of a run, as logged at its end, which the log must contain (repeatable)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: awsweeper audit verify [options] <audit log or s3://bucket/key>")
+		return 2
+	}
+
+	var opts config.Options
+	if err := applyOptions(&opts); err != nil {
+		logrus.WithError(err).Error("Invalid options")
+		return 2
+	}
+
+	sess, cfg, err := aws.NewSession(aws.DefaultRegion(opts.PartitionID()), opts.SessionOptions())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create AWS session")
+		return 1
+	}
+
+	sink, err := audit.NewSink(fs.Arg(0), sess, cfg)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open audit log")
+		return 1
+	}
+
+	data, err := sink.Read()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to read audit log")
+		return 1
+	}
+
+	entries, err := audit.Verify(data, heads...)
+	if err != nil {
+		logrus.WithError(err).WithField("verified", len(entries)).Error("Audit log has been tampered with")
+		return 1
+	}
+
+	runs := 0
+	for _, e := range entries {
+		if e.Kind == audit.KindStart {
+			runs++
+		}
+	}
+
+	fmt.Printf("Audit log is intact: %d entries of %d runs\n", len(entries), runs)
+	return 0
+}
+
+// openAudit opens the audit logs configured in the options and records the start of a run of the command with the
+// identity of the caller and the hash of the config. Without audit logs, it returns nil.
+func openAudit(cfg *config.Config, command string) (*audit.Log, error) {
+	opts := cfg.Options
+	if opts.AuditLog == "" && opts.AuditS3 == "" {
+		return nil, nil
+	}
+
+	sess, awsCfg, err := aws.NewSession(aws.DefaultRegion(opts.PartitionID()), opts.SessionOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	var sinks []audit.Sink
+	for _, location := range []string{opts.AuditLog, opts.AuditS3} {
+		if location == "" {
+			continue
+		}
+
+		sink, err := audit.NewSink(location, sess, awsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log %s: %v", location, err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	identity, err := accounts.NewResolver(sess, awsCfg).CallerIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := cfg.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := audit.Open(fmt.Sprint(logging.Get(logging.RunID)), sinks...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = log.Record(audit.Entry{
+		Kind:    audit.KindStart,
+		Command: command,
+		Caller: &audit.Caller{
+			Account: awssdk.StringValue(identity.Account),
+			ARN:     awssdk.StringValue(identity.Arn),
+			UserID:  awssdk.StringValue(identity.UserId),
+		},
+		ConfigHash: hash,
+		DryRun:     opts.DryRun,
+	})
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+
+	return log, nil
+}
+
+// endAudit records the end of a run in the audit log and logs the hashes of the last records, so that removing them
+// can be detected by verifying the log with these heads. It returns the error of the run, or of writing the log.
+func endAudit(log *audit.Log, err error) error {
+	if log == nil {
+		return err
+	}
+
+	if auditErr := log.End(err); auditErr != nil {
+		if err == nil {
+			err = fmt.Errorf("failed to write the audit log: %v", auditErr)
+		}
+		return err
+	}
+
+	logrus.WithField("audit_heads", log.Heads()).Info("Recorded the run in the audit log")
+	return err
+}
@@ -0,0 +1,189 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultConfigFile is the config file used when none is given on the command line.
+const DefaultConfigFile = "config.yaml"
+
+// Run dispatches to the command named by the first argument and returns the exit code.
+// Without a known command name, the arguments are passed to the wipe command.
+func Run(args []string) int {
+	defer logging.Push(logrus.Fields{logging.RunID: logging.NewRunID()})()
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "wipe":
+			return wipeCommand(args[1:])
+		case "stop":
+			return stopCommand(args[1:])
+		case "start":
+			return startCommand(args[1:])
+		case "validate":
+			return validateCommand(args[1:])
+		case "schema":
+			return schemaCommand(args[1:])
+		case "config":
+			return configCommand(args[1:])
+		case "list":
+			return listCommand(args[1:])
+		case "diff":
+			return diffCommand(args[1:])
+		case "generate":
+			return generateCommand(args[1:])
+		case "sweep-deployment":
+			return sweepDeploymentCommand(args[1:])
+		case "audit":
+			return auditCommand(args[1:])
+		case "serve":
+			return serveCommand(args[1:])
+		case "help", "-h", "-help", "--help":
+			usage()
+			return 0
+		}
+	}
+
+	return wipeCommand(args)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: awsweeper [command] [options] [config.yaml]
+
+Commands:
+  wipe      Delete all resources matched by the filters (default)
+  stop      Stop or scale down resources matched by scheduled filters
+  start     Restore resources stopped by the stop command
+  validate  Check config files without contacting AWS
+  schema    Print the JSON Schema of the config file
+  config    Print the fully resolved config file (config render)
+
+  list              List all existing resources, optionally filtered by type ([types...])
+  diff              Show resources created, deleted and retagged between two inventory snapshots
+  generate          Print a starter config with a filter for every existing resource
+  sweep-deployment  Delete all resources tagged with a deployment identifier, without a config file
+  audit verify      Check that an audit log of deletions has not been tampered with
+  serve             Run the jobs of the config on their cron schedules and serve their reports
+`)
+}
+
+// varsFlag collects the variables given by repeated -var key=value flags.
+type varsFlag map[string]string
+
+func (v varsFlag) String() string {
+	var vars []string
+	for key, value := range v {
+		vars = append(vars, key+"="+value)
+	}
+
+	return strings.Join(vars, ",")
+}
+
+func (v varsFlag) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+
+	v[kv[0]] = kv[1]
+	return nil
+}
+
+// configFlags registers the flags to load the config file with: the variables to interpolate and the flags which
+// take precedence over the options of the config file. It returns a function loading the config file passed as
+// positional argument, to be called once the flags are parsed.
+func configFlags(fs *flag.FlagSet) func() (*config.Config, error) {
+	vars := make(varsFlag)
+	fs.Var(vars, "var", "variable to interpolate into the config file as key=value (repeatable)")
+	applyOptions := optionFlags(fs)
+
+	return func() (*config.Config, error) {
+		cfg, err := config.LoadWithVars(configFile(fs), vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := applyOptions(&cfg.Options); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+}
+
+// optionFlags registers the flags which take precedence over the options of the config file
+// and returns a function applying them and setting up the logs accordingly.
+func optionFlags(fs *flag.FlagSet) func(*config.Options) error {
+	var o config.Options
+	fs.StringVar(&o.Profile, "profile", "", "shared config profile to use")
+	fs.StringVar(&o.RoleToAssume, "role-to-assume", "", "ARN of the role to assume")
+	fs.StringVar(&o.ExternalID, "external-id", "", "external ID required by the role to assume")
+	fs.StringVar(&o.SessionName, "session-name", "", "session name of the assumed role")
+	fs.DurationVar(&o.Duration, "duration", 0, "duration of the assumed role session")
+	fs.StringVar(&o.MFASerial, "mfa-serial", "", "serial number of the MFA device to prompt a token for")
+	fs.StringVar(&o.WebIdentityTokenFile, "web-identity-token-file", "", "file with an OIDC token to assume the role with")
+	endpointURL := fs.String("endpoint-url", "", "endpoint used for all services, e.g. of LocalStack")
+	fs.StringVar(&o.Record, "record", "", "file to record the requests to AWS and their responses to")
+	fs.StringVar(&o.Replay, "replay", "", "file with recorded responses to serve instead of contacting AWS")
+	fs.StringVar(&o.LogLevel, "log-level", "", "level of the logs: error, warn, info (default), debug or trace")
+	fs.StringVar(&o.LogFormat, "log-format", "", "format of the logs: text (default) or json")
+
+	return func(opts *config.Options) error {
+		if o.Profile != "" {
+			opts.Profile = o.Profile
+		}
+		if o.RoleToAssume != "" {
+			opts.RoleToAssume = o.RoleToAssume
+		}
+		if o.ExternalID != "" {
+			opts.ExternalID = o.ExternalID
+		}
+		if o.SessionName != "" {
+			opts.SessionName = o.SessionName
+		}
+		if o.Duration != 0 {
+			opts.Duration = o.Duration
+		}
+		if o.MFASerial != "" {
+			opts.MFASerial = o.MFASerial
+		}
+		if o.WebIdentityTokenFile != "" {
+			opts.WebIdentityTokenFile = o.WebIdentityTokenFile
+		}
+		if o.Record != "" {
+			opts.Record = o.Record
+		}
+		if o.Replay != "" {
+			opts.Replay = o.Replay
+		}
+		if o.LogLevel != "" {
+			opts.LogLevel = o.LogLevel
+		}
+		if o.LogFormat != "" {
+			opts.LogFormat = o.LogFormat
+		}
+		if *endpointURL != "" {
+			if opts.Endpoints == nil {
+				opts.Endpoints = make(map[string]string)
+			}
+			opts.Endpoints["default"] = *endpointURL
+		}
+
+		return logging.Setup(opts.LogLevel, opts.LogFormat)
+	}
+}
+
+// configFile returns the config file passed as positional argument.
+func configFile(fs *flag.FlagSet) string {
+	if fs.NArg() > 0 {
+		return fs.Arg(0)
+	}
+
+	return DefaultConfigFile
+}
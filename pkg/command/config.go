@@ -0,0 +1,45 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "render" {
+		fmt.Fprintln(os.Stderr, "Usage: awsweeper config render [options] [config.yaml]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config render", flag.ContinueOnError)
+	loadConfig := configFlags(fs)
+	region := fs.String("region", "", "apply the overrides of this region")
+	account := fs.String("account", "", "apply the overrides of this account")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open config file")
+		return 1
+	}
+
+	if *region != "" || *account != "" {
+		cfg.Filters = cfg.FiltersFor(*account, *region)
+		cfg.Overrides = nil
+	}
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to render config")
+		return 1
+	}
+
+	fmt.Print(string(out))
+	return 0
+}
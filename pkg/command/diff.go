@@ -0,0 +1,60 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/sirupsen/logrus"
+)
+
+func diffCommand(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	exitCode := fs.Bool("exit-code", false, "exit with 1 if the snapshots differ")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: awsweeper diff [options] <old snapshot> <new snapshot>")
+		return 2
+	}
+
+	var snapshots []*inventory.Snapshot
+	for _, filename := range fs.Args() {
+		s, err := inventory.LoadSnapshot(filename)
+		if err != nil {
+			logrus.WithError(err).WithField("file", filename).Error("Failed to open snapshot")
+			return 1
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	diff := inventory.Compare(snapshots[0], snapshots[1])
+	switch *format {
+	case "text":
+		if err := diff.WriteText(os.Stdout); err != nil {
+			logrus.WithError(err).Error("Failed to write diff")
+			return 1
+		}
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			logrus.WithError(err).Error("Failed to write diff")
+			return 1
+		}
+	default:
+		logrus.WithField("format", *format).Error("Unknown format, expected text or json")
+		return 2
+	}
+
+	if *exitCode && !diff.Empty() {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/wipe"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+func generateCommand(args []string) int {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	applyOptions := optionFlags(fs)
+	var regions, types listFlag
+	fs.Var(&regions, "regions", "regions to list resources in (repeatable, comma separated)")
+	fs.Var(&types, "types", "resource types to list, all supported types by default (repeatable, comma separated)")
+	groupByTag := fs.String("group-by-tag", "", "group resources into one filter per value of this tag")
+	output := fs.String("o", "", "file to write the config to instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// options may be taken from a config file, its filters are ignored
+	cfg := &config.Config{}
+	if fs.NArg() > 0 {
+		loaded, err := config.Load(fs.Arg(0))
+		if err != nil {
+			logrus.WithError(err).Error("Failed to open config file")
+			return 1
+		}
+		cfg.Options = loaded.Options
+	}
+
+	if err := applyOptions(&cfg.Options); err != nil {
+		logrus.WithError(err).Error("Invalid options")
+		return 2
+	}
+	if len(regions) > 0 {
+		cfg.Options.Regions = regions
+	}
+	if len(cfg.Options.Regions) == 0 {
+		logrus.Error("At least one region is required")
+		return 2
+	}
+
+	supported := make(map[aws.ResourceType]bool)
+	for _, t := range aws.SupportedResourceTypes() {
+		supported[t] = true
+	}
+
+	var resourceTypes []aws.ResourceType
+	for _, t := range types {
+		if !supported[aws.ResourceType(t)] {
+			logrus.WithField(logging.Type, t).Error("Resource type is not supported")
+			return 2
+		}
+		resourceTypes = append(resourceTypes, aws.ResourceType(t))
+	}
+
+	wiper := wipe.Wiper{
+		Config: cfg,
+	}
+
+	inventory, warnings, err := wiper.List(resourceTypes...)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list resources")
+		return 1
+	}
+
+	if len(warnings) > 0 {
+		logrus.WithField("warnings", warnings).Warn("Unable to perform as expected because of these warnings")
+	}
+
+	out, err := config.Generate(cfg.Options, inventory, *groupByTag)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate config")
+		return 1
+	}
+
+	if *output == "" {
+		fmt.Print(string(out))
+		return 0
+	}
+
+	if err := afero.WriteFile(config.AppFs, *output, out, 0644); err != nil {
+		logrus.WithError(err).Error("Failed to write config")
+		return 1
+	}
+
+	logrus.WithField("file", *output).Infof("Generated a config with %d resources", inventory.Len())
+	return 0
+}
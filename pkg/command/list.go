@@ -0,0 +1,120 @@
+package command
+
+import (
+	"flag"
+	"os"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/wipe"
+	"github.com/sirupsen/logrus"
+)
+
+func listCommand(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	applyOptions := optionFlags(fs)
+	configFile := fs.String("config", "", "config file to take the options and accounts from, its filters are ignored")
+	var regions listFlag
+	fs.Var(&regions, "regions", "regions to list resources in (repeatable, comma separated)")
+	format := fs.String("format", inventory.FormatTable, "output format: table, json or csv")
+	sortBy := fs.String("sort", "", "attribute to sort by: account, region, type, id, name, age or tag:<key>")
+	save := fs.String("save", "", "file to save the inventory to as snapshot, to be compared with the diff command")
+	groupBy := fs.String("group-by", "", "attribute to group by: account, region, type, id, name, age or tag:<key>")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	for _, attribute := range []string{*sortBy, *groupBy} {
+		if attribute == "" {
+			continue
+		}
+		if err := inventory.ValidateAttribute(attribute); err != nil {
+			logrus.WithError(err).Error("Invalid flag")
+			return 2
+		}
+	}
+
+	supported := make(map[aws.ResourceType]bool)
+	for _, t := range aws.SupportedResourceTypes() {
+		supported[t] = true
+	}
+
+	var resourceTypes []aws.ResourceType
+	for _, t := range fs.Args() {
+		if !supported[aws.ResourceType(t)] {
+			logrus.WithField(logging.Type, t).Error("Resource type is not supported")
+			return 2
+		}
+		resourceTypes = append(resourceTypes, aws.ResourceType(t))
+	}
+
+	cfg := &config.Config{}
+	if *configFile != "" {
+		loaded, err := config.Load(*configFile)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to open config file")
+			return 1
+		}
+		cfg.Options = loaded.Options
+		cfg.Accounts = loaded.Accounts
+	}
+
+	if err := applyOptions(&cfg.Options); err != nil {
+		logrus.WithError(err).Error("Invalid options")
+		return 2
+	}
+	if len(regions) > 0 {
+		cfg.Options.Regions = regions
+	}
+	if len(cfg.Options.Regions) == 0 {
+		logrus.Error("At least one region is required")
+		return 2
+	}
+
+	wiper := wipe.Wiper{
+		Config: cfg,
+	}
+
+	snapshot, warnings, err := takeSnapshot(&wiper, resourceTypes)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list resources")
+		return 1
+	}
+
+	if len(warnings) > 0 {
+		logrus.WithField("warnings", warnings).Warn("Unable to perform as expected because of these warnings")
+	}
+
+	if *save != "" {
+		if err := snapshot.Save(*save); err != nil {
+			logrus.WithError(err).Error("Failed to save inventory snapshot")
+			return 1
+		}
+	}
+	if cfg.Options.SnapshotDir != "" {
+		if filename, err := snapshot.SaveTo(cfg.Options.SnapshotDir, "list"); err != nil {
+			logrus.WithError(err).Error("Failed to save inventory snapshot")
+		} else {
+			logrus.WithField("file", filename).Info("Saved inventory snapshot")
+		}
+	}
+
+	items := snapshot.Items
+	inventory.Sort(items, *sortBy)
+	groups := []inventory.Group{{Items: items}}
+	if *groupBy != "" {
+		if groups, err = inventory.GroupBy(items, *groupBy, snapshot.Time); err != nil {
+			logrus.WithError(err).Error("Failed to group resources")
+			return 2
+		}
+	}
+
+	if err := inventory.Write(os.Stdout, *format, groups); err != nil {
+		logrus.WithError(err).Error("Failed to write inventory")
+		return 1
+	}
+
+	return 0
+}
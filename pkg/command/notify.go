@@ -0,0 +1,58 @@
+package command
+
+import (
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/notify"
+)
+
+// newNotifier returns the notifier of the notify section of the config, nil without it.
+func newNotifier(cfg *config.Config) (*notify.Notifier, error) {
+	n := cfg.Notify
+	if n == nil {
+		return nil, nil
+	}
+
+	notifier := &notify.Notifier{Events: n.Events, OwnerTag: n.OwnerTag}
+	for _, url := range n.Webhooks {
+		notifier.Sinks = append(notifier.Sinks, notify.NewWebhookSink(url))
+	}
+	for _, url := range n.Slack {
+		notifier.Sinks = append(notifier.Sinks, notify.NewSlackSink(url))
+	}
+
+	if n.Email != nil {
+		notifier.Sinks = append(notifier.Sinks, &notify.SMTPSink{
+			Addr:         n.Email.SMTP,
+			From:         n.Email.From,
+			To:           n.Email.To,
+			Username:     n.Email.Username,
+			Password:     n.Email.Password,
+			NotifyOwners: n.Email.Owners,
+		})
+	}
+
+	for _, topic := range n.SNS {
+		a, err := arn.Parse(topic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SNS topic %s: %v", topic, err)
+		}
+
+		// topics are published to in their region, with the credentials of the caller
+		sess, awsCfg, err := aws.NewSession(a.Region, cfg.Options.SessionOptions())
+		if err != nil {
+			return nil, err
+		}
+		notifier.Sinks = append(notifier.Sinks, &notify.SNSSink{
+			API:      sns.New(sess, awsCfg, &awssdk.Config{Region: awssdk.String(a.Region)}),
+			TopicARN: topic,
+		})
+	}
+
+	return notifier, nil
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/cmpsoares91/awsweeper/pkg/wipe"
+	"github.com/sirupsen/logrus"
+)
+
+func stopCommand(args []string) int {
+	return scheduleCommand("stop", args, (*wipe.Wiper).Stop)
+}
+
+func startCommand(args []string) int {
+	return scheduleCommand("start", args, (*wipe.Wiper).Start)
+}
+
+// scheduleAction is Wiper.Stop or Wiper.Start.
+type scheduleAction func(*wipe.Wiper, bool) (aws.IRegionResourceTypeResources, []error, error)
+
+func scheduleCommand(name string, args []string, action scheduleAction) int {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	force := fs.Bool("force", false, "ignore office hours of the schedules")
+	loadConfig := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open config file")
+		return 1
+	}
+
+	out, err := schedule(cfg, name, action, *force)
+	pushMetrics(cfg.Options)
+	if err != nil {
+		logrus.WithError(err).Errorf("Failed to %s resources", name)
+		return 1
+	}
+
+	if len(out.warnings) > 0 {
+		logrus.WithField("warnings", out.warnings).Warn("Unable to perform as expected because of these warnings")
+	}
+
+	fmt.Println(out.resources)
+	return 0
+}
+
+// schedule stops or starts the resources matched by the scheduled filters of the config, with spans and metrics.
+func schedule(cfg *config.Config, name string, action scheduleAction, force bool) (outcome, error) {
+	wiper := &wipe.Wiper{
+		Config: cfg,
+	}
+
+	endTracing := startTracing(cfg.Options, name)
+	start := time.Now()
+	resources, warnings, err := action(wiper, force)
+	metrics.ObserveRun(start, err)
+	endTracing(err)
+
+	return outcome{resources: &resources, items: inventory.Items(cfg.Options.Account, resources), warnings: warnings}, err
+}
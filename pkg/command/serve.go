@@ -0,0 +1,159 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/serve"
+	"github.com/cmpsoares91/awsweeper/pkg/wipe"
+	"github.com/sirupsen/logrus"
+)
+
+func serveCommand(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", "", "address to serve health, reports, metrics and the API on (default from the config or :8080)")
+	reload := fs.Duration("reload-interval", serve.DefaultReloadInterval, "how often to check the config file for changes")
+	loadConfig := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open config file")
+		return 1
+	}
+	if cfg.Serve == nil {
+		logrus.Error("The config requires a serve section with jobs")
+		return 1
+	}
+
+	reports, err := serve.NewReports(cfg.Serve.ReportDir, cfg.Serve.Reports)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load reports")
+		return 1
+	}
+
+	server := &serve.Server{
+		Load:           loadConfig,
+		Run:            runJob,
+		Reports:        reports,
+		ReloadInterval: *reload,
+	}
+	server.API = serve.NewAPI(server, sweepReport)
+
+	addr := cfg.Serve.Listen
+	if *listen != "" {
+		addr = *listen
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	httpServer := &http.Server{Addr: addr, Handler: server.Handler()}
+	serveErr := make(chan error, 1)
+	go func() {
+		logrus.WithField("address", addr).Info("Serving health, reports and metrics")
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			// without the server (e.g. the address is in use), no more jobs are run
+			serveErr <- err
+			cancel()
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			if sig != syscall.SIGHUP {
+				logrus.Info("Shutting down after the running job, if any")
+				cancel()
+				return
+			}
+			if err := server.Reload(time.Now()); err != nil {
+				logrus.WithError(err).Error("Failed to reload config. Keeping the previous one")
+			}
+		}
+	}()
+
+	err = server.Schedule(ctx)
+	httpServer.Shutdown(context.Background())
+	select {
+	case err := <-serveErr:
+		logrus.WithError(err).Error("Failed to serve")
+		return 1
+	default:
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Failed to schedule jobs")
+		return 1
+	}
+	return 0
+}
+
+// runJob runs the command of a job of the serve command as a run of its own.
+func runJob(cfg *config.Config, job config.Job) *serve.Report {
+	defer logging.Push(logrus.Fields{logging.RunID: logging.NewRunID(), "job": job.Name})()
+
+	// the config is shared with the scheduler, so the options are changed on a copy
+	jobCfg := *cfg
+	if job.DryRun != nil {
+		jobCfg.Options.DryRun = *job.DryRun
+	}
+
+	var report *serve.Report
+	switch job.Command {
+	case config.JobStop:
+		report = runReport(&jobCfg, job.Command, func() (outcome, error) {
+			return schedule(&jobCfg, job.Command, (*wipe.Wiper).Stop, false)
+		})
+	case config.JobStart:
+		report = runReport(&jobCfg, job.Command, func() (outcome, error) {
+			return schedule(&jobCfg, job.Command, (*wipe.Wiper).Start, false)
+		})
+	default:
+		report = sweepReport(&jobCfg, nil)
+	}
+
+	report.Job = job.Name
+	return report
+}
+
+// sweepReport runs sweep and returns its report. It sweeps for the wipe jobs and the API of the serve command.
+func sweepReport(cfg *config.Config, only map[string]bool) *serve.Report {
+	return runReport(cfg, config.JobWipe, func() (outcome, error) {
+		return sweep(cfg, only)
+	})
+}
+
+// runReport runs a command in the run of the logging context and returns its report.
+func runReport(cfg *config.Config, command string, run func() (outcome, error)) *serve.Report {
+	report := &serve.Report{
+		ID:      fmt.Sprint(logging.Get(logging.RunID)),
+		Command: command,
+		DryRun:  cfg.Options.DryRun,
+		Start:   time.Now(),
+	}
+
+	out, err := run()
+	report.End = time.Now()
+	report.Resources = out.items
+	for _, w := range out.warnings {
+		report.Warnings = append(report.Warnings, w.Error())
+	}
+
+	if err != nil {
+		report.Error = err.Error()
+		logrus.WithError(err).Errorf("Failed to %s resources", command)
+	} else {
+		logrus.WithField("resources", len(out.items)).Info("Finished run")
+	}
+
+	return report
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/wipe"
+	"github.com/sirupsen/logrus"
+)
+
+// takeSnapshot lists the resources of the given types, in every account if the config has an accounts section.
+func takeSnapshot(wiper *wipe.Wiper, resourceTypes []aws.ResourceType) (*inventory.Snapshot, []error, error) {
+	snapshot := &inventory.Snapshot{Time: time.Now()}
+
+	if wiper.Config.Accounts != nil {
+		resources, warnings, err := wiper.ListAccounts(resourceTypes...)
+		if err != nil {
+			return nil, warnings, err
+		}
+
+		for account, rrtrs := range resources {
+			snapshot.Items = append(snapshot.Items, inventory.Items(account, rrtrs)...)
+		}
+		inventory.Sort(snapshot.Items, "")
+
+		return snapshot, warnings, nil
+	}
+
+	resources, warnings, err := wiper.List(resourceTypes...)
+	if err != nil {
+		return nil, warnings, err
+	}
+	snapshot.Items = inventory.Items("", resources)
+
+	return snapshot, warnings, nil
+}
+
+// saveSnapshot writes the inventory of the given types into the snapshot directory of the config.
+func saveSnapshot(wiper *wipe.Wiper, resourceTypes []aws.ResourceType, suffix string) {
+	snapshot, warnings, err := takeSnapshot(wiper, resourceTypes)
+	if len(warnings) > 0 {
+		logrus.WithField("warnings", warnings).Warn("Inventory snapshot may be incomplete")
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Failed to take inventory snapshot")
+		return
+	}
+
+	filename, err := snapshot.SaveTo(wiper.Config.Options.SnapshotDir, suffix)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to save inventory snapshot")
+		return
+	}
+
+	logrus.WithField("file", filename).Info("Saved inventory snapshot")
+}
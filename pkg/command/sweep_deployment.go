@@ -0,0 +1,158 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/wipe"
+	"github.com/sirupsen/logrus"
+)
+
+// listFlag collects the values of a repeatable flag, each of which may be a comma separated list.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, strings.Split(s, ",")...)
+	return nil
+}
+
+func sweepDeploymentCommand(args []string) int {
+	fs := flag.NewFlagSet("sweep-deployment", flag.ContinueOnError)
+	applyOptions := optionFlags(fs)
+	tag := fs.String("tag", "", "tag identifying the deployment as key=value")
+	dryRun := fs.Bool("dry-run", true, "only show what would be deleted")
+	stackResources := fs.String("stack-resources", "", "how to handle resources belonging to a CloudFormation stack: skip, delete-stack or delete (default)")
+	var regions, exceptTypes, keep listFlag
+	fs.Var(&regions, "regions", "regions to sweep (repeatable, comma separated)")
+	fs.Var(&exceptTypes, "except", "resource types to leave out (repeatable, comma separated)")
+	fs.Var(&keep, "keep", "resources to keep as type=regex of their IDs (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	kv := strings.SplitN(*tag, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		logrus.Error("A tag is required as -tag key=value")
+		return 2
+	}
+
+	supported := make(map[aws.ResourceType]bool)
+	for _, t := range aws.SupportedResourceTypes() {
+		supported[t] = true
+	}
+
+	keepIDs := make(map[aws.ResourceType][]string)
+	for _, k := range keep {
+		typeAndID := strings.SplitN(k, "=", 2)
+		if len(typeAndID) != 2 {
+			logrus.WithField("keep", k).Error("Expected type=regex")
+			return 2
+		}
+		if !supported[aws.ResourceType(typeAndID[0])] {
+			logrus.WithField(logging.Type, typeAndID[0]).Error("Resource type to keep is not supported")
+			return 2
+		}
+		keepIDs[aws.ResourceType(typeAndID[0])] = append(keepIDs[aws.ResourceType(typeAndID[0])], typeAndID[1])
+	}
+
+	var except []aws.ResourceType
+	for _, t := range exceptTypes {
+		if !supported[aws.ResourceType(t)] {
+			logrus.WithField(logging.Type, t).Error("Resource type to leave out is not supported")
+			return 2
+		}
+		except = append(except, aws.ResourceType(t))
+	}
+
+	// options, notifications, Terraform states and accounts may be taken from a config file, its filters are ignored
+	cfg := &config.Config{}
+	if fs.NArg() > 0 {
+		loaded, err := config.Load(fs.Arg(0))
+		if err != nil {
+			logrus.WithError(err).Error("Failed to open config file")
+			return 1
+		}
+		cfg.Options = loaded.Options
+		cfg.Notify = loaded.Notify
+		cfg.Terraform = loaded.Terraform
+		cfg.Accounts = loaded.Accounts
+	}
+
+	if err := applyOptions(&cfg.Options); err != nil {
+		logrus.WithError(err).Error("Invalid options")
+		return 2
+	}
+	if len(regions) > 0 {
+		cfg.Options.Regions = regions
+	}
+	if len(cfg.Options.Regions) == 0 {
+		logrus.Error("At least one region is required")
+		return 2
+	}
+	if cfg.Options.StateFile == "" {
+		cfg.Options.StateFile = config.DefaultStateFile
+	}
+
+	cfg.Options.DryRun = *dryRun
+	switch *stackResources {
+	case "":
+	case config.StackResourcesSkip, config.StackResourcesDeleteStack, config.StackResourcesDelete:
+		cfg.Options.StackResources = *stackResources
+	default:
+		logrus.WithField("policy", *stackResources).Error("Unknown stack-resources policy, expected skip, delete-stack or delete")
+		return 2
+	}
+	cfg.Filters = config.DeploymentFilters(kv[0], kv[1], except, keepIDs)
+	for resourceType, typeFilters := range cfg.Filters {
+		if err := typeFilters.Compile(); err != nil {
+			logrus.WithError(err).WithField(logging.Type, resourceType).Error("Invalid resources to keep")
+			return 2
+		}
+	}
+
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid notifications")
+		return 1
+	}
+
+	auditLog, err := openAudit(cfg, "sweep-deployment")
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open audit log")
+		return 1
+	}
+
+	wiper := wipe.Wiper{
+		Config:   cfg,
+		Audit:    auditLog,
+		Notifier: notifier,
+	}
+
+	var resources fmt.Stringer
+	var warnings []error
+	if cfg.Accounts != nil {
+		report, runWarnings, runErr := wiper.RunAccounts()
+		resources, warnings, err = &report, runWarnings, runErr
+	} else {
+		report, runWarnings, runErr := wiper.Run()
+		resources, warnings, err = &report, runWarnings, runErr
+	}
+	err = endAudit(auditLog, err)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to sweep deployment")
+		return 1
+	}
+
+	if len(warnings) > 0 {
+		logrus.WithField("warnings", warnings).Warn("Unable to perform as expected because of these warnings")
+	}
+
+	fmt.Println(resources)
+	return 0
+}
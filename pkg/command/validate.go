@@ -0,0 +1,60 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+func validateCommand(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	vars := make(varsFlag)
+	fs.Var(vars, "var", "variable to interpolate into the config files as key=value (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{DefaultConfigFile}
+	}
+
+	exitCode := 0
+	for _, file := range files {
+		problems, err := config.ValidateWithVars(file, vars)
+		if err != nil {
+			logrus.WithError(err).WithField("file", file).Error("Failed to validate config file")
+			return 1
+		}
+
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+
+		if len(problems) > 0 {
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+func schemaCommand(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config.Schema()); err != nil {
+		logrus.WithError(err).Error("Failed to write JSON Schema")
+		return 1
+	}
+
+	return 0
+}
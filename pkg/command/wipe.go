@@ -0,0 +1,175 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/cmpsoares91/awsweeper/pkg/tracing"
+	"github.com/cmpsoares91/awsweeper/pkg/wipe"
+	"github.com/sirupsen/logrus"
+)
+
+func wipeCommand(args []string) int {
+	fs := flag.NewFlagSet("wipe", flag.ContinueOnError)
+	loadConfig := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open config file")
+		return 1
+	}
+
+	out, err := sweep(cfg, nil)
+	pushMetrics(cfg.Options)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to wipe resources")
+		return 1
+	}
+
+	if len(out.warnings) > 0 {
+		logrus.WithField("warnings", out.warnings).Warn("Unable to perform as expected because of these warnings")
+	}
+
+	fmt.Println(out.resources)
+	return 0
+}
+
+// outcome is what a run of wipe, stop or start did.
+type outcome struct {
+	resources fmt.Stringer
+	// items are the resources deleted, stopped or started
+	items    []inventory.Item
+	warnings []error
+}
+
+// sweep deletes the resources matched by the config, in every account if it has an accounts section, with the
+// configured notifications, audit log, snapshots, spans and metrics. Unless nil, only the resources with the given
+// inventory keys are deleted. It is run by the wipe command, the wipe jobs and the API of the serve command.
+func sweep(cfg *config.Config, only map[string]bool) (outcome, error) {
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		return outcome{}, fmt.Errorf("invalid notifications: %v", err)
+	}
+
+	auditLog, err := openAudit(cfg, "wipe")
+	if err != nil {
+		return outcome{}, fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	wiper := wipe.Wiper{
+		Config:   cfg,
+		Audit:    auditLog,
+		Notifier: notifier,
+		Only:     only,
+	}
+
+	snapshotTypes := filteredTypes(cfg)
+	if cfg.Options.SnapshotDir != "" && len(snapshotTypes) > 0 {
+		saveSnapshot(&wiper, snapshotTypes, "before")
+	}
+
+	endTracing := startTracing(cfg.Options, "wipe")
+	start := time.Now()
+	var out outcome
+	if cfg.Accounts != nil {
+		report, warnings, runErr := wiper.RunAccounts()
+		out, err = outcome{resources: &report, items: accountItems(report), warnings: warnings}, runErr
+	} else {
+		report, warnings, runErr := wiper.Run()
+		out, err = outcome{resources: &report, items: inventory.Items(cfg.Options.Account, report), warnings: warnings}, runErr
+	}
+
+	metrics.ObserveRun(start, err)
+	endTracing(err)
+	err = endAudit(auditLog, err)
+	if err != nil {
+		return out, err
+	}
+
+	if cfg.Options.SnapshotDir != "" && len(snapshotTypes) > 0 && !cfg.Options.DryRun {
+		saveSnapshot(&wiper, snapshotTypes, "after")
+	}
+
+	return out, nil
+}
+
+// accountItems flattens the resources listed per account into inventory items.
+func accountItems(resources aws.IAccountRegionResourceTypeResources) []inventory.Item {
+	var items []inventory.Item
+	for account, rrtrs := range resources {
+		items = append(items, inventory.Items(account, rrtrs)...)
+	}
+
+	inventory.Sort(items, "")
+	return items
+}
+
+// pushMetrics pushes the metrics of a one-shot run to the Pushgateway, if configured.
+func pushMetrics(opts config.Options) {
+	if opts.Pushgateway == "" {
+		return
+	}
+
+	job := opts.PushgatewayJob
+	if job == "" {
+		job = metrics.DefaultJob
+	}
+
+	if err := metrics.Push(opts.Pushgateway, job, nil); err != nil {
+		logrus.WithError(err).Error("Failed to push metrics")
+	}
+}
+
+// startTracing sets up the configured exporter of spans and starts the span of a command. The returned function
+// ends the span and exports all spans of the run.
+func startTracing(opts config.Options, command string) func(error) {
+	switch opts.Tracing {
+	case config.TracingOTLP:
+		tracing.SetExporter(tracing.NewOTLPExporter(opts.TracingEndpoint))
+	case config.TracingStdout:
+		tracing.SetExporter(tracing.NewWriterExporter(os.Stdout))
+	default:
+		return func(error) {}
+	}
+
+	span := tracing.Start("awsweeper "+command, tracing.Bool("dry_run", opts.DryRun))
+	return func(err error) {
+		span.SetError(err)
+		span.End()
+		if err := tracing.Flush(); err != nil {
+			logrus.WithError(err).Error("Failed to export spans")
+		}
+		tracing.SetExporter(nil)
+	}
+}
+
+// filteredTypes returns the resource types with filters in the config or any of its overrides.
+func filteredTypes(cfg *config.Config) []aws.ResourceType {
+	seen := make(map[aws.ResourceType]bool)
+	var resourceTypes []aws.ResourceType
+	add := func(fs map[aws.ResourceType]filters.Filters) {
+		for t := range fs {
+			if !seen[t] {
+				seen[t] = true
+				resourceTypes = append(resourceTypes, t)
+			}
+		}
+	}
+
+	add(cfg.Filters)
+	for _, o := range cfg.Overrides {
+		add(o.Filters)
+	}
+
+	return resourceTypes
+}
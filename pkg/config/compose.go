@@ -0,0 +1,323 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// variablePattern matches ${NAME} and ${NAME:-default}.
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replaces ${NAME} in the scalars of a config file with the value of the variable or, if not given, the
+// environment variable NAME. Variables in comments are left as they are and values are quoted as needed, so that they
+// can't change the structure of the file.
+func interpolate(data []byte, vars map[string]string) ([]byte, error) {
+	if !variablePattern.Match(data) {
+		return data, nil
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		// the error is reported with its position when parsing the file
+		return data, nil
+	}
+
+	var missing []string
+	changed := interpolateNode(&doc, vars, func(node *yamlv3.Node, name string) {
+		if !contains(missing, name) {
+			missing = append(missing, name)
+		}
+	})
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("Undefined variables: %v", missing)
+	}
+	if !changed {
+		return data, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yamlv3.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// interpolateNode replaces the variables in the scalars of a node and its children, calling undefined for every
+// variable which is not defined. It returns whether any scalar has changed.
+func interpolateNode(node *yamlv3.Node, vars map[string]string, undefined func(node *yamlv3.Node, name string)) bool {
+	changed := false
+	for _, child := range node.Content {
+		changed = interpolateNode(child, vars, undefined) || changed
+	}
+	if node.Kind != yamlv3.ScalarNode || !variablePattern.MatchString(node.Value) {
+		return changed
+	}
+
+	node.Value = variablePattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+		groups := variablePattern.FindStringSubmatch(match)
+		name := groups[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		if len(groups[2]) > 0 {
+			return groups[3]
+		}
+
+		undefined(node, name)
+		return match
+	})
+	if node.Style&(yamlv3.SingleQuotedStyle|yamlv3.DoubleQuotedStyle|yamlv3.LiteralStyle|yamlv3.FoldedStyle) == 0 {
+		// resolve the type of plain values again, e.g. a number
+		node.Tag = ""
+	}
+	return true
+}
+
+// load reads a config file and merges the files it includes (relative to its directory) into it.
+func load(filename string, vars map[string]string, visited map[string]bool) (*Config, error) {
+	if visited[filename] {
+		return nil, fmt.Errorf("Config %s is included recursively", filename)
+	}
+	visited[filename] = true
+	defer delete(visited, filename)
+
+	data, err := afero.ReadFile(AppFs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = interpolate(data, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	cfg, err := parse(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &Config{optionKeys: make(map[string]bool)}
+	for _, include := range cfg.Include {
+		included, err := load(includePath(filename, include), vars, visited)
+		if err != nil {
+			return nil, err
+		}
+
+		merged.merge(included)
+	}
+
+	merged.merge(cfg)
+	merged.Include = nil
+	return merged, nil
+}
+
+// includePath returns the path of a file included by a config file, relative to its directory.
+func includePath(filename, include string) string {
+	if filepath.IsAbs(include) {
+		return include
+	}
+
+	return filepath.Join(filepath.Dir(filename), include)
+}
+
+// parse parses the data of a config file, remembering which options it sets.
+func parse(filename string, data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	// remember which options are set, so that e.g. "dry-run: false" overrides an included "dry-run: true"
+	var keys struct {
+		Options map[string]interface{}
+	}
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	cfg.optionKeys = make(map[string]bool)
+	for key := range keys.Options {
+		cfg.optionKeys[key] = true
+	}
+
+	return &cfg, nil
+}
+
+// merge merges other into the config. Options set in other take precedence, as do its accounts, Terraform states,
+// notifications, serve jobs, definitions and filters of a resource type. Overrides are appended.
+func (c *Config) merge(other *Config) {
+	mergeOptions(&c.Options, other.Options, other.optionKeys)
+	for key := range other.optionKeys {
+		c.optionKeys[key] = true
+	}
+
+	if other.Accounts != nil {
+		c.Accounts = other.Accounts
+	}
+
+	if other.Terraform != nil {
+		c.Terraform = other.Terraform
+	}
+
+	if other.Notify != nil {
+		c.Notify = other.Notify
+	}
+
+	if other.Serve != nil {
+		c.Serve = other.Serve
+	}
+
+	for name, fs := range other.Definitions {
+		if c.Definitions == nil {
+			c.Definitions = make(map[string]filters.Filters)
+		}
+		c.Definitions[name] = fs
+	}
+
+	for resourceType, fs := range other.Filters {
+		if c.Filters == nil {
+			c.Filters = make(map[aws.ResourceType]filters.Filters)
+		}
+		c.Filters[resourceType] = fs
+	}
+
+	c.Overrides = append(c.Overrides, other.Overrides...)
+}
+
+// mergeOptions sets all options of other whose yaml keys are set.
+func mergeOptions(options *Options, other Options, keys map[string]bool) {
+	dst := reflect.ValueOf(options).Elem()
+	src := reflect.ValueOf(other)
+	for i := 0; i < src.NumField(); i++ {
+		key := strings.Split(src.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if keys[key] {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+// resolveDefinitions replaces filters using a definition with the filters of that definition.
+func (c *Config) resolveDefinitions() error {
+	for resourceType, fs := range c.Filters {
+		resolved, err := c.resolve(fs)
+		if err != nil {
+			return fmt.Errorf("Invalid filter of %s: %v", resourceType, err)
+		}
+		c.Filters[resourceType] = resolved
+	}
+
+	for _, o := range c.Overrides {
+		for resourceType, fs := range o.Filters {
+			resolved, err := c.resolve(fs)
+			if err != nil {
+				return fmt.Errorf("Invalid override filter of %s: %v", resourceType, err)
+			}
+			o.Filters[resourceType] = resolved
+		}
+	}
+
+	c.Definitions = nil
+	return nil
+}
+
+func (c *Config) resolve(fs filters.Filters) (resolved filters.Filters, err error) {
+	if fs == nil {
+		return nil, nil
+	}
+
+	resolved = filters.Filters{}
+	for _, f := range fs {
+		if f.Use == "" {
+			resolved = append(resolved, f)
+			continue
+		}
+
+		definition, ok := c.Definitions[f.Use]
+		if !ok {
+			return nil, fmt.Errorf("Filter definition %q does not exist", f.Use)
+		}
+
+		// an empty definition would select all resources
+		if len(definition) == 0 {
+			return nil, fmt.Errorf("Filter definition %q is empty", f.Use)
+		}
+
+		if !reflect.DeepEqual(f, filters.Filter{Use: f.Use}) {
+			return nil, fmt.Errorf("Filter using definition %q must not have other fields", f.Use)
+		}
+
+		resolved = append(resolved, definition...)
+	}
+
+	return resolved, nil
+}
+
+// HasAccountOverrides returns whether any override is scoped to accounts, so that the filters depend on the account.
+func (c *Config) HasAccountOverrides() bool {
+	for _, o := range c.Overrides {
+		if len(o.Accounts) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// FiltersFor returns the filters to apply in the given account and region, i.e. the filters
+// with all matching overrides applied in order.
+func (c *Config) FiltersFor(account aws.Account, region aws.Region) map[aws.ResourceType]filters.Filters {
+	if len(c.Overrides) == 0 {
+		return c.Filters
+	}
+
+	result := make(map[aws.ResourceType]filters.Filters)
+	for resourceType, fs := range c.Filters {
+		result[resourceType] = fs
+	}
+
+	for _, o := range c.Overrides {
+		if !matches(o.Regions, region) || !matches(o.Accounts, account) {
+			continue
+		}
+
+		for resourceType, fs := range o.Filters {
+			result[resourceType] = fs
+		}
+	}
+
+	return result
+}
+
+func matches(values []string, value string) bool {
+	return len(values) == 0 || contains(values, value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func writeFiles(t *testing.T, files map[string]string) {
+	AppFs = afero.NewMemMapFs()
+	for name, content := range files {
+		if err := afero.WriteFile(AppFs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadWithVars(t *testing.T) {
+	defer func() { AppFs = afero.NewOsFs() }()
+	os.Setenv("AWSWEEPER_TEST_OWNER", "team-a")
+	defer os.Unsetenv("AWSWEEPER_TEST_OWNER")
+
+	writeFiles(t, map[string]string{
+		"conf/shared.yaml": `options:
+  dry-run: true
+  max-retries: 3
+  regions: [eu-west-1]
+definitions:
+  deployment:
+    - tags:
+        - DeploymentIdentifier: "${DEPLOYMENT}"
+          Owner: "${AWSWEEPER_TEST_OWNER}"
+`,
+		"conf/main.yaml": `include: [shared.yaml]
+options:
+  dry-run: false
+  regions: [eu-west-1, us-east-1]
+filters:
+  ec2:
+    - use: deployment
+  s3_bucket:
+    - use: deployment
+overrides:
+  - regions: [us-east-1]
+    filters:
+      s3_bucket:
+        - ids: ["^${DEPLOYMENT}-${SUFFIX:-logs}$"]
+`,
+	})
+
+	cfg, err := LoadWithVars("conf/main.yaml", map[string]string{"DEPLOYMENT": "vd-2965"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Options.DryRun || cfg.Options.MaxRetries != 3 || len(cfg.Options.Regions) != 2 {
+		t.Errorf("options have not been merged as expected: %+v", cfg.Options)
+	}
+
+	deployment := filters.Filters{{Tags: &filters.Tags{{"DeploymentIdentifier": "vd-2965", "Owner": "team-a"}}}}
+	if !reflect.DeepEqual(cfg.Filters["ec2"], deployment) {
+		t.Errorf("unexpected ec2 filters: %v", cfg.Filters["ec2"])
+	}
+
+	if f := cfg.FiltersFor("", "eu-west-1"); !reflect.DeepEqual(f[aws.ResourceType("s3_bucket")], deployment) {
+		t.Errorf("unexpected s3_bucket filters in eu-west-1: %v", f["s3_bucket"])
+	}
+
+	logs := filters.Filters{{IDs: &[]string{"^vd-2965-logs$"}}}
+	if f := cfg.FiltersFor("", "us-east-1"); !reflect.DeepEqual(f[aws.ResourceType("s3_bucket")], logs) {
+		t.Errorf("unexpected s3_bucket filters in us-east-1: %v", f["s3_bucket"])
+	}
+}
+
+func TestLoadWithVars_Values(t *testing.T) {
+	defer func() { AppFs = afero.NewOsFs() }()
+
+	writeFiles(t, map[string]string{"c.yaml": `# set ${PROFILE} to sweep with another profile
+options:
+  regions: [eu-west-1]
+  max-retries: ${RETRIES}
+  profile: ${PROFILE} # e.g. ${UNDEFINED_AWSWEEPER_VARIABLE}
+  session-name: "${SESSION}"
+filters:
+  ec2:
+    - tags:
+        - Owner: ${OWNER}
+`})
+
+	vars := map[string]string{
+		"RETRIES": "5",
+		"PROFILE": "sandbox: admin",
+		"SESSION": `"quoted"`,
+		"OWNER":   "team-a\nregions: us-east-1",
+	}
+	cfg, err := LoadWithVars("c.yaml", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Options.MaxRetries != 5 || cfg.Options.Profile != vars["PROFILE"] || cfg.Options.SessionName != vars["SESSION"] {
+		t.Errorf("expected the values of the variables, got %+v", cfg.Options)
+	}
+	if !reflect.DeepEqual(cfg.Options.Regions, []string{"eu-west-1"}) {
+		t.Errorf("expected a variable not to change the structure of the config, got regions %v", cfg.Options.Regions)
+	}
+
+	owner := filters.Filters{{Tags: &filters.Tags{{"Owner": vars["OWNER"]}}}}
+	if !reflect.DeepEqual(cfg.Filters["ec2"], owner) {
+		t.Errorf("unexpected ec2 filters: %v", cfg.Filters["ec2"])
+	}
+}
+
+func TestLoad_AllResources(t *testing.T) {
+	defer func() { AppFs = afero.NewOsFs() }()
+
+	writeFiles(t, map[string]string{"c.yaml": "options:\n  regions: [eu-west-1]\nfilters:\n  s3_bucket:\n"})
+	cfg, err := Load("c.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a resource type without value selects all resources, also once the config is rendered
+	bucket := aws.IResources{&aws.S3Bucket{ID: awssdk.String("logs")}}
+	for i := 0; i < 2; i++ {
+		if matched, err := cfg.Filters["s3_bucket"].Apply(bucket); err != nil || len(matched) != 1 {
+			t.Errorf("expected all buckets to be selected, got %v: %v", matched, err)
+		}
+
+		rendered, err := yaml.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, map[string]string{"c.yaml": string(rendered)})
+		if cfg, err = Load("c.yaml"); err != nil {
+			t.Fatalf("rendered config does not load: %v\n%s", err, rendered)
+		}
+	}
+
+	if matched, _ := (filters.Filters{}).Apply(bucket); len(matched) != 0 {
+		t.Errorf("expected an empty filter list to select nothing, got %v", matched)
+	}
+}
+
+func TestLoadWithVarsErrors(t *testing.T) {
+	defer func() { AppFs = afero.NewOsFs() }()
+
+	tests := map[string]string{
+		"undefined variable": `options:
+  regions: ["${UNDEFINED_AWSWEEPER_VARIABLE}"]
+`,
+		"unknown definition": `options:
+  regions: [eu-west-1]
+filters:
+  ec2:
+    - use: unknown
+`,
+		"empty definition": `options:
+  regions: [eu-west-1]
+definitions:
+  everything:
+filters:
+  ec2:
+    - use: everything
+`,
+		"empty filter list": `options:
+  regions: [eu-west-1]
+filters:
+  s3_bucket: []
+`,
+		"empty override filter list": `options:
+  regions: [eu-west-1]
+overrides:
+  - regions: [eu-west-1]
+    filters:
+      s3_bucket: []
+`,
+		"recursive include": `include: [c.yaml]
+options:
+  regions: [eu-west-1]
+`,
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"c.yaml": config})
+			if _, err := LoadWithVars("c.yaml", nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSubmitted(t *testing.T) {
+	served := &Config{Options: Options{
+		Regions:        []string{"eu-west-1", "us-east-1"},
+		Profile:        "sweeper",
+		Endpoints:      map[string]string{"default": "http://localhost:4566"},
+		StackResources: StackResourcesSkip,
+	}, Filters: map[aws.ResourceType]filters.Filters{"s3_bucket": {{}}}}
+
+	cfg, err := Submitted(served, []byte(`options:
+  regions: [eu-west-1]
+  stack-resources: delete
+filters:
+  ec2:
+    - tags:
+        - Owner: team-a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Filters) != 1 || len(cfg.Filters["ec2"]) != 1 || len(cfg.Options.Regions) != 1 ||
+		cfg.Options.StackResources != StackResourcesDelete || cfg.Options.Profile != "sweeper" ||
+		cfg.Options.Endpoints["default"] != "http://localhost:4566" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if len(served.Options.Regions) != 2 || len(served.Filters) != 1 {
+		t.Errorf("expected the served config to be unchanged, got %+v", served)
+	}
+
+	for _, data := range []string{
+		"include: [/etc/awsweeper/config.yaml]\n",
+		"options:\n  role-to-assume: ${ROLE}\n",
+		"options:\n  endpoints:\n    default: https://attacker.example.com\n",
+		"options:\n  web-identity-token-file: /var/run/secrets/token\n",
+		"terraform:\n  states: [/etc/passwd]\n",
+		"notify:\n  webhooks: [https://attacker.example.com]\n",
+	} {
+		if _, err := Submitted(served, []byte(data)); err == nil {
+			t.Errorf("expected an error for %q", data)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/cron"
 	"github.com/cmpsoares91/awsweeper/pkg/filters"
 	"github.com/spf13/afero"
 	yaml "gopkg.in/yaml.v2"
@@ -14,36 +19,453 @@ var AppFs = afero.NewOsFs()
 
 // Config represents the content of a yaml file that is used as a contract to filter resources for deletion.
 type Config struct {
-	Options Options                              `yaml:",omitempty"`
-	Filters map[aws.ResourceType]filters.Filters `yaml:",omitempty"`
+	Include     []string                             `yaml:",omitempty"`
+	Options     Options                              `yaml:",omitempty"`
+	Accounts    *Accounts                            `yaml:",omitempty"`
+	Terraform   *Terraform                           `yaml:",omitempty"`
+	Notify      *Notify                              `yaml:",omitempty"`
+	Serve       *Serve                               `yaml:",omitempty"`
+	Definitions map[string]filters.Filters           `yaml:",omitempty"`
+	Filters     map[aws.ResourceType]filters.Filters `yaml:",omitempty"`
+	Overrides   []Override                           `yaml:",omitempty"`
+
+	// optionKeys are the keys of the options set in the file, used when merging included files.
+	optionKeys map[string]bool
+}
+
+// Override replaces the filters of resource types in particular regions and/or accounts.
+// Without regions (or accounts) the override applies to all of them.
+type Override struct {
+	Regions  []string                             `yaml:",omitempty"`
+	Accounts []string                             `yaml:",omitempty"`
+	Filters  map[aws.ResourceType]filters.Filters `yaml:",omitempty"`
+}
+
+// DefaultRoleTemplate is the role assumed in each account unless configured otherwise.
+const DefaultRoleTemplate = "arn:{{ .Partition }}:iam::{{ .ID }}:role/OrganizationAccountAccessRole"
+
+// Accounts selects the accounts to sweep, either explicitly by ID or by the organizational units they belong to.
+// The role to assume in each account is rendered from RoleTemplate (a text/template with the fields ID, Name and Partition).
+type Accounts struct {
+	IDs                 []string `yaml:"ids,omitempty"`
+	OrganizationalUnits []string `yaml:"organizational-units,omitempty"`
+	Exclude             []string `yaml:"exclude,omitempty"`
+	RoleTemplate        string   `yaml:"role-template,omitempty"`
+}
+
+// Terraform modes of handling resources managed by Terraform.
+const (
+	// TerraformProtect keeps resources in the Terraform states from being deleted.
+	TerraformProtect = "protect"
+	// TerraformUnmanaged restricts the sweep to resources of the types managed by Terraform which are not in its states.
+	TerraformUnmanaged = "unmanaged"
+)
+
+// Terraform refers to Terraform state files, either local paths or S3 objects (s3://bucket/key), whose resources are
+// handled according to the mode.
+type Terraform struct {
+	States []string `yaml:"states"`
+	Mode   string   `yaml:"mode,omitempty"`
+}
+
+// Events of a run notifications are sent for.
+const (
+	// NotifyWarning lists the resources selected for deletion before deleting them.
+	NotifyWarning = "warning"
+	// NotifySummary reports the result of a run.
+	NotifySummary = "summary"
+)
+
+// Notify configures where warnings listing the resources selected for deletion and summaries of each run are sent.
+type Notify struct {
+	// Events are the events notified, warning and/or summary. All by default.
+	Events []string `yaml:"events,omitempty"`
+	// OwnerTag is the tag resources are grouped by, owner by default.
+	OwnerTag string `yaml:"owner-tag,omitempty"`
+	// GracePeriod is waited after sending the warning before deleting, so that owners can react to it.
+	GracePeriod time.Duration `yaml:"grace-period,omitempty"`
+	Webhooks    []string      `yaml:"webhooks,omitempty"`
+	Slack       []string      `yaml:"slack,omitempty"`
+	SNS         []string      `yaml:"sns,omitempty"`
+	Email       *Email        `yaml:"email,omitempty"`
+}
+
+// Email configures the SMTP server notifications are sent with and their recipients.
+type Email struct {
+	SMTP     string   `yaml:"smtp"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	// Owners sends warnings to the owners which are email addresses as well, listing only their resources.
+	Owners bool `yaml:"owners,omitempty"`
+}
+
+// Commands jobs of the serve command can run.
+const (
+	JobWipe  = "wipe"
+	JobStop  = "stop"
+	JobStart = "start"
+)
+
+// Defaults of the serve command.
+const (
+	DefaultListen  = ":8080"
+	DefaultReports = 20
+	// MinTokenLength is the minimum length of tokens of the API, so that they can't be guessed.
+	MinTokenLength = 16
+)
+
+// Serve configures the jobs run by the serve command and how their reports are kept and served.
+type Serve struct {
+	// Listen is the address health, reports and metrics are served on, DefaultListen if empty.
+	Listen string `yaml:"listen,omitempty"`
+	// Reports is the number of reports kept, DefaultReports if 0.
+	Reports int `yaml:"reports,omitempty"`
+	// ReportDir is the directory reports are saved to, so that they survive restarts. Reports are only kept in
+	// memory if empty.
+	ReportDir string `yaml:"report-dir,omitempty"`
+	Jobs      []Job  `yaml:"jobs"`
+	// API enables the HTTP API to plan and apply sweeps of submitted configs, if set.
+	API *API `yaml:"api,omitempty"`
+}
+
+// API configures the HTTP API of the serve command.
+type API struct {
+	// Tokens authenticate requests, one of them is required as bearer token.
+	Tokens []string `yaml:"tokens"`
+}
+
+// Job runs a command on a cron schedule.
+type Job struct {
+	Name string `yaml:"name"`
+	// Schedule is a cron expression, e.g. "0 2 * * *" or "@every 1h".
+	Schedule string `yaml:"schedule"`
+	// Timezone the schedule is in, UTC by default.
+	Timezone string `yaml:"timezone,omitempty"`
+	// Command is wipe (default), stop or start.
+	Command string `yaml:"command,omitempty"`
+	// DryRun overrides the dry-run option of the config, if set.
+	DryRun *bool `yaml:"dry-run,omitempty"`
 }
 
+// resolve checks the jobs and sets the defaults.
+func (s *Serve) resolve() error {
+	if s.Listen == "" {
+		s.Listen = DefaultListen
+	}
+	if s.Reports == 0 {
+		s.Reports = DefaultReports
+	}
+
+	names := make(map[string]bool)
+	for i := range s.Jobs {
+		job := &s.Jobs[i]
+		if job.Name == "" || names[job.Name] {
+			return fmt.Errorf("Jobs require a unique name, got %q", job.Name)
+		}
+		names[job.Name] = true
+
+		if _, err := cron.Parse(job.Schedule); err != nil {
+			return fmt.Errorf("Invalid schedule of job %s: %v", job.Name, err)
+		}
+		if _, err := time.LoadLocation(job.Timezone); err != nil {
+			return fmt.Errorf("Invalid timezone of job %s: %v", job.Name, err)
+		}
+
+		switch job.Command {
+		case "":
+			job.Command = JobWipe
+		case JobWipe, JobStop, JobStart:
+		default:
+			return fmt.Errorf("Unknown command %q of job %s, expected %s, %s or %s", job.Command, job.Name, JobWipe, JobStop, JobStart)
+		}
+	}
+
+	if s.API != nil {
+		if len(s.API.Tokens) == 0 {
+			return fmt.Errorf("At least one token is required for the API")
+		}
+		for _, token := range s.API.Tokens {
+			if len(token) < MinTokenLength {
+				return fmt.Errorf("Tokens of the API must have at least %d characters", MinTokenLength)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Policies of handling resources which belong to a CloudFormation stack.
+const (
+	// StackResourcesSkip keeps resources belonging to a stack.
+	StackResourcesSkip = "skip"
+	// StackResourcesDeleteStack deletes the owning stack instead of the resources belonging to it.
+	StackResourcesDeleteStack = "delete-stack"
+	// StackResourcesDelete deletes resources belonging to a stack individually, the default.
+	StackResourcesDelete = "delete"
+)
+
+// Exporters of the spans of sweeps.
+const (
+	// TracingOTLP posts spans to an OpenTelemetry collector over OTLP/HTTP.
+	TracingOTLP = "otlp"
+	// TracingStdout writes spans to stdout.
+	TracingStdout = "stdout"
+)
+
+// DefaultStateFile is where the state of stopped resources is persisted unless configured otherwise.
+const DefaultStateFile = ".awsweeper-state.json"
+
 type Options struct {
-	DryRun           bool              `yaml:"dry-run,omitempty"`
-	MaxRetries       int               `yaml:"max-retries,omitempty"`
-	S3ForcePathStyle bool              `yaml:"s3-force-path-style,omitempty"`
-	Regions          []string          `yaml:"regions"`
-	RoleToAssume     string            `yaml:"role-to-assume,omitempty"`
-	Extra            map[string]string `yaml:"extra,omitempty"`
+	DryRun               bool              `yaml:"dry-run,omitempty"`
+	MaxRetries           int               `yaml:"max-retries,omitempty"`
+	S3ForcePathStyle     bool              `yaml:"s3-force-path-style,omitempty"`
+	Regions              []string          `yaml:"regions"`
+	ExcludeRegions       []string          `yaml:"exclude-regions,omitempty"`
+	Partition            string            `yaml:"partition,omitempty"`
+	Profile              string            `yaml:"profile,omitempty"`
+	RoleToAssume         string            `yaml:"role-to-assume,omitempty"`
+	ExternalID           string            `yaml:"external-id,omitempty"`
+	SessionName          string            `yaml:"session-name,omitempty"`
+	AccountExternalID    string            `yaml:"account-external-id,omitempty"`
+	AccountSessionName   string            `yaml:"account-session-name,omitempty"`
+	Duration             time.Duration     `yaml:"duration,omitempty"`
+	MFASerial            string            `yaml:"mfa-serial,omitempty"`
+	WebIdentityTokenFile string            `yaml:"web-identity-token-file,omitempty"`
+	Endpoints            map[string]string `yaml:"endpoints,omitempty"`
+	DisableSSL           bool              `yaml:"disable-ssl,omitempty"`
+	InsecureSkipVerify   bool              `yaml:"insecure-skip-verify,omitempty"`
+	BulkTags             bool              `yaml:"bulk-tags,omitempty"`
+	PageSize             int64             `yaml:"page-size,omitempty"`
+	StateFile            string            `yaml:"state-file,omitempty"`
+	SnapshotDir          string            `yaml:"snapshot-dir,omitempty"`
+	StackResources       string            `yaml:"stack-resources,omitempty"`
+	Pushgateway          string            `yaml:"pushgateway,omitempty"`
+	PushgatewayJob       string            `yaml:"pushgateway-job,omitempty"`
+	Tracing              string            `yaml:"tracing,omitempty"`
+	TracingEndpoint      string            `yaml:"tracing-endpoint,omitempty"`
+	LogLevel             string            `yaml:"log-level,omitempty"`
+	LogFormat            string            `yaml:"log-format,omitempty"`
+	AuditLog             string            `yaml:"audit-log,omitempty"`
+	AuditS3              string            `yaml:"audit-s3,omitempty"`
+	Extra                map[string]string `yaml:"extra,omitempty"`
+
+	// Account and AccountRole are set for each account when sweeping the accounts of an organization.
+	Account     string `yaml:"-"`
+	AccountRole string `yaml:"-"`
+
+	// Record and Replay are cassette files of AWS traffic, set on the command line only.
+	Record string `yaml:"-"`
+	Replay string `yaml:"-"`
+}
+
+// PartitionID returns the configured partition or the partition of the first region.
+func (o Options) PartitionID() string {
+	if o.Partition != "" {
+		return o.Partition
+	}
+
+	if len(o.Regions) > 0 && o.Regions[0] != aws.AllRegions {
+		return aws.PartitionOf(o.Regions[0])
+	}
+
+	return aws.PartitionOf("")
+}
+
+// SessionOptions returns the options used to create an AWS session.
+func (o Options) SessionOptions() aws.SessionOptions {
+	return aws.SessionOptions{
+		MaxRetries:           o.MaxRetries,
+		Profile:              o.Profile,
+		RoleToAssume:         o.RoleToAssume,
+		ExternalID:           o.ExternalID,
+		SessionName:          o.SessionName,
+		Duration:             o.Duration,
+		MFASerial:            o.MFASerial,
+		WebIdentityTokenFile: o.WebIdentityTokenFile,
+		AccountRole:          o.AccountRole,
+		AccountExternalID:    o.AccountExternalID,
+		AccountSessionName:   o.AccountSessionName,
+		Endpoints:            o.Endpoints,
+		S3ForcePathStyle:     o.S3ForcePathStyle,
+		DisableSSL:           o.DisableSSL,
+		InsecureSkipVerify:   o.InsecureSkipVerify,
+		BulkTags:             o.BulkTags,
+		PageSize:             o.PageSize,
+		Record:               o.Record,
+		Replay:               o.Replay,
+	}
 }
 
 // Load will read yaml config file and returns its value as config type
 func Load(filename string) (*Config, error) {
-	var cfg Config
+	return LoadWithVars(filename, nil)
+}
 
-	data, err := afero.ReadFile(AppFs, filename)
+// LoadWithVars reads a yaml config file like Load, interpolating ${NAME} with the given variables or environment variables.
+// Included files are merged and references to filter definitions are resolved.
+func LoadWithVars(filename string, vars map[string]string) (*Config, error) {
+	cfg, err := load(filename, vars, make(map[string]bool))
 	if err != nil {
 		return nil, err
 	}
 
-	err = yaml.UnmarshalStrict(data, &cfg)
+	if err := cfg.complete(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Submitted returns the config of a plan submitted to the API of the serve command: the served config with the
+// filters, definitions and overrides of the submitted config and, if set, its regions and stack-resources policy.
+// Credentials, endpoints, files and everything else are taken from the served config, so that submitted configs can't
+// make the server send its credentials elsewhere or read and write its files. Submitted configs may set nothing else
+// and may not reference variables, which would disclose the environment of the server.
+func Submitted(served *Config, data []byte) (*Config, error) {
+	if variablePattern.Match(data) {
+		return nil, fmt.Errorf("Variables are not supported in submitted configs")
+	}
+
+	var keys struct {
+		Top     map[string]interface{} `yaml:",inline"`
+		Options map[string]interface{} `yaml:"options"`
+	}
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("config: %v", err)
+	}
+	for key := range keys.Top {
+		if !contains(submittedKeys, key) {
+			return nil, fmt.Errorf("%s can't be set in submitted configs, only %s", key, strings.Join(submittedKeys, ", "))
+		}
+	}
+	for key := range keys.Options {
+		if !contains(submittedOptions, key) {
+			return nil, fmt.Errorf("Option %s can't be set in submitted configs, only %s", key, strings.Join(submittedOptions, ", "))
+		}
+	}
+
+	submitted, err := parse("config", data)
 	if err != nil {
 		return nil, err
 	}
 
-	if cfg.Options.Regions == nil {
-		return nil, fmt.Errorf("At least one region is required in options")
+	cfg := *served
+	cfg.Serve = nil
+	cfg.Filters = submitted.Filters
+	cfg.Definitions = submitted.Definitions
+	cfg.Overrides = submitted.Overrides
+	if submitted.optionKeys["regions"] {
+		if len(submitted.Options.Regions) == 0 {
+			return nil, fmt.Errorf("At least one region is required in options")
+		}
+		cfg.Options.Regions = submitted.Options.Regions
+	}
+	if submitted.optionKeys["stack-resources"] {
+		cfg.Options.StackResources = submitted.Options.StackResources
 	}
 
+	if err := cfg.complete(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
+
+// submittedKeys and submittedOptions are what submitted configs may set.
+var (
+	submittedKeys    = []string{"options", "filters", "definitions", "overrides"}
+	submittedOptions = []string{"regions", "stack-resources"}
+)
+
+// complete compiles the filters of a loaded config, checks it and sets the defaults.
+func (c *Config) complete() error {
+	for resourceType, fs := range c.Filters {
+		if fs != nil && len(fs) == 0 {
+			return fmt.Errorf("Empty filter list of %s, remove the resource type to keep its resources or leave it without value to delete them all", resourceType)
+		}
+	}
+
+	for _, o := range c.Overrides {
+		for resourceType, fs := range o.Filters {
+			if fs != nil && len(fs) == 0 {
+				return fmt.Errorf("Empty override filter list of %s, remove the resource type to keep its resources or leave it without value to delete them all", resourceType)
+			}
+		}
+	}
+
+	if err := c.resolveDefinitions(); err != nil {
+		return err
+	}
+
+	for resourceType, fs := range c.Filters {
+		if err := fs.Compile(); err != nil {
+			return fmt.Errorf("Invalid filter of %s: %v", resourceType, err)
+		}
+	}
+
+	for _, o := range c.Overrides {
+		for resourceType, fs := range o.Filters {
+			if err := fs.Compile(); err != nil {
+				return fmt.Errorf("Invalid override filter of %s: %v", resourceType, err)
+			}
+		}
+	}
+
+	if c.Options.Regions == nil {
+		return fmt.Errorf("At least one region is required in options")
+	}
+
+	if c.Options.WebIdentityTokenFile != "" && c.Options.RoleToAssume == "" {
+		return fmt.Errorf("A role to assume is required when using a web identity token file")
+	}
+
+	if c.Terraform != nil {
+		if len(c.Terraform.States) == 0 {
+			return fmt.Errorf("At least one Terraform state is required")
+		}
+
+		switch c.Terraform.Mode {
+		case "":
+			c.Terraform.Mode = TerraformProtect
+		case TerraformProtect, TerraformUnmanaged:
+		default:
+			return fmt.Errorf("Unknown Terraform mode %q, expected %s or %s", c.Terraform.Mode, TerraformProtect, TerraformUnmanaged)
+		}
+	}
+
+	if c.Serve != nil {
+		if err := c.Serve.resolve(); err != nil {
+			return err
+		}
+	}
+
+	if c.Accounts != nil && c.Accounts.RoleTemplate == "" {
+		c.Accounts.RoleTemplate = DefaultRoleTemplate
+	}
+
+	if c.Options.StateFile == "" {
+		c.Options.StateFile = DefaultStateFile
+	}
+
+	switch c.Options.StackResources {
+	case "":
+		c.Options.StackResources = StackResourcesDelete
+	case StackResourcesSkip, StackResourcesDeleteStack, StackResourcesDelete:
+	default:
+		return fmt.Errorf("Unknown stack-resources policy %q, expected %s, %s or %s", c.Options.StackResources,
+			StackResourcesSkip, StackResourcesDeleteStack, StackResourcesDelete)
+	}
+
+	return nil
+}
+
+// Hash returns the SHA-256 hash of the resolved config, identifying the config a run was made with.
+func (c *Config) Hash() (string, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"regexp"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+)
+
+// DeploymentFilters returns filters selecting the resources of every supported resource type which are tagged
+// with the given key and (exactly) the given value. Resource types in except are left out, keep maps resource types
+// to regular expressions of IDs of resources to keep.
+func DeploymentFilters(tagKey, tagValue string, except []aws.ResourceType, keep map[aws.ResourceType][]string) map[aws.ResourceType]filters.Filters {
+	excluded := make(map[aws.ResourceType]bool)
+	for _, t := range except {
+		excluded[t] = true
+	}
+
+	result := make(map[aws.ResourceType]filters.Filters)
+	for _, t := range aws.SupportedResourceTypes() {
+		if excluded[t] {
+			continue
+		}
+
+		f := filters.Filter{
+			Tags: &filters.Tags{{tagKey: "^" + regexp.QuoteMeta(tagValue) + "$"}},
+		}
+
+		if ids, ok := keep[t]; ok && len(ids) > 0 {
+			idsToKeep := ids
+			f.Not = &filters.Filters{{IDs: &idsToKeep}}
+		}
+
+		result[t] = filters.Filters{f}
+	}
+
+	return result
+}
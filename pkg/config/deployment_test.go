@@ -0,0 +1,33 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+)
+
+func TestDeploymentFilters(t *testing.T) {
+	result := DeploymentFilters("DeploymentIdentifier", "vd-2965.1",
+		[]aws.ResourceType{"rds_cluster"},
+		map[aws.ResourceType][]string{"s3_bucket": {"-logs$"}})
+
+	if len(result) != len(aws.SupportedResourceTypes())-1 {
+		t.Errorf("expected filters for all but one resource type, got %d", len(result))
+	}
+
+	if _, ok := result["rds_cluster"]; ok {
+		t.Error("expected no filters for rds_cluster")
+	}
+
+	tags := &filters.Tags{{"DeploymentIdentifier": `^vd-2965\.1$`}}
+	if expected := (filters.Filters{{Tags: tags}}); !reflect.DeepEqual(result["ec2"], expected) {
+		t.Errorf("unexpected ec2 filters: %v", result["ec2"])
+	}
+
+	keep := filters.Filters{{Tags: tags, Not: &filters.Filters{{IDs: &[]string{"-logs$"}}}}}
+	if !reflect.DeepEqual(result["s3_bucket"], keep) {
+		t.Errorf("unexpected s3_bucket filters: %v", result["s3_bucket"])
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const generatedHeader = `Generated by awsweeper generate on %s.
+Every filter below matches existing resources of its region, which are deleted by running awsweeper with this config.
+Delete the filters of all resources you want to keep, then set dry-run to false. To keep all resources of a type in a
+region, delete the resource type from the override of the region.`
+
+// Generate returns a starter config with one filter per resource in the inventory, pinned to its ID and commented
+// with its name, creation date and tags. The filters of each region are in an override of that region, so that
+// resources of the same name in other regions aren't matched by them. With groupByTag, resources are grouped into one
+// filter per value of that tag instead; resources without the tag keep their ID-pinned filter.
+func Generate(opts Options, inventory aws.IRegionResourceTypeResources, groupByTag string) ([]byte, error) {
+	root := mapping()
+	root.HeadComment = fmt.Sprintf(generatedHeader, time.Now().UTC().Format(time.RFC3339))
+
+	options := mapping()
+	appendPair(options, "dry-run", scalar("true", "!!bool"))
+	regions := &yamlv3.Node{Kind: yamlv3.SequenceNode}
+	for _, r := range opts.Regions {
+		regions.Content = append(regions.Content, scalar(r, "!!str"))
+	}
+	appendPair(options, "regions", regions)
+	if opts.Partition != "" {
+		appendPair(options, "partition", scalar(opts.Partition, "!!str"))
+	}
+	appendPair(root, "options", options)
+
+	var inventoryRegions []string
+	for region := range inventory {
+		inventoryRegions = append(inventoryRegions, region)
+	}
+	sort.Strings(inventoryRegions)
+
+	overrides := &yamlv3.Node{Kind: yamlv3.SequenceNode}
+	for _, region := range inventoryRegions {
+		fs := regionFilters(region, inventory[region], groupByTag)
+		if len(fs.Content) == 0 {
+			continue
+		}
+
+		override := mapping()
+		overrideRegions := &yamlv3.Node{Kind: yamlv3.SequenceNode, Style: yamlv3.FlowStyle}
+		overrideRegions.Content = append(overrideRegions.Content, scalar(region, "!!str"))
+		appendPair(override, "regions", overrideRegions)
+		appendPair(override, "filters", fs)
+		overrides.Content = append(overrides.Content, override)
+	}
+	appendPair(root, "overrides", overrides)
+
+	var out bytes.Buffer
+	encoder := yamlv3.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), encoder.Close()
+}
+
+// regionFilters returns the filters of the resources of a region by resource type.
+func regionFilters(region aws.Region, rtrs aws.IResourceTypeResources, groupByTag string) *yamlv3.Node {
+	var resourceTypes []string
+	for t, resources := range rtrs {
+		if len(resources) > 0 {
+			resourceTypes = append(resourceTypes, string(t))
+		}
+	}
+	sort.Strings(resourceTypes)
+
+	fs := mapping()
+	for _, t := range resourceTypes {
+		var resources []generatedResource
+		for _, r := range rtrs[aws.ResourceType(t)] {
+			resources = append(resources, generatedResource{region: region, resource: r})
+		}
+		sort.Slice(resources, func(i, j int) bool {
+			return resources[i].resource.GetID() < resources[j].resource.GetID()
+		})
+
+		filterList := &yamlv3.Node{Kind: yamlv3.SequenceNode}
+		var ungrouped []generatedResource
+		if groupByTag != "" {
+			ungrouped = appendTagFilters(filterList, resources, groupByTag)
+		} else {
+			ungrouped = resources
+		}
+
+		for _, r := range ungrouped {
+			filter := mapping()
+			ids := &yamlv3.Node{Kind: yamlv3.SequenceNode, Style: yamlv3.FlowStyle}
+			ids.Content = append(ids.Content, scalar(exactMatch(r.resource.GetID()), "!!str"))
+			appendPair(filter, "ids", ids)
+			filter.HeadComment = r.comment()
+			filterList.Content = append(filterList.Content, filter)
+		}
+
+		appendPair(fs, t, filterList)
+	}
+
+	return fs
+}
+
+// appendTagFilters appends one filter per value of the tag to the list and returns the resources without the tag.
+func appendTagFilters(list *yamlv3.Node, resources []generatedResource, tag string) []generatedResource {
+	var untagged []generatedResource
+	byValue := make(map[string][]generatedResource)
+	for _, r := range resources {
+		tags := r.resource.GetTags()
+		if tags == nil {
+			untagged = append(untagged, r)
+			continue
+		}
+		value, ok := (*tags)[tag]
+		if !ok {
+			untagged = append(untagged, r)
+			continue
+		}
+		byValue[value] = append(byValue[value], r)
+	}
+
+	var values []string
+	for v := range byValue {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	for _, v := range values {
+		var lines []string
+		for _, r := range byValue[v] {
+			lines = append(lines, r.comment())
+		}
+
+		tagFilter := mapping()
+		appendPair(tagFilter, tag, scalar(exactMatch(v), "!!str"))
+		tags := &yamlv3.Node{Kind: yamlv3.SequenceNode}
+		tags.Content = append(tags.Content, tagFilter)
+
+		filter := mapping()
+		appendPair(filter, "tags", tags)
+		filter.HeadComment = fmt.Sprintf("%s=%s (%d resources):\n%s", tag, v, len(byValue[v]), strings.Join(lines, "\n"))
+		list.Content = append(list.Content, filter)
+	}
+
+	return untagged
+}
+
+type generatedResource struct {
+	region   aws.Region
+	resource aws.IResource
+}
+
+// comment describes the resource in a single line.
+func (r generatedResource) comment() string {
+	parts := []string{r.region, r.resource.GetID()}
+	if name := r.resource.GetName(); name != "" && name != r.resource.GetID() {
+		parts = append(parts, fmt.Sprintf("name=%s", name))
+	}
+	if created := r.resource.GetCreationDate(); created != nil {
+		parts = append(parts, fmt.Sprintf("created=%s", created.UTC().Format(time.RFC3339)))
+	}
+	if tags := r.resource.GetTags(); tags != nil && len(*tags) > 0 {
+		var kvs []string
+		for k, v := range *tags {
+			kvs = append(kvs, k+"="+v)
+		}
+		sort.Strings(kvs)
+		parts = append(parts, fmt.Sprintf("tags={%s}", strings.Join(kvs, ", ")))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func exactMatch(s string) string {
+	return "^" + regexp.QuoteMeta(s) + "$"
+}
+
+func mapping() *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.MappingNode}
+}
+
+func scalar(value, tag string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: value, Tag: tag}
+}
+
+func appendPair(node *yamlv3.Node, key string, value *yamlv3.Node) {
+	node.Content = append(node.Content, scalar(key, "!!str"), value)
+}
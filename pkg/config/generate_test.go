@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsweeper "github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/spf13/afero"
+)
+
+func TestGenerate(t *testing.T) {
+	defer func() { AppFs = afero.NewOsFs() }()
+
+	inventory := awsweeper.IRegionResourceTypeResources{
+		"eu-west-1": {
+			"ec2": {
+				&awsweeper.Instance{ID: aws.String("i-1"), Name: aws.String("web"), Tags: awsweeper.Tags{"team": "a"}},
+				&awsweeper.Instance{ID: aws.String("i-2"), Tags: awsweeper.Tags{"team": "b.c"}},
+				&awsweeper.Instance{ID: aws.String("i-3")},
+			},
+		},
+		"us-east-1": {
+			"s3_bucket": {
+				&awsweeper.S3Bucket{ID: aws.String("logs.example.com")},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		groupByTag string
+		filters    int
+	}{
+		{name: "pinned to ids", filters: 3},
+		{name: "grouped by tag", groupByTag: "team", filters: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := Generate(Options{Regions: []string{"eu-west-1", "us-east-1"}}, inventory, tc.groupByTag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(out), "# eu-west-1 i-1 name=web tags={team=a}") {
+				t.Errorf("expected a comment describing i-1, got:\n%s", out)
+			}
+
+			writeFiles(t, map[string]string{"generated.yaml": string(out)})
+			cfg, err := Load("generated.yaml")
+			if err != nil {
+				t.Fatalf("generated config does not load: %v\n%s", err, out)
+			}
+
+			if !cfg.Options.DryRun {
+				t.Error("expected the generated config to be a dry run")
+			}
+
+			if fs := cfg.FiltersFor("", "eu-west-1"); len(fs["ec2"]) != tc.filters {
+				t.Errorf("expected %d ec2 filters, got %d", tc.filters, len(fs["ec2"]))
+			}
+
+			for region, rtrs := range inventory {
+				fs := cfg.FiltersFor("", region)
+				for resType, resources := range rtrs {
+					matched, err := fs[resType].Apply(resources)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(matched) != len(resources) {
+						t.Errorf("expected all %s resources in %s to match, got %d of %d", resType, region, len(matched), len(resources))
+					}
+				}
+			}
+
+			// the filters of a region don't select resources of the same name in other regions
+			if fs := cfg.FiltersFor("", "eu-west-1"); fs["s3_bucket"] != nil {
+				t.Errorf("expected no s3_bucket filters in eu-west-1, got %v", fs["s3_bucket"])
+			}
+			if fs := cfg.FiltersFor("", "us-east-1"); fs["ec2"] != nil {
+				t.Errorf("expected no ec2 filters in us-east-1, got %v", fs["ec2"])
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+)
+
+type schema = map[string]interface{}
+
+func stringSchema(description string) schema {
+	return schema{"type": "string", "description": description}
+}
+
+func stringsSchema(description string) schema {
+	return schema{"type": "array", "items": schema{"type": "string"}, "description": description}
+}
+
+// Schema returns a JSON Schema of the config file, including all supported resource types,
+// to be used by editors for completion and validation.
+func Schema() map[string]interface{} {
+	resourceTypes := schema{}
+	for _, t := range aws.SupportedResourceTypes() {
+		resourceTypes[string(t)] = schema{
+			"oneOf": []schema{
+				{"type": "null"},
+				{"type": "array", "items": schema{"$ref": "#/definitions/filter"}},
+			},
+		}
+	}
+
+	return schema{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "awsweeper config",
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"options"},
+		"properties": schema{
+			"include": stringsSchema("config files to include, relative to this file"),
+			"options": schema{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"regions"},
+				"properties": schema{
+					"dry-run":                 schema{"type": "boolean"},
+					"max-retries":             schema{"type": "integer", "minimum": 0},
+					"s3-force-path-style":     schema{"type": "boolean"},
+					"regions":                 schema{"type": "array", "minItems": 1, "items": schema{"type": "string"}, "description": "regions to sweep or \"all\""},
+					"exclude-regions":         stringsSchema("regions to leave out"),
+					"partition":               schema{"enum": []string{"aws", "aws-cn", "aws-us-gov"}},
+					"profile":                 stringSchema("profile of the shared config and credentials files"),
+					"role-to-assume":          stringSchema("ARN of the role to assume"),
+					"external-id":             stringSchema("external ID required by the role to assume"),
+					"session-name":            stringSchema("session name of the assumed role"),
+					"account-external-id":     stringSchema("external ID required by the role assumed in each account"),
+					"account-session-name":    stringSchema("session name of the role assumed in each account"),
+					"duration":                stringSchema("duration of the assumed role session, e.g. 1h"),
+					"mfa-serial":              stringSchema("serial number of the MFA device"),
+					"web-identity-token-file": stringSchema("file with an OIDC token"),
+					"endpoints":               schema{"type": "object", "additionalProperties": schema{"type": "string"}, "description": "endpoints by service endpoint ID or \"default\""},
+					"disable-ssl":             schema{"type": "boolean"},
+					"insecure-skip-verify":    schema{"type": "boolean"},
+					"page-size":               schema{"type": "integer", "minimum": 1, "description": "resources requested per page, within the limits of each API"},
+					"bulk-tags":               schema{"type": "boolean", "description": "fetch tags with the Resource Groups Tagging API"},
+					"state-file":              stringSchema("file the state of stopped resources is kept in"),
+					"snapshot-dir":            stringSchema("directory the inventory of each run is saved to"),
+					"stack-resources":         schema{"enum": []string{StackResourcesSkip, StackResourcesDeleteStack, StackResourcesDelete}, "description": "how to handle resources belonging to a CloudFormation stack, delete by default"},
+					"pushgateway":             schema{"type": "string", "format": "uri", "description": "URL of a Prometheus Pushgateway to push the metrics of each run to"},
+					"pushgateway-job":         stringSchema("job the metrics are pushed as, awsweeper by default"),
+					"tracing":                 schema{"enum": []string{TracingOTLP, TracingStdout}, "description": "where to export the spans of each run"},
+					"tracing-endpoint":        schema{"type": "string", "format": "uri", "description": "OTLP/HTTP endpoint of the collector, http://localhost:4318 by default"},
+					"log-level":               schema{"enum": []string{"panic", "fatal", "error", "warn", "warning", "info", "debug", "trace"}, "description": "level of the logs, info by default"},
+					"log-format":              schema{"enum": []string{"text", "json"}, "description": "format of the logs, text by default"},
+					"audit-log":               stringSchema("file the audit log of deletions is appended to"),
+					"audit-s3":                schema{"type": "string", "pattern": "^s3://[^/]+/.+", "description": "S3 object (s3://bucket/key) the audit log of deletions is appended to"},
+					"extra":                   schema{"type": "object", "additionalProperties": schema{"type": "string"}},
+				},
+			},
+			"accounts": schema{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": schema{
+					"ids":                  stringsSchema("IDs of the accounts to sweep"),
+					"organizational-units": stringsSchema("organizational units whose accounts are swept"),
+					"exclude":              stringsSchema("IDs of the accounts to leave out"),
+					"role-template":        stringSchema("template of the role to assume in each account"),
+				},
+			},
+			"terraform": schema{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"states"},
+				"properties": schema{
+					"states": schema{"type": "array", "minItems": 1, "items": schema{"type": "string"}, "description": "Terraform state files, local paths or s3://bucket/key"},
+					"mode":   schema{"enum": []string{TerraformProtect, TerraformUnmanaged}, "description": "protect managed resources or sweep only unmanaged resources of managed types"},
+				},
+			},
+			"notify": schema{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": schema{
+					"events":       schema{"type": "array", "items": schema{"enum": []string{NotifyWarning, NotifySummary}}, "description": "events notified, all by default"},
+					"owner-tag":    stringSchema("tag resources are grouped by, owner by default"),
+					"grace-period": stringSchema("how long to wait after the warning before deleting, e.g. 1h"),
+					"webhooks":     schema{"type": "array", "items": schema{"type": "string", "format": "uri"}, "description": "URLs notifications are posted to as JSON"},
+					"slack":        schema{"type": "array", "items": schema{"type": "string", "format": "uri"}, "description": "Slack-compatible incoming webhooks"},
+					"sns":          stringsSchema("ARNs of SNS topics notifications are published to"),
+					"email": schema{
+						"type":                 "object",
+						"additionalProperties": false,
+						"required":             []string{"smtp", "from"},
+						"properties": schema{
+							"smtp":     stringSchema("host:port of the SMTP server"),
+							"from":     stringSchema("sender of the emails"),
+							"to":       stringsSchema("recipients of all notifications"),
+							"username": stringSchema("username to authenticate with"),
+							"password": stringSchema("password to authenticate with"),
+							"owners":   schema{"type": "boolean", "description": "send warnings to owners which are email addresses as well"},
+						},
+					},
+				},
+			},
+			"serve": schema{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"jobs"},
+				"properties": schema{
+					"listen":     stringSchema("address health, reports and metrics are served on, :8080 by default"),
+					"reports":    schema{"type": "integer", "minimum": 1, "description": "number of reports kept, 20 by default"},
+					"report-dir": stringSchema("directory reports are saved to"),
+					"jobs": schema{
+						"type": "array",
+						"items": schema{
+							"type":                 "object",
+							"additionalProperties": false,
+							"required":             []string{"name", "schedule"},
+							"properties": schema{
+								"name":     stringSchema("unique name of the job"),
+								"schedule": stringSchema("cron expression, e.g. \"0 2 * * *\" or \"@every 1h\""),
+								"timezone": stringSchema("timezone of the schedule, UTC by default"),
+								"command":  schema{"enum": []string{JobWipe, JobStop, JobStart}, "description": "command run by the job, wipe by default"},
+								"dry-run":  schema{"type": "boolean", "description": "overrides the dry-run option"},
+							},
+						},
+					},
+					"api": schema{
+						"type":                 "object",
+						"additionalProperties": false,
+						"required":             []string{"tokens"},
+						"properties": schema{
+							"tokens": schema{
+								"type":        "array",
+								"minItems":    1,
+								"items":       schema{"type": "string"},
+								"description": "bearer tokens authenticating requests to the API",
+							},
+						},
+					},
+				},
+			},
+			"definitions": schema{
+				"type":                 "object",
+				"additionalProperties": schema{"type": "array", "items": schema{"$ref": "#/definitions/filter"}},
+				"description":          "named filters to be referenced with use",
+			},
+			"filters": schema{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties":           resourceTypes,
+			},
+			"overrides": schema{
+				"type": "array",
+				"items": schema{
+					"type":                 "object",
+					"additionalProperties": false,
+					"properties": schema{
+						"regions":  stringsSchema("regions the override applies to"),
+						"accounts": stringsSchema("accounts the override applies to"),
+						"filters": schema{
+							"type":                 "object",
+							"additionalProperties": false,
+							"properties":           resourceTypes,
+						},
+					},
+				},
+			},
+		},
+		"definitions": schema{
+			"filter": schema{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": schema{
+					"use": stringSchema("name of a filter definition"),
+					"ids": stringsSchema("regular expressions matching the resource ID"),
+					"tags": schema{
+						"type":        "array",
+						"items":       schema{"type": "object", "additionalProperties": schema{"type": "string"}},
+						"description": "tag keys with regular expressions matching their values",
+					},
+					"created": schema{
+						"type":                 "object",
+						"additionalProperties": false,
+						"properties": schema{
+							"before": stringSchema("timestamp"),
+							"after":  stringSchema("timestamp"),
+						},
+					},
+					"age": schema{
+						"type":                 "object",
+						"additionalProperties": false,
+						"properties": schema{
+							"older_than":   stringSchema("duration, e.g. 72h"),
+							"younger_than": stringSchema("duration, e.g. 72h"),
+						},
+					},
+					"not": schema{"type": "array", "items": schema{"$ref": "#/definitions/filter"}},
+					"schedule": schema{
+						"type":                 "object",
+						"additionalProperties": false,
+						"properties": schema{
+							"days":           schema{"type": "array", "items": schema{"enum": []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}}},
+							"start":          schema{"type": "string", "pattern": "^[0-2][0-9]:[0-5][0-9]$"},
+							"stop":           schema{"type": "string", "pattern": "^[0-2][0-9]:[0-5][0-9]$"},
+							"timezone":       stringSchema("IANA time zone, e.g. Europe/Lisbon"),
+							"read_capacity":  schema{"type": "integer", "minimum": 1},
+							"write_capacity": schema{"type": "integer", "minimum": 1},
+						},
+					},
+				},
+			},
+		},
+	}
+}
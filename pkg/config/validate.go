@@ -0,0 +1,470 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/cron"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// snsTopic matches ARNs of SNS topics.
+var snsTopic = regexp.MustCompile(`^arn:[^:]+:sns:[^:]+:[0-9]{12}:.+`)
+
+// s3Location matches S3 objects s3://bucket/key.
+var s3Location = regexp.MustCompile(`^s3://[^/]+/.+`)
+
+// Problem is an issue found in a config file, located by its line and column.
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+	}
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+): (.*)`)
+
+// validator collects the problems of a config file and the files it includes.
+type validator struct {
+	// file is the file being validated
+	file     string
+	problems []Problem
+	vars     map[string]string
+	visited  map[string]bool
+	// definitions are the names of the filter definitions of all files and uses the references to them
+	definitions map[string]bool
+	uses        []reference
+}
+
+// reference is a reference to a filter definition with use.
+type reference struct {
+	file string
+	node *yamlv3.Node
+}
+
+func (v *validator) addf(node *yamlv3.Node, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate checks a config file and the files it includes without contacting AWS and returns all problems found:
+// unknown fields, unsupported resource types, invalid regular expressions, time ranges, durations and schedules,
+// undefined variables and references to filter definitions which don't exist.
+func Validate(filename string) ([]Problem, error) {
+	return ValidateWithVars(filename, nil)
+}
+
+// ValidateWithVars validates a config file like Validate, interpolating variables like LoadWithVars. Unless the files
+// have problems, the config is then loaded like LoadWithVars, so that problems only found by composing the files are
+// reported as well, without their line.
+func ValidateWithVars(filename string, vars map[string]string) ([]Problem, error) {
+	v := &validator{vars: vars, visited: make(map[string]bool), definitions: make(map[string]bool)}
+	if err := v.validateFile(filename); err != nil {
+		return nil, err
+	}
+
+	for _, use := range v.uses {
+		if !v.definitions[use.node.Value] {
+			v.problems = append(v.problems, Problem{
+				File:    use.file,
+				Line:    use.node.Line,
+				Column:  use.node.Column,
+				Message: fmt.Sprintf("filter definition %q does not exist", use.node.Value),
+			})
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return v.problems, nil
+	}
+
+	if _, err := LoadWithVars(filename, vars); err != nil {
+		v.problems = append(v.problems, Problem{File: filename, Message: err.Error()})
+	}
+	return v.problems, nil
+}
+
+// validateFile checks a config file and the files it includes.
+func (v *validator) validateFile(filename string) error {
+	data, err := afero.ReadFile(AppFs, filename)
+	if err != nil {
+		return err
+	}
+
+	v.visited[filename] = true
+	previous := v.file
+	v.file = filename
+	defer func() { v.file = previous }()
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		messages := []string{err.Error()}
+		if typeErr, ok := err.(*yaml.TypeError); ok {
+			messages = typeErr.Errors
+		}
+
+		lines := strings.Split(string(data), "\n")
+		for _, m := range messages {
+			problem := Problem{File: filename, Message: m}
+			if match := yamlErrorLine.FindStringSubmatch(m); match != nil {
+				problem.Line, _ = strconv.Atoi(match[1])
+				problem.Message = match[2]
+			}
+			// the types of interpolated values are checked when loading the config
+			if problem.Line > 0 && problem.Line <= len(lines) && variablePattern.MatchString(lines[problem.Line-1]) {
+				continue
+			}
+			v.problems = append(v.problems, problem)
+		}
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		// syntax errors have already been reported by the strict unmarshalling
+		return nil
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yamlv3.MappingNode {
+		v.problems = append(v.problems, Problem{File: filename, Line: 1, Message: "config must be a mapping"})
+		return nil
+	}
+
+	// values are checked once interpolated, at the position of their variables
+	interpolateNode(&doc, v.vars, func(node *yamlv3.Node, name string) {
+		v.addf(node, "undefined variable %s", name)
+	})
+
+	root := doc.Content[0]
+	if previous != "" || mappingValue(root, "include") != nil {
+		// options may be set by the including or included files
+	} else if options := mappingValue(root, "options"); options == nil {
+		v.addf(root, "options with at least one region are required")
+	} else if regions := mappingValue(options, "regions"); regions == nil || len(regions.Content) == 0 {
+		v.addf(options, "at least one region is required in options")
+	}
+
+	if policy := mappingValue(mappingValue(root, "options"), "stack-resources"); policy != nil {
+		switch policy.Value {
+		case StackResourcesSkip, StackResourcesDeleteStack, StackResourcesDelete:
+		default:
+			v.addf(policy, "unknown stack-resources policy %q, expected %s, %s or %s", policy.Value,
+				StackResourcesSkip, StackResourcesDeleteStack, StackResourcesDelete)
+		}
+	}
+
+	if exporter := mappingValue(mappingValue(root, "options"), "tracing"); exporter != nil &&
+		exporter.Value != TracingOTLP && exporter.Value != TracingStdout {
+		v.addf(exporter, "unknown tracing exporter %q, expected %s or %s", exporter.Value, TracingOTLP, TracingStdout)
+	}
+
+	if level := mappingValue(mappingValue(root, "options"), "log-level"); level != nil {
+		if _, err := logrus.ParseLevel(level.Value); err != nil {
+			v.addf(level, "unknown log-level %q", level.Value)
+		}
+	}
+
+	if format := mappingValue(mappingValue(root, "options"), "log-format"); format != nil &&
+		format.Value != logging.FormatText && format.Value != logging.FormatJSON {
+		v.addf(format, "unknown log-format %q, expected %s or %s", format.Value, logging.FormatText, logging.FormatJSON)
+	}
+
+	for _, key := range []string{"pushgateway", "tracing-endpoint"} {
+		if endpoint := mappingValue(mappingValue(root, "options"), key); endpoint != nil {
+			if u, err := url.Parse(endpoint.Value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				v.addf(endpoint, "%s must be an http(s) URL, got %q", key, endpoint.Value)
+			}
+		}
+	}
+
+	if location := mappingValue(mappingValue(root, "options"), "audit-s3"); location != nil {
+		if !s3Location.MatchString(location.Value) {
+			v.addf(location, "audit-s3 must be an S3 object s3://bucket/key, got %q", location.Value)
+		}
+	}
+
+	if n := mappingValue(root, "notify"); n != nil {
+		v.validateNotify(n)
+	}
+
+	if jobs := mappingValue(mappingValue(root, "serve"), "jobs"); jobs != nil {
+		v.validateJobs(jobs)
+	}
+
+	if api := mappingValue(mappingValue(root, "serve"), "api"); api != nil {
+		if tokens := mappingValue(api, "tokens"); tokens == nil || len(tokens.Content) == 0 {
+			v.addf(api, "at least one token is required for the api")
+		} else {
+			for _, token := range tokens.Content {
+				// the length of interpolated tokens is only known when loading the config
+				if !variablePattern.MatchString(token.Value) && len(token.Value) < MinTokenLength {
+					v.addf(token, "tokens must have at least %d characters", MinTokenLength)
+				}
+			}
+		}
+	}
+
+	if fs := mappingValue(root, "filters"); fs != nil {
+		v.validateFilters(fs)
+	}
+
+	if tf := mappingValue(root, "terraform"); tf != nil {
+		if states := mappingValue(tf, "states"); states == nil || len(states.Content) == 0 {
+			v.addf(tf, "at least one Terraform state is required")
+		}
+		if mode := mappingValue(tf, "mode"); mode != nil && mode.Value != TerraformProtect && mode.Value != TerraformUnmanaged {
+			v.addf(mode, "unknown Terraform mode %q, expected %s or %s", mode.Value, TerraformProtect, TerraformUnmanaged)
+		}
+	}
+
+	if definitions := mappingValue(root, "definitions"); definitions != nil && definitions.Kind == yamlv3.MappingNode {
+		for i := 1; i < len(definitions.Content); i += 2 {
+			v.definitions[definitions.Content[i-1].Value] = true
+			for _, f := range definitions.Content[i].Content {
+				v.validateFilter(f)
+			}
+		}
+	}
+
+	if overrides := mappingValue(root, "overrides"); overrides != nil {
+		for _, o := range overrides.Content {
+			if fs := mappingValue(o, "filters"); fs != nil {
+				v.validateFilters(fs)
+			}
+		}
+	}
+
+	if includes := mappingValue(root, "include"); includes != nil {
+		for _, include := range includes.Content {
+			path := includePath(filename, include.Value)
+			// recursive includes are reported when loading the config
+			if v.visited[path] {
+				continue
+			}
+			if err := v.validateFile(path); err != nil {
+				v.addf(include, "failed to read included file: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateJobs checks the names, schedules and commands of the jobs of the serve command.
+func (v *validator) validateJobs(jobs *yamlv3.Node) {
+	names := make(map[string]bool)
+	for _, job := range jobs.Content {
+		if name := mappingValue(job, "name"); name == nil {
+			v.addf(job, "name is required in jobs")
+		} else if names[name.Value] {
+			v.addf(name, "duplicate job %q", name.Value)
+		} else {
+			names[name.Value] = true
+		}
+
+		if schedule := mappingValue(job, "schedule"); schedule == nil {
+			v.addf(job, "schedule is required in jobs")
+		} else if _, err := cron.Parse(schedule.Value); err != nil {
+			v.addf(schedule, "invalid schedule: %v", err)
+		}
+
+		if tz := mappingValue(job, "timezone"); tz != nil {
+			if _, err := time.LoadLocation(tz.Value); err != nil {
+				v.addf(tz, "unknown timezone %q", tz.Value)
+			}
+		}
+
+		if command := mappingValue(job, "command"); command != nil &&
+			command.Value != JobWipe && command.Value != JobStop && command.Value != JobStart {
+			v.addf(command, "unknown command %q, expected %s, %s or %s", command.Value, JobWipe, JobStop, JobStart)
+		}
+	}
+}
+
+// validateNotify checks the events and sinks of notifications.
+func (v *validator) validateNotify(n *yamlv3.Node) {
+	if events := mappingValue(n, "events"); events != nil {
+		for _, e := range events.Content {
+			if e.Value != NotifyWarning && e.Value != NotifySummary {
+				v.addf(e, "unknown notify event %q, expected %s or %s", e.Value, NotifyWarning, NotifySummary)
+			}
+		}
+	}
+
+	for _, key := range []string{"webhooks", "slack"} {
+		if urls := mappingValue(n, key); urls != nil {
+			for _, endpoint := range urls.Content {
+				if u, err := url.Parse(endpoint.Value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					v.addf(endpoint, "%s must be http(s) URLs, got %q", key, endpoint.Value)
+				}
+			}
+		}
+	}
+
+	if topics := mappingValue(n, "sns"); topics != nil {
+		for _, topic := range topics.Content {
+			if !snsTopic.MatchString(topic.Value) {
+				v.addf(topic, "sns must be ARNs of SNS topics, got %q", topic.Value)
+			}
+		}
+	}
+
+	if email := mappingValue(n, "email"); email != nil {
+		for _, key := range []string{"smtp", "from"} {
+			if mappingValue(email, key) == nil {
+				v.addf(email, "%s is required in email", key)
+			}
+		}
+		if addr := mappingValue(email, "smtp"); addr != nil {
+			if _, _, err := net.SplitHostPort(addr.Value); err != nil {
+				v.addf(addr, "smtp must be host:port, got %q", addr.Value)
+			}
+		}
+	}
+}
+
+// mappingValue returns the value of key in a mapping node or nil.
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) validateFilters(node *yamlv3.Node) {
+	supported := make(map[string]bool)
+	for _, t := range aws.SupportedResourceTypes() {
+		supported[string(t)] = true
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !supported[key.Value] {
+			v.addf(key, "resource type %q is not supported", key.Value)
+		}
+
+		// an empty list is most likely left over from deleting the filters of resources to keep, so it is rejected
+		// rather than taken to select all resources of the type like a resource type without value
+		if value.Kind == yamlv3.SequenceNode && len(value.Content) == 0 {
+			v.addf(value, "empty filter list of %q, remove the resource type to keep its resources or "+
+				"leave it without value to delete them all", key.Value)
+		}
+
+		if value.Kind == yamlv3.SequenceNode {
+			for _, f := range value.Content {
+				v.validateFilter(f)
+			}
+		}
+	}
+}
+
+func (v *validator) validateFilter(node *yamlv3.Node) {
+	if node.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "use":
+			v.uses = append(v.uses, reference{file: v.file, node: value})
+		case "ids":
+			for _, id := range value.Content {
+				v.validateRegexp(id)
+			}
+		case "tags":
+			for _, tag := range value.Content {
+				for j := 1; j < len(tag.Content); j += 2 {
+					v.validateRegexp(tag.Content[j])
+				}
+			}
+		case "created":
+			var created filters.Created
+			if err := value.Decode(&created); err != nil {
+				v.addf(value, "invalid created: %v", err)
+			} else if created.Before != nil && created.After != nil && !created.After.Before(*created.Before) {
+				v.addf(value, "created after %s is not before %s, no resource can match", created.After, created.Before)
+			}
+		case "age":
+			v.validateAge(value)
+		case "schedule":
+			var schedule filters.Schedule
+			if err := value.Decode(&schedule); err != nil {
+				v.addf(value, "invalid schedule: %v", err)
+			} else {
+				for _, err := range schedule.Validate() {
+					v.addf(value, "%v", err)
+				}
+			}
+		case "not":
+			for _, f := range value.Content {
+				v.validateFilter(f)
+			}
+		}
+	}
+}
+
+func (v *validator) validateRegexp(node *yamlv3.Node) {
+	if _, err := regexp.Compile(node.Value); err != nil {
+		v.addf(node, "invalid regular expression %q: %v", node.Value, err)
+	}
+}
+
+func (v *validator) validateAge(node *yamlv3.Node) {
+	durations := make(map[string]time.Duration)
+	for _, key := range []string{"older_than", "younger_than"} {
+		value := mappingValue(node, key)
+		if value == nil {
+			continue
+		}
+
+		d, err := time.ParseDuration(value.Value)
+		if err != nil {
+			v.addf(value, "invalid duration %q for %s: %v", value.Value, key, err)
+			continue
+		}
+
+		if d < 0 {
+			v.addf(value, "%s must not be negative", key)
+		}
+		durations[key] = d
+	}
+
+	olderThan, hasOlderThan := durations["older_than"]
+	youngerThan, hasYoungerThan := durations["younger_than"]
+	if hasOlderThan && hasYoungerThan && youngerThan <= olderThan {
+		v.addf(node, "younger_than %s is not greater than older_than %s, no resource can match", youngerThan, olderThan)
+	}
+}
@@ -0,0 +1,313 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestValidate(t *testing.T) {
+	AppFs = afero.NewMemMapFs()
+	defer func() { AppFs = afero.NewOsFs() }()
+
+	tests := []struct {
+		name     string
+		config   string
+		problems []string
+	}{
+		{
+			name: "valid",
+			config: `options:
+  regions: [eu-west-1]
+filters:
+  ec2:
+    - ids: ["^vd-2965"]
+      age:
+        older_than: 24h
+        younger_than: 72h
+  s3_bucket:
+`,
+		},
+		{
+			name: "all problems with positions",
+			config: `options:
+  regions: [eu-west-1]
+filters:
+  ec3:
+  ec2:
+    - ids: ["vd-(2965"]
+      tags:
+        - Name: "[a-"
+      created:
+        before: 2018-01-01
+        after: 2019-01-01
+    - not:
+        - ids: ["("]
+    - schedule:
+        start: "08:00"
+        stop: "08:00"
+    - schedule:
+        start: "22:00"
+        stop: "06:00"
+`,
+			problems: []string{
+				`c.yaml:4:3: resource type "ec3" is not supported`,
+				`c.yaml:6:13: invalid regular expression "vd-(2965"`,
+				`c.yaml:8:17: invalid regular expression "[a-"`,
+				`c.yaml:10:9: created after`,
+				`c.yaml:13:17: invalid regular expression "("`,
+				`c.yaml:15:9: schedule start 08:00 is the same as stop 08:00`,
+			},
+		},
+		{
+			name: "empty filter list",
+			config: `options:
+  regions: [eu-west-1]
+filters:
+  s3_bucket: []
+`,
+			problems: []string{
+				`c.yaml:4:14: empty filter list of "s3_bucket"`,
+			},
+		},
+		{
+			name: "unknown fields and missing regions",
+			config: `options:
+  dry-run: true
+  unknown: 1
+`,
+			problems: []string{
+				`c.yaml:3: field unknown not found`,
+				`c.yaml:2:3: at least one region is required in options`,
+			},
+		},
+		{
+			name: "pushgateway",
+			config: `options:
+  regions: [eu-west-1]
+  pushgateway: pushgateway:9091
+`,
+			problems: []string{
+				`c.yaml:3:16: pushgateway must be an http(s) URL`,
+			},
+		},
+		{
+			name: "tracing",
+			config: `options:
+  regions: [eu-west-1]
+  tracing: jaeger
+  tracing-endpoint: localhost:4318
+`,
+			problems: []string{
+				`c.yaml:3:12: unknown tracing exporter "jaeger"`,
+				`c.yaml:4:21: tracing-endpoint must be an http(s) URL`,
+			},
+		},
+		{
+			name: "logs",
+			config: `options:
+  regions: [eu-west-1]
+  log-level: verbose
+  log-format: logfmt
+`,
+			problems: []string{
+				`c.yaml:3:14: unknown log-level "verbose"`,
+				`c.yaml:4:15: unknown log-format "logfmt"`,
+			},
+		},
+		{
+			name: "audit",
+			config: `options:
+  regions: [eu-west-1]
+  audit-log: audit.jsonl
+  audit-s3: bucket/audit.jsonl
+`,
+			problems: []string{
+				`c.yaml:4:13: audit-s3 must be an S3 object s3://bucket/key, got "bucket/audit.jsonl"`,
+			},
+		},
+		{
+			name: "notify",
+			config: `options:
+  regions: [eu-west-1]
+notify:
+  events: [warning, reminder]
+  webhooks: [https://example.com/hook, example.com]
+  sns: [arn:aws:sns:eu-west-1:123456789012:sweeps, sweeps]
+  email:
+    smtp: smtp.example.com
+`,
+			problems: []string{
+				`c.yaml:4:21: unknown notify event "reminder"`,
+				`c.yaml:5:40: webhooks must be http(s) URLs, got "example.com"`,
+				`c.yaml:6:52: sns must be ARNs of SNS topics, got "sweeps"`,
+				`c.yaml:8:5: from is required in email`,
+				`c.yaml:8:11: smtp must be host:port, got "smtp.example.com"`,
+			},
+		},
+		{
+			name: "serve",
+			config: `options:
+  regions: [eu-west-1]
+serve:
+  jobs:
+    - name: nightly
+      schedule: 0 2 * * *
+    - name: nightly
+      schedule: 0 25 * * *
+      timezone: Mars/Olympus
+      command: delete
+  api:
+    tokens: [secret, "${API_TOKEN:-a-token-long-enough-for-the-api}"]
+`,
+			problems: []string{
+				`c.yaml:7:13: duplicate job "nightly"`,
+				`c.yaml:8:17: invalid schedule: invalid hour "25"`,
+				`c.yaml:9:17: unknown timezone "Mars/Olympus"`,
+				`c.yaml:10:16: unknown command "delete"`,
+				`c.yaml:12:14: tokens must have at least 16 characters`,
+			},
+		},
+		{
+			name: "terraform",
+			config: `options:
+  regions: [eu-west-1]
+terraform:
+  states: [s3://bucket/terraform.tfstate]
+  mode: orphans
+`,
+			problems: []string{
+				`c.yaml:5:9: unknown Terraform mode "orphans"`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := afero.WriteFile(AppFs, "c.yaml", []byte(tc.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			problems, err := Validate("c.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(problems) != len(tc.problems) {
+				t.Fatalf("expected %d problems, got %d: %v", len(tc.problems), len(problems), problems)
+			}
+
+			for i, p := range problems {
+				if !strings.HasPrefix(p.String(), tc.problems[i]) {
+					t.Errorf("expected problem starting with %q, got %q", tc.problems[i], p.String())
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_Composed(t *testing.T) {
+	AppFs = afero.NewMemMapFs()
+	defer func() { AppFs = afero.NewOsFs() }()
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		vars     map[string]string
+		problems []string
+	}{
+		{
+			name: "valid",
+			files: map[string]string{
+				"conf/c.yaml": `include: [shared.yaml]
+options:
+  max-retries: ${RETRIES}
+filters:
+  ec2:
+    - use: deployment
+`,
+				"conf/shared.yaml": `options:
+  regions: [eu-west-1]
+include: [definitions.yaml]
+`,
+				"conf/definitions.yaml": `definitions:
+  deployment:
+    - ids: ["^${DEPLOYMENT}-"]
+`,
+			},
+			vars: map[string]string{"RETRIES": "3", "DEPLOYMENT": "vd-2965"},
+		},
+		{
+			name: "problems of included files and definitions",
+			files: map[string]string{
+				"conf/c.yaml": `include: [shared.yaml, missing.yaml]
+options:
+  max-retries: ${RETRIES}
+filters:
+  ec2:
+    - use: missing
+`,
+				"conf/shared.yaml": `options:
+  regions: [eu-west-1]
+filters:
+  ec3:
+  s3_bucket:
+    - ids: ["${DEPLOYMENT}-("]
+`,
+			},
+			vars: map[string]string{"DEPLOYMENT": "vd-2965"},
+			problems: []string{
+				`conf/c.yaml:3:16: undefined variable RETRIES`,
+				`conf/shared.yaml:4:3: resource type "ec3" is not supported`,
+				`conf/shared.yaml:6:13: invalid regular expression "vd-2965-("`,
+				`conf/c.yaml:1:24: failed to read included file`,
+				`conf/c.yaml:6:12: filter definition "missing" does not exist`,
+			},
+		},
+		{
+			name: "problems of the composed config",
+			files: map[string]string{
+				"conf/c.yaml": `include: [shared.yaml]
+filters:
+  ec2:
+    - use: everything
+`,
+				"conf/shared.yaml": `options:
+  regions: [eu-west-1]
+definitions:
+  everything:
+`,
+			},
+			problems: []string{
+				`conf/c.yaml: Invalid filter of ec2: Filter definition "everything" is empty`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			AppFs = afero.NewMemMapFs()
+			for name, content := range tc.files {
+				if err := afero.WriteFile(AppFs, name, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			problems, err := ValidateWithVars("conf/c.yaml", tc.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(problems) != len(tc.problems) {
+				t.Fatalf("expected %d problems, got %d: %v", len(tc.problems), len(problems), problems)
+			}
+
+			for i, p := range problems {
+				if !strings.HasPrefix(p.String(), tc.problems[i]) {
+					t.Errorf("expected problem starting with %q, got %q", tc.problems[i], p.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Package cron parses cron expressions and computes when they are due next.
+//
+// Expressions have the five standard fields: minute, hour, day of month, month and day of week. Fields are *, values,
+// ranges (1-5), steps (*/15 or 0-30/10) or lists of them (1,15), months and days of week may be given by their names
+// (jan, mon). The descriptors @yearly, @monthly, @weekly, @daily, @hourly and @every <duration> (e.g. @every 90m) are
+// supported as well.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule interface {
+	// Next returns the first time the schedule is due after t, in the location of t, or the zero time if never.
+	Next(t time.Time) time.Time
+}
+
+// field is the range of valid values of a field and the names of its values, if any.
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minutes = field{name: "minute", min: 0, max: 59}
+	hours   = field{name: "hour", min: 0, max: 23}
+	days    = field{name: "day of month", min: 1, max: 31}
+	months  = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	weekday = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("interval of @every must be at least 1s, got %s", d)
+		}
+		return every(d), nil
+	}
+
+	if standard, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = standard
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d in %q", len(fields), expr)
+	}
+
+	s := &spec{}
+	var err error
+	if s.minute, err = minutes.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hours.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = days.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = months.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = weekday.parse(fields[4]); err != nil {
+		return nil, err
+	}
+
+	// 7 is Sunday as well
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDom = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	s.anyDow = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+	return s, nil
+}
+
+// parse returns the set of values of a field as bits.
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeAndStep := strings.SplitN(part, "/", 2)
+		step := 1
+		if len(rangeAndStep) == 2 {
+			var err error
+			if step, err = strconv.Atoi(rangeAndStep[1]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q of %s", rangeAndStep[1], f.name)
+			}
+		}
+
+		start, end := f.min, f.max
+		if r := rangeAndStep[0]; r != "*" {
+			bounds := strings.SplitN(r, "-", 2)
+			var err error
+			if start, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = f.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if len(rangeAndStep) == 2 {
+				// 5/15 is 5-max/15
+				end = f.max
+			}
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q of %s", r, f.name)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a value of a field, a number or a name.
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// spec is a schedule of the standard fields.
+type spec struct {
+	minute, hour, dom, month, dow uint64
+	// anyDom and anyDow are set if the day of month or week is unrestricted. If both are restricted, a day matching
+	// either is due.
+	anyDom, anyDow bool
+}
+
+// maxYears bounds the search for the next time, e.g. of February 30th, which never comes.
+const maxYears = 5
+
+func (s *spec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(end) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *spec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDom && s.anyDow:
+		return true
+	case s.anyDom:
+		return dow
+	case s.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// every is due in fixed intervals.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Truncate(time.Second).Add(time.Duration(e))
+}
@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2020, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2020-01-15 10:31"},
+		{"*/15 * * * *", "2020-01-15 10:45"},
+		{"0 2 * * *", "2020-01-16 02:00"},
+		{"@daily", "2020-01-16 00:00"},
+		{"@hourly", "2020-01-15 11:00"},
+		{"30 9 * * mon-fri", "2020-01-16 09:30"},
+		{"0 18 * * sat,sun", "2020-01-18 18:00"},
+		{"0 0 * * 7", "2020-01-19 00:00"},
+		{"0 0 1 * *", "2020-02-01 00:00"},
+		{"0 0 29 feb *", "2020-02-29 00:00"},
+		{"5/20 10 * * *", "2020-01-15 10:45"},
+		{"0 0 1-7 * mon", "2020-01-20 00:00"},
+		{"0 12 * JUN *", "2020-06-01 12:00"},
+		{"@every 90m", "2020-01-15 12:00"},
+	} {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+
+		if next := s.Next(now).Format("2006-01-02 15:04"); next != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.expr, tc.want, next)
+		}
+	}
+}
+
+func TestNext_Never(t *testing.T) {
+	s, err := Parse("0 0 30 feb *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected February 30th never to come, got %s", next)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * foo *",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"@every 1ms",
+		"@every tomorrow",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
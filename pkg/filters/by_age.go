@@ -13,13 +13,15 @@ func (f Filter) byAge(resources aws.IResources) (filteredResources aws.IResource
 		return resources, err
 	}
 
-	logrus.WithField("Age:", f.Age).Debug("Filtering resources based on Age")
+	logrus.WithField("age", f.Age).Debug("Filtering resources based on Age")
 	now := time.Now()
 
 	for _, r := range resources {
 		createdAfter := true
 		createdBefore := true
-		r.EnsureLazyLoaded()
+		if !loaded(r) {
+			continue
+		}
 		creationDate := r.GetCreationDate()
 		if creationDate != nil {
 			if f.Age.YoungerThan != nil {
@@ -37,8 +39,8 @@ func (f Filter) byAge(resources aws.IResources) (filteredResources aws.IResource
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"Before Filtering": len(resources),
-		"After Filtering":  len(filteredResources),
+		"before": len(resources),
+		"after":  len(filteredResources),
 	}).Debug("Filtered By Age")
 	return filteredResources, err
 }
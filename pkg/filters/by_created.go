@@ -2,6 +2,7 @@ package filters
 
 import (
 	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
 	"github.com/sirupsen/logrus"
 )
 
@@ -11,11 +12,13 @@ func (f Filter) byCreated(resources aws.IResources) (filteredResources aws.IReso
 		return resources, err
 	}
 
-	logrus.WithField("Created:", f.Created).Debug("Filtering resources based on CreatedDate")
+	logrus.WithField("created", f.Created).Debug("Filtering resources based on CreatedDate")
 	for _, r := range resources {
 		createdAfter := true
 		createdBefore := true
-		r.EnsureLazyLoaded()
+		if !loaded(r) {
+			continue
+		}
 		creationDate := r.GetCreationDate()
 
 		if creationDate != nil {
@@ -27,7 +30,10 @@ func (f Filter) byCreated(resources aws.IResources) (filteredResources aws.IReso
 				createdBefore = creationDate.Unix() < f.Created.Before.Unix()
 			}
 		} else {
-			logrus.WithField("Resource", r).Warn("Ignoring 'Created' filtering because resources does not have creation date")
+			logrus.WithFields(logrus.Fields{
+				logging.ID:     r.GetID(),
+				logging.Action: "filter",
+			}).Warn("Ignoring 'Created' filtering because resources does not have creation date")
 		}
 
 		if createdAfter && createdBefore {
@@ -36,8 +42,8 @@ func (f Filter) byCreated(resources aws.IResources) (filteredResources aws.IReso
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"Before Filtering": len(resources),
-		"After Filtering":  len(filteredResources),
+		"before": len(resources),
+		"after":  len(filteredResources),
 	}).Debug("Filtered By Created")
 	return filteredResources, err
 }
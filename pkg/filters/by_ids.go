@@ -1,8 +1,6 @@
 package filters
 
 import (
-	"regexp"
-
 	"github.com/cmpsoares91/awsweeper/pkg/aws"
 	"github.com/sirupsen/logrus"
 )
@@ -13,22 +11,23 @@ func (f Filter) byIDs(resources aws.IResources) (filteredResources aws.IResource
 		return resources, err
 	}
 
-	logrus.WithField("IDs:", f.IDs).Debug("Filtering resources based on IDs")
+	logrus.WithField("ids", f.IDs).Debug("Filtering resources based on IDs")
 	for _, idFilter := range *f.IDs {
-		for _, r := range resources {
-			if ok, err := regexp.MatchString(idFilter, r.GetID()); ok {
-				if err != nil {
-					return nil, err
-				}
+		re, err := compileRegexp(idFilter)
+		if err != nil {
+			return nil, err
+		}
 
+		for _, r := range resources {
+			if re.MatchString(r.GetID()) {
 				filteredResources = append(filteredResources, r)
 			}
 		}
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"Before Filtering": len(resources),
-		"After Filtering":  len(filteredResources),
+		"before": len(resources),
+		"after":  len(filteredResources),
 	}).Debug("Filtered By ID")
 	return filteredResources, err
 }
@@ -11,14 +11,14 @@ func (f Filter) byNot(resources aws.IResources) (filteredResources aws.IResource
 		return resources, err
 	}
 
-	logrus.WithField("Not:", f.Not).Debug("Filtering resources based on Not")
+	logrus.WithField("not", f.Not).Debug("Filtering resources based on Not")
 	matchedResources, err := f.Not.Apply(resources)
 	if err != nil {
 		return nil, err
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"Number of Matched Resources": len(matchedResources),
+		"matched": len(matchedResources),
 	}).Debug("'Not' filter discovered resources that should not be included")
 	matchedResourcesMap := make(map[string]bool)
 	for _, mr := range matchedResources {
@@ -32,8 +32,8 @@ func (f Filter) byNot(resources aws.IResources) (filteredResources aws.IResource
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"Before Filtering": len(resources),
-		"After Filtering":  len(filteredResources),
+		"before": len(resources),
+		"after":  len(filteredResources),
 	}).Debug("Filtered By Not")
 	return filteredResources, err
 }
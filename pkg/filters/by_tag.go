@@ -1,9 +1,6 @@
 package filters
 
 import (
-	"log"
-	"regexp"
-
 	"github.com/cmpsoares91/awsweeper/pkg/aws"
 	"github.com/sirupsen/logrus"
 )
@@ -14,11 +11,13 @@ func (f Filter) byTags(resources aws.IResources) (filteredResources aws.IResourc
 		return resources, err
 	}
 
-	logrus.WithField("Tags", f.Tags).Debug("Filtering resources based on Tags")
+	logrus.WithField("tags", f.Tags).Debug("Filtering resources based on Tags")
 
 	for _, tag := range *f.Tags {
 		for _, r := range resources {
-			r.EnsureLazyLoaded()
+			if !loaded(r) {
+				continue
+			}
 			allTagsMatched := true
 			for tagKey, tagValueRegex := range tag {
 				resourceTags := r.GetTags()
@@ -26,9 +25,12 @@ func (f Filter) byTags(resources aws.IResources) (filteredResources aws.IResourc
 					if tagVal, ok := (*resourceTags)[tagKey]; !ok {
 						allTagsMatched = false
 					} else {
-						if matched, err := regexp.MatchString(tagValueRegex, tagVal); err != nil {
-							log.Fatal(err)
-						} else if !matched {
+						re, err := compileRegexp(tagValueRegex)
+						if err != nil {
+							return nil, err
+						}
+
+						if !re.MatchString(tagVal) {
 							allTagsMatched = false
 						}
 					}
@@ -42,8 +44,8 @@ func (f Filter) byTags(resources aws.IResources) (filteredResources aws.IResourc
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"Before Filtering": len(resources),
-		"After Filtering":  len(filteredResources),
+		"before": len(resources),
+		"after":  len(filteredResources),
 	}).Debug("Filtered By Tags")
 	return filteredResources, err
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,11 +14,13 @@ type Filters []Filter
 
 // Filter represents an entry in Config and selects the resources of a particular resource type.
 type Filter struct {
-	IDs     *[]string `yaml:",omitempty"`
-	Tags    *Tags     `yaml:",omitempty"`
-	Created *Created  `yaml:",omitempty"`
-	Age     *Age      `yaml:",omitempty"`
-	Not     *Filters  `yaml:",omitempty`
+	Use      string    `yaml:",omitempty"`
+	IDs      *[]string `yaml:",omitempty"`
+	Tags     *Tags     `yaml:",omitempty"`
+	Created  *Created  `yaml:",omitempty"`
+	Age      *Age      `yaml:",omitempty"`
+	Not      *Filters  `yaml:",omitempty"`
+	Schedule *Schedule `yaml:",omitempty"`
 }
 
 type Tags []map[string]string
@@ -32,10 +35,43 @@ type Age struct {
 	YoungerThan *time.Duration `yaml:"younger_than,omitempty"`
 }
 
+// MarshalYAML keeps the difference between no filters (null), which select all resources, and an empty list.
+func (filters Filters) MarshalYAML() (interface{}, error) {
+	if filters == nil {
+		return nil, nil
+	}
+
+	return []Filter(filters), nil
+}
+
+// Deletion returns the filters that select resources for deletion, i.e. all filters without a schedule.
+func (filters Filters) Deletion() (deletion Filters) {
+	for _, f := range filters {
+		if f.Schedule == nil {
+			deletion = append(deletion, f)
+		}
+	}
+
+	return deletion
+}
+
+// Scheduled returns the filters that select resources to be stopped and started on a schedule.
+func (filters Filters) Scheduled() (scheduled Filters) {
+	for _, f := range filters {
+		if f.Schedule != nil {
+			scheduled = append(scheduled, f)
+		}
+	}
+
+	return scheduled
+}
+
+// Apply returns the resources selected by any of the filters. Without filters (nil), all resources are selected,
+// but none by an empty list of filters.
 func (filters Filters) Apply(resources aws.IResources) (filteredResources aws.IResources, err error) {
-	logrus.WithField("Number of filters", len(filters)).Debug("Applying Filters")
+	logrus.WithField("filters", len(filters)).Debug("Applying Filters")
 
-	if len(filters) == 0 {
+	if filters == nil {
 		return resources, err
 	}
 
@@ -51,11 +87,27 @@ func (filters Filters) Apply(resources aws.IResources) (filteredResources aws.IR
 	return filteredResources, err
 }
 
+// Match returns the first filter selecting the resource, nil if none does.
+func (filters Filters) Match(r aws.IResource) (*Filter, error) {
+	for i, filter := range filters {
+		fr, err := filter.Apply(aws.IResources{r})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(fr) > 0 {
+			return &filters[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (filter Filter) Apply(resources aws.IResources) (filteredResources aws.IResources, err error) {
 	logrus.WithFields(logrus.Fields{
-		"Filter": filter,
-		"Number of Resources Before Filtering": len(resources),
-	}).Info("Apply Filter")
+		"filter": filter,
+		"before": len(resources),
+	}).Debug("Apply Filter")
 
 	filteredResources = resources
 	filteredResources, err = filter.byIDs(filteredResources)
@@ -86,8 +138,25 @@ func (filter Filter) Apply(resources aws.IResources) (filteredResources aws.IRes
 	return filteredResources, err
 }
 
+// loaded loads the attributes of a resource which filters match on. A resource whose attributes can't be loaded is
+// not matched.
+func loaded(r aws.IResource) bool {
+	if err := r.EnsureLazyLoaded(); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			logging.ID:     r.GetID(),
+			logging.Action: "filter",
+		}).Warn("Not matching a resource whose attributes can't be loaded")
+		return false
+	}
+
+	return true
+}
+
 func (filter Filter) String() string {
 	var output []string
+	if filter.Use != "" {
+		output = append(output, fmt.Sprintf("USE:[%s]", filter.Use))
+	}
 	if filter.IDs != nil {
 		output = append(output, fmt.Sprintf("IDS:[%s]", strings.Join(*filter.IDs, ",")))
 	}
@@ -129,5 +198,9 @@ func (filter Filter) String() string {
 		output = append(output, fmt.Sprintf("NOT:{%s}", strings.Join(ns, ",")))
 	}
 
+	if filter.Schedule != nil {
+		output = append(output, fmt.Sprintf("SCHEDULE:[%s]", filter.Schedule.String()))
+	}
+
 	return strings.Join(output, ", ")
 }
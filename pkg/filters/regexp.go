@@ -0,0 +1,61 @@
+package filters
+
+import (
+	"regexp"
+	"sync"
+)
+
+var (
+	regexpsMu sync.Mutex
+	regexps   = make(map[string]*regexp.Regexp)
+)
+
+// compileRegexp compiles expr once and returns the cached regular expression afterwards.
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	regexpsMu.Lock()
+	defer regexpsMu.Unlock()
+
+	if re, ok := regexps[expr]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	regexps[expr] = re
+	return re, nil
+}
+
+// Compile precompiles the regular expressions of all filters, including nested ones,
+// and returns the first one that fails to compile.
+func (filters Filters) Compile() error {
+	for _, f := range filters {
+		if f.IDs != nil {
+			for _, expr := range *f.IDs {
+				if _, err := compileRegexp(expr); err != nil {
+					return err
+				}
+			}
+		}
+
+		if f.Tags != nil {
+			for _, tag := range *f.Tags {
+				for _, expr := range tag {
+					if _, err := compileRegexp(expr); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		if f.Not != nil {
+			if err := f.Not.Compile(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package filters
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const officeHoursLayout = "15:04"
+
+// Schedule turns a filter into a scale-down rule: instead of being deleted, matched resources
+// are stopped outside of office hours and started again within them.
+type Schedule struct {
+	Days          []string `yaml:",omitempty"`
+	Start         string   `yaml:",omitempty"`
+	Stop          string   `yaml:",omitempty"`
+	Timezone      string   `yaml:",omitempty"`
+	ReadCapacity  *int64   `yaml:"read_capacity,omitempty"`
+	WriteCapacity *int64   `yaml:"write_capacity,omitempty"`
+}
+
+// IsOfficeHours reports whether t falls into the office hours described by the schedule.
+// Without days all weekdays are office days, without start/stop the whole day is office hours.
+// If start is after stop, the office hours span midnight and belong to the day they start on, e.g. 22:00-06:00 on
+// fri lasts until saturday 06:00.
+func (s Schedule) IsOfficeHours(t time.Time) (bool, error) {
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return false, err
+		}
+		t = t.In(loc)
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	start, stop := 0, 24*60
+	if s.Start != "" {
+		st, err := time.Parse(officeHoursLayout, s.Start)
+		if err != nil {
+			return false, fmt.Errorf("invalid schedule start %q: %v", s.Start, err)
+		}
+		start = st.Hour()*60 + st.Minute()
+	}
+
+	if s.Stop != "" {
+		st, err := time.Parse(officeHoursLayout, s.Stop)
+		if err != nil {
+			return false, fmt.Errorf("invalid schedule stop %q: %v", s.Stop, err)
+		}
+		stop = st.Hour()*60 + st.Minute()
+	}
+
+	if start <= stop {
+		return s.isOfficeDay(t.Weekday()) && now >= start && now < stop, nil
+	}
+
+	// overnight office hours
+	if now >= start {
+		return s.isOfficeDay(t.Weekday()), nil
+	}
+	if now < stop {
+		return s.isOfficeDay((t.Weekday() + 6) % 7), nil
+	}
+	return false, nil
+}
+
+// isOfficeDay reports whether office hours start on the weekday.
+func (s Schedule) isOfficeDay(weekday time.Weekday) bool {
+	days := s.Days
+	if len(days) == 0 {
+		days = []string{"mon", "tue", "wed", "thu", "fri"}
+	}
+
+	for _, d := range days {
+		if strings.EqualFold(d, weekday.String()[:3]) || strings.EqualFold(d, weekday.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the days, office hours, timezone and capacities of the schedule.
+func (s Schedule) Validate() (errs []error) {
+	for _, d := range s.Days {
+		valid := false
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			if strings.EqualFold(d, wd.String()[:3]) || strings.EqualFold(d, wd.String()) {
+				valid = true
+			}
+		}
+
+		if !valid {
+			errs = append(errs, fmt.Errorf("invalid schedule day %q", d))
+		}
+	}
+
+	var start, stop time.Time
+	var startErr, stopErr error
+	if s.Start != "" {
+		if start, startErr = time.Parse(officeHoursLayout, s.Start); startErr != nil {
+			errs = append(errs, fmt.Errorf("invalid schedule start %q: expected HH:MM", s.Start))
+		}
+	}
+
+	if s.Stop != "" {
+		if stop, stopErr = time.Parse(officeHoursLayout, s.Stop); stopErr != nil {
+			errs = append(errs, fmt.Errorf("invalid schedule stop %q: expected HH:MM", s.Stop))
+		}
+	}
+
+	// a start after the stop spans midnight, but without any time in between there are no office hours
+	if s.Start != "" && s.Stop != "" && startErr == nil && stopErr == nil && start.Equal(stop) {
+		errs = append(errs, fmt.Errorf("schedule start %s is the same as stop %s", s.Start, s.Stop))
+	}
+
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("invalid schedule timezone %q", s.Timezone))
+		}
+	}
+
+	if s.ReadCapacity != nil && *s.ReadCapacity < 1 {
+		errs = append(errs, fmt.Errorf("schedule read_capacity must be at least 1"))
+	}
+
+	if s.WriteCapacity != nil && *s.WriteCapacity < 1 {
+		errs = append(errs, fmt.Errorf("schedule write_capacity must be at least 1"))
+	}
+
+	return errs
+}
+
+func (s Schedule) String() string {
+	output := fmt.Sprintf("%s-%s", s.Start, s.Stop)
+	if len(s.Days) > 0 {
+		output = output + fmt.Sprintf(" (%s)", strings.Join(s.Days, ","))
+	}
+	if s.Timezone != "" {
+		output = output + " " + s.Timezone
+	}
+
+	return output
+}
@@ -0,0 +1,87 @@
+package filters
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedule_IsOfficeHours(t *testing.T) {
+	// 2020-01-15 is a wednesday
+	at := func(day int, clock string) time.Time {
+		hm, err := time.Parse(officeHoursLayout, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2020, 1, day, hm.Hour(), hm.Minute(), 0, 0, time.UTC)
+	}
+
+	office := Schedule{Start: "08:00", Stop: "19:00"}
+	overnight := Schedule{Days: []string{"fri"}, Start: "22:00", Stop: "06:00"}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		t        time.Time
+		expected bool
+	}{
+		{name: "whole weekday", schedule: Schedule{}, t: at(15, "00:00"), expected: true},
+		{name: "whole weekend day", schedule: Schedule{}, t: at(18, "12:00"), expected: false},
+		{name: "before start", schedule: office, t: at(15, "07:59"), expected: false},
+		{name: "at start", schedule: office, t: at(15, "08:00"), expected: true},
+		{name: "before stop", schedule: office, t: at(15, "18:59"), expected: true},
+		{name: "at stop", schedule: office, t: at(15, "19:00"), expected: false},
+		{name: "not an office day", schedule: Schedule{Days: []string{"mon", "Tuesday"}, Start: "08:00", Stop: "19:00"}, t: at(15, "12:00"), expected: false},
+		{name: "full day name", schedule: Schedule{Days: []string{"Wednesday"}}, t: at(15, "12:00"), expected: true},
+		{name: "saturday", schedule: office, t: at(18, "12:00"), expected: false},
+		{name: "sunday", schedule: office, t: at(19, "12:00"), expected: false},
+		// 07:30 UTC is 08:30 in Paris in winter
+		{name: "timezone", schedule: Schedule{Start: "08:00", Stop: "19:00", Timezone: "Europe/Paris"}, t: at(15, "07:30"), expected: true},
+		{name: "timezone before start", schedule: Schedule{Start: "08:00", Stop: "19:00", Timezone: "America/New_York"}, t: at(15, "12:00"), expected: false},
+		// 23:30 UTC on sunday is monday in Tokyo
+		{name: "timezone changes the day", schedule: Schedule{Timezone: "Asia/Tokyo"}, t: at(19, "23:30"), expected: true},
+		{name: "overnight after start", schedule: overnight, t: at(17, "23:00"), expected: true},
+		{name: "overnight after midnight", schedule: overnight, t: at(18, "05:59"), expected: true},
+		{name: "overnight at stop", schedule: overnight, t: at(18, "06:00"), expected: false},
+		{name: "overnight before start", schedule: overnight, t: at(17, "21:59"), expected: false},
+		{name: "overnight of another day", schedule: overnight, t: at(17, "05:00"), expected: false},
+	}
+
+	for _, tc := range tests {
+		officeHours, err := tc.schedule.IsOfficeHours(tc.t)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if officeHours != tc.expected {
+			t.Errorf("%s: expected office hours %t at %s, got %t", tc.name, tc.expected, tc.t.Format(time.RFC1123), officeHours)
+		}
+	}
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		schedule Schedule
+		errs     []string
+	}{
+		{schedule: Schedule{Days: []string{"mon", "Friday"}, Start: "08:00", Stop: "19:00", Timezone: "Europe/Lisbon"}},
+		{schedule: Schedule{Start: "22:00", Stop: "06:00"}},
+		{schedule: Schedule{Start: "08:00", Stop: "08:00"}, errs: []string{"is the same as stop"}},
+		{schedule: Schedule{Days: []string{"someday"}, Start: "8am", Timezone: "Mars/Olympus"}, errs: []string{
+			`invalid schedule day "someday"`, `invalid schedule start "8am"`, `invalid schedule timezone "Mars/Olympus"`,
+		}},
+	}
+
+	for _, tc := range tests {
+		errs := tc.schedule.Validate()
+		if len(errs) != len(tc.errs) {
+			t.Errorf("%s: expected errors %v, got %v", tc.schedule, tc.errs, errs)
+			continue
+		}
+		for i, err := range errs {
+			if !strings.Contains(err.Error(), tc.errs[i]) {
+				t.Errorf("%s: expected error %q, got %v", tc.schedule, tc.errs[i], err)
+			}
+		}
+	}
+}
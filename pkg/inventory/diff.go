@@ -0,0 +1,148 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// Change is a resource whose tags differ between two snapshots.
+type Change struct {
+	Old Item `json:"old"`
+	New Item `json:"new"`
+}
+
+// Diff is the difference between two snapshots.
+type Diff struct {
+	Created []Item   `json:"created"`
+	Deleted []Item   `json:"deleted"`
+	Changed []Change `json:"changed"`
+}
+
+// Empty reports whether the snapshots contain the same resources with the same tags.
+func (d Diff) Empty() bool {
+	return len(d.Created) == 0 && len(d.Deleted) == 0 && len(d.Changed) == 0
+}
+
+// Compare returns the resources created, deleted and whose tags changed from the old to the new snapshot.
+func Compare(old, new *Snapshot) Diff {
+	oldItems := make(map[string]Item)
+	for _, i := range old.Items {
+		oldItems[i.Key()] = i
+	}
+
+	d := Diff{Created: []Item{}, Deleted: []Item{}, Changed: []Change{}}
+	seen := make(map[string]bool)
+	for _, i := range new.Items {
+		seen[i.Key()] = true
+		o, ok := oldItems[i.Key()]
+		if !ok {
+			d.Created = append(d.Created, i)
+			continue
+		}
+
+		if !tagsEqual(o.Tags, i.Tags) {
+			d.Changed = append(d.Changed, Change{Old: o, New: i})
+		}
+	}
+
+	for _, i := range old.Items {
+		if !seen[i.Key()] {
+			d.Deleted = append(d.Deleted, i)
+		}
+	}
+
+	Sort(d.Created, "")
+	Sort(d.Deleted, "")
+	sort.SliceStable(d.Changed, func(a, b int) bool { return d.Changed[a].New.Key() < d.Changed[b].New.Key() })
+
+	return d
+}
+
+func tagsEqual(x, y map[string]string) bool {
+	if len(x) == 0 && len(y) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(x, y)
+}
+
+// WriteText writes the diff grouped by account, region and resource type: created resources prefixed with +,
+// deleted ones with - and changed ones with ~ followed by the tags added, removed and modified.
+func (d Diff) WriteText(w io.Writer) error {
+	type line struct {
+		item Item
+		text string
+	}
+
+	var lines []line
+	for _, i := range d.Created {
+		lines = append(lines, line{i, fmt.Sprintf("+ %s", describe(i))})
+	}
+	for _, i := range d.Deleted {
+		lines = append(lines, line{i, fmt.Sprintf("- %s", describe(i))})
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, line{c.New, fmt.Sprintf("~ %s %s", describe(c.New), tagChanges(c.Old.Tags, c.New.Tags))})
+	}
+
+	group := func(i Item) string {
+		if i.Account != "" {
+			return fmt.Sprintf("[%s][%s][%s]", i.Account, i.Region, i.ResourceType)
+		}
+		return fmt.Sprintf("[%s][%s]", i.Region, i.ResourceType)
+	}
+
+	sort.SliceStable(lines, func(a, b int) bool {
+		ga, gb := group(lines[a].item), group(lines[b].item)
+		if ga != gb {
+			return ga < gb
+		}
+		return lines[a].item.ID < lines[b].item.ID
+	})
+
+	current := ""
+	for _, l := range lines {
+		if g := group(l.item); g != current {
+			current = g
+			if _, err := fmt.Fprintln(w, g); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "  %s\n", l.text); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d created, %d deleted, %d changed\n", len(d.Created), len(d.Deleted), len(d.Changed))
+	return err
+}
+
+func describe(i Item) string {
+	if i.Name != "" && i.Name != i.ID {
+		return fmt.Sprintf("%s (%s)", i.ID, i.Name)
+	}
+
+	return i.ID
+}
+
+func tagChanges(old, new map[string]string) string {
+	var changes []string
+	for k, v := range new {
+		if ov, ok := old[k]; !ok {
+			changes = append(changes, fmt.Sprintf("+%s=%s", k, v))
+		} else if ov != v {
+			changes = append(changes, fmt.Sprintf("%s=%s->%s", k, ov, v))
+		}
+	}
+	for k, v := range old {
+		if _, ok := new[k]; !ok {
+			changes = append(changes, fmt.Sprintf("-%s=%s", k, v))
+		}
+	}
+	sort.Strings(changes)
+
+	return fmt.Sprint(changes)
+}
@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/spf13/afero"
+)
+
+func TestCompare(t *testing.T) {
+	defer func() { config.AppFs = afero.NewOsFs() }()
+	config.AppFs = afero.NewMemMapFs()
+
+	old := &Snapshot{Time: now, Items: []Item{
+		{Region: "eu-west-1", ResourceType: "ec2", ID: "i-1", Tags: map[string]string{"team": "a"}},
+		{Region: "eu-west-1", ResourceType: "ec2", ID: "i-2", Tags: map[string]string{"team": "a", "env": "dev"}},
+		{Region: "us-east-1", ResourceType: "s3_bucket", ID: "logs"},
+	}}
+	new := &Snapshot{Time: now, Items: []Item{
+		{Region: "eu-west-1", ResourceType: "ec2", ID: "i-2", Tags: map[string]string{"team": "b", "owner": "me"}},
+		{Region: "eu-west-1", ResourceType: "ec2", ID: "i-3", Name: "web"},
+		{Region: "us-east-1", ResourceType: "s3_bucket", ID: "logs", Tags: map[string]string{}},
+	}}
+
+	filename, err := old.SaveTo("snapshots", "before")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename != "snapshots/20200301T120000Z-before.json" {
+		t.Errorf("unexpected snapshot file %s", filename)
+	}
+	if old, err = LoadSnapshot(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := Compare(old, new)
+	var out bytes.Buffer
+	if err := diff.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[eu-west-1][ec2]
+  - i-1
+  ~ i-2 [+owner=me -env=dev team=a->b]
+  + i-3 (web)
+1 created, 1 deleted, 1 changed
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+
+	if !Compare(new, new).Empty() {
+		t.Error("expected no difference between the same snapshots")
+	}
+}
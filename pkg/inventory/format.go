@@ -0,0 +1,131 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats supported by Write.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Write writes the groups in the format. A single group without a value is written as a plain list of items.
+func Write(w io.Writer, format string, groups []Group) error {
+	switch format {
+	case FormatTable:
+		return writeTable(w, groups)
+	case FormatJSON:
+		return writeJSON(w, groups)
+	case FormatCSV:
+		return writeCSV(w, groups)
+	}
+
+	return fmt.Errorf("unknown format %q, expected table, json or csv", format)
+}
+
+func ungrouped(groups []Group) bool {
+	return len(groups) == 1 && groups[0].Value == ""
+}
+
+func formatTags(tags map[string]string) string {
+	var kvs []string
+	for k, v := range tags {
+		kvs = append(kvs, k+"="+v)
+	}
+	sort.Strings(kvs)
+
+	return strings.Join(kvs, ",")
+}
+
+func formatCreationDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeTable(w io.Writer, groups []Group) error {
+	withAccount := false
+	for _, g := range groups {
+		for _, i := range g.Items {
+			withAccount = withAccount || i.Account != ""
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for n, g := range groups {
+		if !ungrouped(groups) {
+			if n > 0 {
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintf(tw, "# %s (%d)\n", g.Value, len(g.Items))
+		}
+
+		if withAccount {
+			fmt.Fprint(tw, "ACCOUNT\t")
+		}
+		fmt.Fprintln(tw, "REGION\tTYPE\tID\tNAME\tCREATED\tTAGS")
+		for _, i := range g.Items {
+			if withAccount {
+				fmt.Fprintf(tw, "%s\t", i.Account)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				i.Region, i.ResourceType, i.ID, i.Name, formatCreationDate(i.CreationDate), formatTags(i.Tags))
+		}
+	}
+
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, groups []Group) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if ungrouped(groups) {
+		items := groups[0].Items
+		if items == nil {
+			items = []Item{}
+		}
+		return encoder.Encode(items)
+	}
+
+	return encoder.Encode(groups)
+}
+
+func writeCSV(w io.Writer, groups []Group) error {
+	cw := csv.NewWriter(w)
+	header := []string{"account", "region", "type", "id", "name", "created", "tags"}
+	if !ungrouped(groups) {
+		header = append([]string{"group"}, header...)
+	}
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		for _, i := range g.Items {
+			record := []string{i.Account, i.Region, string(i.ResourceType), i.ID, i.Name,
+				formatCreationDate(i.CreationDate), formatTags(i.Tags)}
+			if !ungrouped(groups) {
+				record = append([]string{g.Value}, record...)
+			}
+
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,209 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+)
+
+// Item is a single resource of the inventory with all its attributes.
+type Item struct {
+	Account      string            `json:"account,omitempty"`
+	Region       string            `json:"region"`
+	ResourceType aws.ResourceType  `json:"type"`
+	ID           string            `json:"id"`
+	Name         string            `json:"name,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	CreationDate *time.Time        `json:"created,omitempty"`
+}
+
+// Age returns the time since the resource was created or 0 if the creation date is unknown.
+func (i Item) Age(now time.Time) time.Duration {
+	if i.CreationDate == nil {
+		return 0
+	}
+
+	return now.Sub(*i.CreationDate)
+}
+
+// Items flattens the resources listed per region and type into inventory items.
+func Items(account string, resources aws.IRegionResourceTypeResources) []Item {
+	var items []Item
+	for region, rtrs := range resources {
+		for resType, rs := range rtrs {
+			for _, r := range rs {
+				items = append(items, NewItem(account, region, resType, r))
+			}
+		}
+	}
+
+	Sort(items, "")
+	return items
+}
+
+// NewItem returns the inventory item of a resource, with a copy of its tags.
+func NewItem(account string, region aws.Region, resourceType aws.ResourceType, r aws.IResource) Item {
+	item := Item{
+		Account:      account,
+		Region:       region,
+		ResourceType: resourceType,
+		ID:           r.GetID(),
+		Name:         r.GetName(),
+		CreationDate: r.GetCreationDate(),
+	}
+
+	if tags := r.GetTags(); tags != nil && len(*tags) > 0 {
+		item.Tags = make(map[string]string, len(*tags))
+		for k, v := range *tags {
+			item.Tags[k] = v
+		}
+	}
+
+	return item
+}
+
+// Key of an item, unique within an inventory.
+func (i Item) Key() string {
+	return strings.Join([]string{i.Account, i.Region, string(i.ResourceType), i.ID}, "/")
+}
+
+// Attribute returns the value of an attribute to sort or group by: account, region, type, id, name, age or
+// tag:<key>. Ages are grouped into buckets.
+func (i Item) Attribute(attribute string, now time.Time) (string, error) {
+	switch {
+	case attribute == "account":
+		return i.Account, nil
+	case attribute == "region":
+		return i.Region, nil
+	case attribute == "type":
+		return string(i.ResourceType), nil
+	case attribute == "id":
+		return i.ID, nil
+	case attribute == "name":
+		return i.Name, nil
+	case attribute == "age":
+		return ageBucket(i, now), nil
+	case strings.HasPrefix(attribute, "tag:"):
+		return i.Tags[strings.TrimPrefix(attribute, "tag:")], nil
+	}
+
+	return "", fmt.Errorf("unknown attribute %q, expected account, region, type, id, name, age or tag:<key>", attribute)
+}
+
+// ValidateAttribute returns an error if items can not be sorted or grouped by the attribute.
+func ValidateAttribute(attribute string) error {
+	_, err := Item{}.Attribute(attribute, time.Time{})
+	return err
+}
+
+var ageBuckets = []struct {
+	name   string
+	maxAge time.Duration
+}{
+	{"< 1 day", 24 * time.Hour},
+	{"< 1 week", 7 * 24 * time.Hour},
+	{"< 30 days", 30 * 24 * time.Hour},
+	{"< 90 days", 90 * 24 * time.Hour},
+}
+
+func ageBucket(i Item, now time.Time) string {
+	if i.CreationDate == nil {
+		return "unknown"
+	}
+
+	age := i.Age(now)
+	for _, b := range ageBuckets {
+		if age < b.maxAge {
+			return b.name
+		}
+	}
+
+	return ">= 90 days"
+}
+
+// Sort sorts the items by the attribute, oldest first for age. Ties, and all items without an attribute, are sorted
+// by region, type and ID.
+func Sort(items []Item, attribute string) {
+	sort.SliceStable(items, func(a, b int) bool {
+		x, y := items[a], items[b]
+		switch {
+		case attribute == "age":
+			if !timeEqual(x.CreationDate, y.CreationDate) {
+				// unknown creation dates last
+				if x.CreationDate == nil || y.CreationDate == nil {
+					return y.CreationDate == nil
+				}
+				return x.CreationDate.Before(*y.CreationDate)
+			}
+		case attribute != "":
+			xv, _ := x.Attribute(attribute, time.Time{})
+			yv, _ := y.Attribute(attribute, time.Time{})
+			if xv != yv {
+				return xv < yv
+			}
+		}
+
+		return x.Key() < y.Key()
+	})
+}
+
+func timeEqual(x, y *time.Time) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+
+	return x.Equal(*y)
+}
+
+// Group is a set of items sharing the value of an attribute.
+type Group struct {
+	Value string `json:"value"`
+	Items []Item `json:"items"`
+}
+
+// GroupBy groups the items by the value of the attribute, keeping their order within each group.
+// Groups are ordered by value, age buckets from youngest to oldest.
+func GroupBy(items []Item, attribute string, now time.Time) ([]Group, error) {
+	if err := ValidateAttribute(attribute); err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var groups []Group
+	for _, i := range items {
+		value, _ := i.Attribute(attribute, now)
+		g, ok := index[value]
+		if !ok {
+			g = len(groups)
+			index[value] = g
+			groups = append(groups, Group{Value: value})
+		}
+		groups[g].Items = append(groups[g].Items, i)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		if attribute == "age" {
+			return ageOrder(groups[a].Value) < ageOrder(groups[b].Value)
+		}
+		return groups[a].Value < groups[b].Value
+	})
+
+	return groups, nil
+}
+
+func ageOrder(bucket string) int {
+	for i, b := range ageBuckets {
+		if b.name == bucket {
+			return i
+		}
+	}
+
+	if bucket == "unknown" {
+		return len(ageBuckets) + 1
+	}
+
+	return len(ageBuckets)
+}
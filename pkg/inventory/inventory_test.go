@@ -0,0 +1,110 @@
+package inventory
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsweeper "github.com/cmpsoares91/awsweeper/pkg/aws"
+)
+
+var now = time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func daysAgo(days int) *time.Time {
+	t := now.Add(-time.Duration(days) * 24 * time.Hour)
+	return &t
+}
+
+func testItems() []Item {
+	return Items("", awsweeper.IRegionResourceTypeResources{
+		"eu-west-1": {
+			"ec2": {
+				&awsweeper.Instance{ID: aws.String("i-2"), Name: aws.String("web"), Tags: awsweeper.Tags{"team": "b"}, CreationDate: daysAgo(3)},
+				&awsweeper.Instance{ID: aws.String("i-1"), Tags: awsweeper.Tags{"team": "a"}, CreationDate: daysAgo(100)},
+			},
+		},
+		"us-east-1": {
+			"s3_bucket": {
+				&awsweeper.S3Bucket{ID: aws.String("logs"), Tags: awsweeper.Tags{"team": "a"}},
+			},
+		},
+	})
+}
+
+func ids(items []Item) []string {
+	var result []string
+	for _, i := range items {
+		result = append(result, i.ID)
+	}
+	return result
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		attribute string
+		expected  []string
+	}{
+		{"", []string{"i-1", "i-2", "logs"}},
+		{"name", []string{"i-1", "logs", "i-2"}},
+		{"age", []string{"i-1", "i-2", "logs"}},
+		{"tag:team", []string{"i-1", "logs", "i-2"}},
+	}
+
+	for _, tc := range tests {
+		items := testItems()
+		Sort(items, tc.attribute)
+		if !reflect.DeepEqual(ids(items), tc.expected) {
+			t.Errorf("sort by %q: expected %v, got %v", tc.attribute, tc.expected, ids(items))
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	groups, err := GroupBy(testItems(), "age", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	for _, g := range groups {
+		values = append(values, g.Value)
+	}
+
+	if expected := []string{"< 1 week", ">= 90 days", "unknown"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected groups %v, got %v", expected, values)
+	}
+
+	if _, err := GroupBy(testItems(), "owner", now); err == nil {
+		t.Error("expected an error for an unknown attribute")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	groups, err := GroupBy(testItems(), "tag:team", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, FormatCSV, groups); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []string{
+		"group,account,region,type,id,name,created,tags",
+		"a,,eu-west-1,ec2,i-1,,2019-11-22T12:00:00Z,team=a",
+		"a,,us-east-1,s3_bucket,logs,,,team=a",
+		"b,,eu-west-1,ec2,i-2,web,2020-02-27T12:00:00Z,team=b",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), out.String())
+	}
+
+	if err := Write(&out, "yaml", groups); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/spf13/afero"
+)
+
+// Snapshot is the inventory at a point in time.
+type Snapshot struct {
+	Time  time.Time `json:"time"`
+	Items []Item    `json:"items"`
+}
+
+// LoadSnapshot reads a snapshot file.
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	data, err := afero.ReadFile(config.AppFs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Save writes the snapshot file.
+func (s *Snapshot) Save(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(config.AppFs, filename, data, 0644)
+}
+
+// SaveTo writes the snapshot into the directory, named by its time and the suffix, and returns the file name.
+func (s *Snapshot) SaveTo(dir, suffix string) (string, error) {
+	if err := config.AppFs.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	name := s.Time.UTC().Format("20060102T150405Z")
+	if suffix != "" {
+		name = name + "-" + suffix
+	}
+
+	filename := filepath.Join(dir, name+".json")
+	return filename, s.Save(filename)
+}
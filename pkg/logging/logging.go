@@ -0,0 +1,135 @@
+// Package logging configures the level and format of the logs and adds the context of the current run (e.g. its
+// ID, the account and region being swept) to every event.
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields identifying what an event is about, used consistently across all events.
+const (
+	// RunID identifies the run of a command
+	RunID = "run_id"
+	// Account is the ID of the account being swept
+	Account = "account"
+	// Region is the region being swept
+	Region = "region"
+	// Type is the resource type
+	Type = "type"
+	// ID is the ID of a resource
+	ID = "id"
+	// Action is what is done with a resource, e.g. delete
+	Action = "action"
+)
+
+// Formats of the logs.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// DefaultLevel is the level of the logs unless configured otherwise.
+const DefaultLevel = logrus.InfoLevel
+
+var (
+	mu      sync.Mutex
+	context = make(logrus.Fields)
+)
+
+func init() {
+	logrus.SetLevel(DefaultLevel)
+	logrus.AddHook(contextHook{})
+}
+
+// Setup sets the level (e.g. debug, info) and format (text or json) of the logs. Empty values keep the defaults.
+func Setup(level, format string) error {
+	lvl := DefaultLevel
+	if level != "" {
+		var err error
+		if lvl, err = logrus.ParseLevel(level); err != nil {
+			return err
+		}
+	}
+
+	switch format {
+	case "", FormatText:
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	case FormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q, expected %s or %s", format, FormatText, FormatJSON)
+	}
+
+	logrus.SetLevel(lvl)
+	return nil
+}
+
+// Push adds fields to the context of all events until the returned function is called, which restores the
+// previous context. Empty values remove a field from the context.
+func Push(fields logrus.Fields) func() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	previous := make(logrus.Fields, len(context))
+	for k, v := range context {
+		previous[k] = v
+	}
+
+	for k, v := range fields {
+		if v == "" || v == nil {
+			delete(context, k)
+		} else {
+			context[k] = v
+		}
+	}
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		context = previous
+	}
+}
+
+// Get returns the value of a field of the context, nil if it is not set.
+func Get(key string) interface{} {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return context[key]
+}
+
+// NewRunID returns a random ID of a run.
+func NewRunID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextHook adds the fields of the context to events which don't set them.
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire replaces the fields of the event, which are shared with the entry it was logged with, by a copy including
+// the context.
+func (contextHook) Fire(e *logrus.Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	data := make(logrus.Fields, len(e.Data)+len(context))
+	for k, v := range context {
+		data[k] = v
+	}
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	e.Data = data
+	return nil
+}
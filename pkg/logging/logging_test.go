@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetup(t *testing.T) {
+	defer Setup("", "")
+
+	if err := Setup("debug", FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if logrus.GetLevel() != logrus.DebugLevel {
+		t.Errorf("expected level debug, got %s", logrus.GetLevel())
+	}
+	if _, ok := logrus.StandardLogger().Formatter.(*logrus.JSONFormatter); !ok {
+		t.Errorf("expected the JSON formatter, got %T", logrus.StandardLogger().Formatter)
+	}
+
+	if err := Setup("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if logrus.GetLevel() != DefaultLevel {
+		t.Errorf("expected the default level, got %s", logrus.GetLevel())
+	}
+
+	if err := Setup("verbose", ""); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if err := Setup("", "logfmt"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestPush(t *testing.T) {
+	var b bytes.Buffer
+	logrus.SetOutput(&b)
+	defer logrus.SetOutput(os.Stderr)
+	if err := Setup("info", FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	defer Setup("", "")
+
+	event := func(log func()) map[string]interface{} {
+		b.Reset()
+		log()
+		fields := make(map[string]interface{})
+		if err := json.Unmarshal(b.Bytes(), &fields); err != nil {
+			t.Fatalf("%v: %s", err, b.String())
+		}
+		return fields
+	}
+
+	restoreRun := Push(logrus.Fields{RunID: "run", Account: "123456789012"})
+	restoreRegion := Push(logrus.Fields{Region: "eu-west-1"})
+	entry := logrus.WithFields(logrus.Fields{Type: "s3_bucket", Region: "us-east-1"})
+
+	fields := event(func() { entry.Info("Deleting") })
+	for k, want := range map[string]string{RunID: "run", Account: "123456789012", Region: "us-east-1", Type: "s3_bucket"} {
+		if fields[k] != want {
+			t.Errorf("expected %s=%s, got %v", k, want, fields[k])
+		}
+	}
+
+	restoreRegion()
+	Push(logrus.Fields{Account: ""})
+	fields = event(func() { entry.Info("Deleted") })
+	if _, ok := fields[Account]; ok || fields[RunID] != "run" {
+		t.Errorf("expected the account to be removed from the context, got %v", fields)
+	}
+	if len(entry.Data) != 2 {
+		t.Errorf("expected the fields of the entry to be unchanged, got %v", entry.Data)
+	}
+
+	restoreRun()
+	fields = event(func() { logrus.Info("Done") })
+	if _, ok := fields[RunID]; ok {
+		t.Errorf("expected an empty context, got %v", fields)
+	}
+}
@@ -0,0 +1,206 @@
+// Package metrics collects metrics of sweeps and exposes them in the Prometheus text format, either served over
+// HTTP or pushed to a Prometheus Pushgateway.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics of the stages of a sweep, labeled by region and resource type.
+var (
+	Discovered = NewCounter("awsweeper_resources_discovered_total", "Resources listed.", "region", "type")
+	Matched    = NewCounter("awsweeper_resources_matched_total", "Resources matched by the filters.", "region", "type")
+	Deleted    = NewCounter("awsweeper_resources_deleted_total", "Resources deleted.", "region", "type")
+	Failed     = NewCounter("awsweeper_resources_failed_total", "Resources which failed to be deleted.", "region", "type")
+)
+
+// Metrics of the requests to AWS, labeled by service.
+var (
+	APICalls     = NewCounter("awsweeper_api_calls_total", "Requests sent to AWS, including retries.", "service")
+	APIThrottles = NewCounter("awsweeper_api_throttles_total", "Requests to AWS which were throttled.", "service")
+)
+
+// Metrics of runs.
+var (
+	Runs        = NewCounter("awsweeper_runs_total", "Runs by result (success or failure).", "result")
+	RunDuration = NewGauge("awsweeper_run_duration_seconds", "Duration of the last run.")
+	LastSuccess = NewGauge("awsweeper_last_success_timestamp_seconds", "Time of the last successful run as Unix timestamp.")
+)
+
+// Metrics of the serve command.
+var (
+	SkippedRuns   = NewCounter("awsweeper_skipped_runs_total", "Runs of jobs skipped because another run was in progress.", "job")
+	ConfigReloads = NewCounter("awsweeper_config_reloads_total", "Reloads of a changed config by result (success or failure).", "result")
+)
+
+// registry holds all metrics, in the order they were created.
+var registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+// family is a metric with its values by label values.
+type family struct {
+	name, help, kind string
+	labels           []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newFamily(name, help, kind string, labels []string) *family {
+	f := &family{name: name, help: help, kind: kind, labels: labels, values: make(map[string]float64)}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.families = append(registry.families, f)
+	return f
+}
+
+// labelValueEscaper escapes label values as the Prometheus text format does: only backslashes, double quotes and line
+// feeds, unlike strconv.Quote which also escapes e.g. tabs and non-printable characters.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// key returns the label pairs of the given label values as they are written, e.g. `region="eu-west-1"`.
+func (f *family) key(labelValues []string) string {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", f.name, len(f.labels), len(labelValues)))
+	}
+
+	pairs := make([]string, len(f.labels))
+	for i, l := range f.labels {
+		pairs[i] = l + `="` + labelValueEscaper.Replace(labelValues[i]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *family) add(v float64, labelValues []string) {
+	key := f.key(labelValues)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] += v
+}
+
+func (f *family) set(v float64, labelValues []string) {
+	key := f.key(labelValues)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = v
+}
+
+func (f *family) get(labelValues []string) float64 {
+	key := f.key(labelValues)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[key]
+}
+
+func (f *family) write(w io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.values) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(f.values))
+	for k := range f.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		name := f.name
+		if k != "" {
+			name += "{" + k + "}"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", name, strconv.FormatFloat(f.values[k], 'g', -1, 64)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Counter is a metric which only increases.
+type Counter struct{ f *family }
+
+// NewCounter creates and registers a counter with the given labels.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{newFamily(name, help, "counter", labels)}
+}
+
+// Add adds v to the counter with the given label values.
+func (c *Counter) Add(v float64, labelValues ...string) { c.f.add(v, labelValues) }
+
+// Inc increments the counter with the given label values.
+func (c *Counter) Inc(labelValues ...string) { c.f.add(1, labelValues) }
+
+// Value returns the value of the counter with the given label values.
+func (c *Counter) Value(labelValues ...string) float64 { return c.f.get(labelValues) }
+
+// Gauge is a metric which can be set to any value.
+type Gauge struct{ f *family }
+
+// NewGauge creates and registers a gauge with the given labels.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{newFamily(name, help, "gauge", labels)}
+}
+
+// Set sets the gauge with the given label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) { g.f.set(v, labelValues) }
+
+// Value returns the value of the gauge with the given label values.
+func (g *Gauge) Value(labelValues ...string) float64 { return g.f.get(labelValues) }
+
+// ObserveRun records the duration and result of a run which started at start.
+func ObserveRun(start time.Time, err error) {
+	now := time.Now()
+	RunDuration.Set(now.Sub(start).Seconds())
+	if err != nil {
+		Runs.Inc("failure")
+		return
+	}
+
+	Runs.Inc("success")
+	LastSuccess.Set(float64(now.Unix()))
+}
+
+// Write writes all metrics with a value in the Prometheus text format.
+func Write(w io.Writer) error {
+	registry.mu.Lock()
+	families := append([]*family(nil), registry.families...)
+	registry.mu.Unlock()
+
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler serves the metrics, e.g. on /metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		Write(w)
+	})
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestWrite(t *testing.T) {
+	c := NewCounter("test_write_total", "Test counter.", "region", "type")
+	c.Add(2, "eu-west-1", "ec2")
+	c.Inc("eu-west-1", "ec2")
+	c.Inc("us-east-1", `quoted "type"`)
+	// only backslashes, double quotes and line feeds are escaped, the tab and é are written as they are
+	c.Inc("eu-west-1", "back\\slash\nnew line\ttab é")
+	g := NewGauge("test_write_seconds", "Test gauge.")
+	g.Set(1.5)
+	NewGauge("test_write_unset", "Never set.")
+
+	var b bytes.Buffer
+	if err := Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP test_write_total Test counter.
+# TYPE test_write_total counter
+test_write_total{region="eu-west-1",type="back\\slash\nnew line	tab é"} 1
+test_write_total{region="eu-west-1",type="ec2"} 3
+test_write_total{region="us-east-1",type="quoted \"type\""} 1
+# HELP test_write_seconds Test gauge.
+# TYPE test_write_seconds gauge
+test_write_seconds 1.5
+`
+	if !bytes.Contains(b.Bytes(), []byte(want)) {
+		t.Errorf("expected metrics to contain\n%s\ngot\n%s", want, b.String())
+	}
+	if bytes.Contains(b.Bytes(), []byte("test_write_unset")) {
+		t.Errorf("expected metrics without values to be omitted, got\n%s", b.String())
+	}
+}
+
+func TestObserveRun(t *testing.T) {
+	failures := Runs.Value("failure")
+	ObserveRun(time.Now(), errors.New("failed"))
+	if Runs.Value("failure") != failures+1 {
+		t.Errorf("expected a failed run to be counted")
+	}
+
+	ObserveRun(time.Now().Add(-time.Minute), nil)
+	if d := RunDuration.Value(); d < 60 {
+		t.Errorf("expected a run duration of at least 60s, got %v", d)
+	}
+	if ts := LastSuccess.Value(); time.Since(time.Unix(int64(ts), 0)) > time.Minute {
+		t.Errorf("expected the last success to be now, got %v", ts)
+	}
+}
+
+func TestPush(t *testing.T) {
+	var method, path, contentType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, contentType = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	NewCounter("test_push_total", "Test counter.").Inc()
+	if err := Push(server.URL+"/", DefaultJob, map[string]string{"instance": "ci/1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if method != http.MethodPut || path != "/metrics/job/awsweeper/instance/ci%2F1" || contentType != ContentType {
+		t.Errorf("unexpected request %s %s (%s)", method, path, contentType)
+	}
+	if !bytes.Contains(body, []byte("test_push_total 1\n")) {
+		t.Errorf("expected the metrics to be pushed, got\n%s", body)
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid metric", http.StatusBadRequest)
+	})
+	if err := Push(server.URL, DefaultJob, nil); err == nil {
+		t.Error("expected an error for a failed push")
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		MaxRetries:  aws.Int(1),
+	}))
+	Instrument(&sess.Handlers)
+
+	// the first attempt is throttled, the retry succeeds
+	attempts := 0
+	sess.Handlers.Send.Clear()
+	sess.Handlers.UnmarshalMeta.Clear()
+	sess.Handlers.Unmarshal.Clear()
+	sess.Handlers.UnmarshalError.Clear()
+	sess.Handlers.ValidateResponse.Clear()
+	sess.Handlers.Send.PushBack(func(r *request.Request) {
+		attempts++
+		r.HTTPResponse = &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(&bytes.Buffer{})}
+		if attempts == 1 {
+			r.HTTPResponse.StatusCode = http.StatusBadRequest
+			r.Error = awserr.New("ThrottlingException", "Rate exceeded", nil)
+		}
+	})
+	sess.Handlers.Retry.PushFront(func(r *request.Request) { r.RetryDelay = 0 })
+
+	calls, throttles := APICalls.Value("dynamodb"), APIThrottles.Value("dynamodb")
+	if _, err := dynamodb.New(sess).ListTables(&dynamodb.ListTablesInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := APICalls.Value("dynamodb") - calls; got != 2 {
+		t.Errorf("expected 2 calls, got %v", got)
+	}
+	if got := APIThrottles.Value("dynamodb") - throttles; got != 1 {
+		t.Errorf("expected 1 throttle, got %v", got)
+	}
+}
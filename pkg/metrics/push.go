@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultJob is the job the metrics are pushed as unless configured otherwise.
+const DefaultJob = "awsweeper"
+
+// Push replaces the metrics of the job (and grouping labels, e.g. instance) on a Prometheus Pushgateway with the
+// current metrics.
+func Push(gateway, job string, grouping map[string]string) error {
+	var body bytes.Buffer
+	if err := Write(&body); err != nil {
+		return err
+	}
+
+	u := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	for _, k := range sortedKeys(grouping) {
+		u += "/" + url.PathEscape(k) + "/" + url.PathEscape(grouping[k])
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
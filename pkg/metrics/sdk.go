@@ -0,0 +1,18 @@
+package metrics
+
+import (
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Instrument counts the requests sent with the handlers (e.g. of a session) and the ones that were throttled.
+func Instrument(h *request.Handlers) {
+	h.CompleteAttempt.PushBackNamed(request.NamedHandler{
+		Name: "awsweeper.metrics",
+		Fn: func(r *request.Request) {
+			APICalls.Inc(r.ClientInfo.ServiceName)
+			if r.Error != nil && request.IsErrorThrottle(r.Error) {
+				APIThrottles.Inc(r.ClientInfo.ServiceName)
+			}
+		},
+	})
+}
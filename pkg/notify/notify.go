@@ -0,0 +1,206 @@
+// Package notify tells the owners of resources and the team running sweeps what is about to be deleted and what a run
+// did, through webhooks, Slack, email and SNS.
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/sirupsen/logrus"
+)
+
+// Events notifications are sent for.
+const (
+	EventWarning = config.NotifyWarning
+	EventSummary = config.NotifySummary
+)
+
+// DefaultOwnerTag is the tag resources are grouped by unless configured otherwise.
+const DefaultOwnerTag = "owner"
+
+// Owner is a group of resources with the same value of the owner tag, empty for resources without it.
+type Owner struct {
+	Owner     string           `json:"owner"`
+	Resources []inventory.Item `json:"resources"`
+}
+
+// Notification is the warning or summary of a run.
+type Notification struct {
+	Event    string `json:"event"`
+	RunID    string `json:"run_id,omitempty"`
+	Account  string `json:"account,omitempty"`
+	DryRun   bool   `json:"dry_run"`
+	OwnerTag string `json:"owner_tag"`
+	// Owners are the resources selected for deletion (in warnings) or deleted (in summaries), grouped by owner.
+	Owners []Owner `json:"owners"`
+	// Failed, Warnings and Error are set on summaries.
+	Failed   int      `json:"failed,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Group groups items by the value of the owner tag. Owners are sorted by name, resources without owner come last.
+func Group(items []inventory.Item, ownerTag string) []Owner {
+	byOwner := make(map[string][]inventory.Item)
+	for _, i := range items {
+		owner := i.Tags[ownerTag]
+		byOwner[owner] = append(byOwner[owner], i)
+	}
+
+	owners := make([]Owner, 0, len(byOwner))
+	for owner, resources := range byOwner {
+		inventory.Sort(resources, "")
+		owners = append(owners, Owner{Owner: owner, Resources: resources})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if (owners[i].Owner == "") != (owners[j].Owner == "") {
+			return owners[j].Owner == ""
+		}
+		return owners[i].Owner < owners[j].Owner
+	})
+
+	return owners
+}
+
+// Count returns the number of resources of all owners.
+func (n *Notification) Count() int {
+	count := 0
+	for _, o := range n.Owners {
+		count += len(o.Resources)
+	}
+	return count
+}
+
+// Subject returns a one line description of the notification.
+func (n *Notification) Subject() string {
+	account := ""
+	if n.Account != "" {
+		account = " in account " + n.Account
+	}
+
+	switch {
+	case n.Event == EventWarning && n.DryRun:
+		return fmt.Sprintf("awsweeper: %d resources%s are scheduled for deletion", n.Count(), account)
+	case n.Event == EventWarning:
+		return fmt.Sprintf("awsweeper: %d resources%s are about to be deleted", n.Count(), account)
+	case n.DryRun:
+		return fmt.Sprintf("awsweeper: dry run%s would delete %d resources", account, n.Count())
+	case n.Error != "":
+		return fmt.Sprintf("awsweeper: run%s failed after deleting %d resources", account, n.Count())
+	default:
+		return fmt.Sprintf("awsweeper: run%s deleted %d resources, %d failed", account, n.Count(), n.Failed)
+	}
+}
+
+// Text returns the plain text of the notification: its subject followed by the resources of each owner and the
+// problems of the run.
+func (n *Notification) Text() string {
+	var b strings.Builder
+	b.WriteString(n.Subject())
+	b.WriteString("\n")
+
+	for _, o := range n.Owners {
+		owner := o.Owner
+		if owner == "" {
+			owner = "no " + n.OwnerTag + " tag"
+		}
+		fmt.Fprintf(&b, "\n%s (%d):\n", owner, len(o.Resources))
+		for _, r := range o.Resources {
+			fmt.Fprintf(&b, "  - %s %s %s", r.Region, r.ResourceType, r.ID)
+			if r.Name != "" && r.Name != r.ID {
+				fmt.Fprintf(&b, " (%s)", r.Name)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if n.Error != "" {
+		fmt.Fprintf(&b, "\nError: %s\n", n.Error)
+	}
+	if len(n.Warnings) > 0 {
+		b.WriteString("\nWarnings:\n")
+		for _, w := range n.Warnings {
+			fmt.Fprintf(&b, "  - %s\n", w)
+		}
+	}
+
+	if n.RunID != "" {
+		fmt.Fprintf(&b, "\nRun %s\n", n.RunID)
+	}
+
+	return b.String()
+}
+
+// Sink delivers notifications.
+type Sink interface {
+	Send(n *Notification) error
+}
+
+// Notifier sends notifications of the enabled events to sinks. All methods of a nil Notifier are no-ops, so that
+// notifications can be disabled.
+type Notifier struct {
+	Sinks []Sink
+	// Events are the events notifications are sent for, all if empty.
+	Events []string
+	// OwnerTag is the tag resources are grouped by, DefaultOwnerTag if empty.
+	OwnerTag string
+}
+
+func (n *Notifier) ownerTag() string {
+	if n.OwnerTag == "" {
+		return DefaultOwnerTag
+	}
+	return n.OwnerTag
+}
+
+// Group groups items by the value of the owner tag of the notifier.
+func (n *Notifier) Group(items []inventory.Item) []Owner {
+	return Group(items, n.ownerTag())
+}
+
+// Enabled returns whether notifications are sent for the event.
+func (n *Notifier) Enabled(event string) bool {
+	if n == nil || len(n.Sinks) == 0 {
+		return false
+	}
+	if len(n.Events) == 0 {
+		return true
+	}
+
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Send sends the notification to all sinks if its event is enabled. Sinks which fail are logged, the first error is
+// returned.
+func (n *Notifier) Send(notification *Notification) error {
+	if !n.Enabled(notification.Event) {
+		return nil
+	}
+
+	if notification.OwnerTag == "" {
+		notification.OwnerTag = n.ownerTag()
+	}
+
+	var first error
+	for _, s := range n.Sinks {
+		if err := s.Send(notification); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"event": notification.Event,
+				"sink":  fmt.Sprintf("%T", s),
+			}).Error("Failed to send notification")
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	return first
+}
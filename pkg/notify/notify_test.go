@@ -0,0 +1,276 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+)
+
+func warning() *Notification {
+	items := []inventory.Item{
+		{Region: "eu-west-1", ResourceType: "ec2", ID: "i-2", Tags: map[string]string{"owner": "bob@example.com"}},
+		{Region: "eu-west-1", ResourceType: "s3_bucket", ID: "logs"},
+		{Region: "eu-west-1", ResourceType: "ec2", ID: "i-1", Name: "web", Tags: map[string]string{"owner": "alice@example.com"}},
+		{Region: "us-east-1", ResourceType: "ec2", ID: "i-3", Tags: map[string]string{"owner": "alice@example.com"}},
+	}
+
+	return &Notification{
+		Event:    EventWarning,
+		RunID:    "run",
+		Account:  "123456789012",
+		OwnerTag: DefaultOwnerTag,
+		Owners:   Group(items, DefaultOwnerTag),
+	}
+}
+
+func TestGroup(t *testing.T) {
+	n := warning()
+
+	var owners []string
+	for _, o := range n.Owners {
+		owners = append(owners, o.Owner)
+	}
+	if strings.Join(owners, ",") != "alice@example.com,bob@example.com," {
+		t.Errorf("expected owners sorted with resources without owner last, got %q", owners)
+	}
+	if len(n.Owners[0].Resources) != 2 || n.Count() != 4 {
+		t.Errorf("unexpected groups %+v", n.Owners)
+	}
+
+	want := `awsweeper: 4 resources in account 123456789012 are about to be deleted
+
+alice@example.com (2):
+  - eu-west-1 ec2 i-1 (web)
+  - us-east-1 ec2 i-3
+
+bob@example.com (1):
+  - eu-west-1 ec2 i-2
+
+no owner tag (1):
+  - eu-west-1 s3_bucket logs
+
+Run run
+`
+	if text := n.Text(); text != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, text)
+	}
+}
+
+func TestNotifier_Events(t *testing.T) {
+	var sent []string
+	sink := sinkFunc(func(n *Notification) error {
+		sent = append(sent, n.Event)
+		return nil
+	})
+
+	var disabled *Notifier
+	if disabled.Enabled(EventWarning) || disabled.Send(warning()) != nil {
+		t.Error("expected a nil notifier to be disabled")
+	}
+
+	notifier := &Notifier{Sinks: []Sink{sink}, Events: []string{EventSummary}}
+	notifier.Send(warning())
+	notifier.Send(&Notification{Event: EventSummary})
+	if strings.Join(sent, ",") != EventSummary {
+		t.Errorf("expected only the summary to be sent, got %v", sent)
+	}
+}
+
+type sinkFunc func(n *Notification) error
+
+func (f sinkFunc) Send(n *Notification) error { return f(n) }
+
+func TestWebhookSink(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookSink(server.URL).Send(warning()); err != nil {
+		t.Fatal(err)
+	}
+
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.Event != EventWarning || len(n.Owners) != 3 || n.Owners[0].Resources[0].ID != "i-1" {
+		t.Errorf("unexpected notification %s", body)
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	if err := NewWebhookSink(server.URL).Send(warning()); err == nil || !strings.Contains(err.Error(), "410") {
+		t.Errorf("expected an error for a failed request, got %v", err)
+	}
+}
+
+func TestSlackSink(t *testing.T) {
+	var message map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&message)
+	}))
+	defer server.Close()
+
+	if err := NewSlackSink(server.URL).Send(warning()); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := message["text"]; !strings.HasPrefix(text, "*awsweeper: 4 resources") || !strings.Contains(text, "```alice@example.com (2):") {
+		t.Errorf("unexpected message %q", text)
+	}
+}
+
+// mail is a message received by the SMTP stand-in.
+type mail struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer accepts mails on a local port until it is closed, without authentication.
+func smtpServer(t *testing.T) (addr string, mails chan mail, close func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mails = make(chan mail, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			serveSMTP(conn, mails)
+		}
+	}()
+
+	return l.Addr().String(), mails, func() { l.Close() }
+}
+
+func serveSMTP(conn net.Conn, mails chan mail) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	var m mail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			m = mail{from: strings.Trim(strings.TrimSpace(line)[10:], "<>")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			m.to = append(m.to, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			m.data = data.String()
+			mails <- m
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPSink(t *testing.T) {
+	addr, mails, closeServer := smtpServer(t)
+	defer closeServer()
+
+	sink := &SMTPSink{Addr: addr, From: "awsweeper@example.com", To: []string{"team@example.com"}, NotifyOwners: true}
+	if err := sink.Send(warning()); err != nil {
+		t.Fatal(err)
+	}
+
+	team := <-mails
+	if team.from != "awsweeper@example.com" || strings.Join(team.to, ",") != "team@example.com" ||
+		!strings.Contains(team.data, "Subject: awsweeper: 4 resources in account 123456789012 are about to be deleted\r\n") ||
+		!strings.Contains(team.data, "  - eu-west-1 s3_bucket logs\r\n") {
+		t.Errorf("unexpected mail to the team %+v", team)
+	}
+
+	for _, owner := range []string{"alice@example.com", "bob@example.com"} {
+		m := <-mails
+		if strings.Join(m.to, ",") != owner || strings.Contains(m.data, "s3_bucket") {
+			t.Errorf("expected a mail to %s with only their resources, got %+v", owner, m)
+		}
+	}
+
+	// summaries are sent to the team only
+	if err := sink.Send(&Notification{Event: EventSummary, Owners: warning().Owners}); err != nil {
+		t.Fatal(err)
+	}
+	if m := <-mails; !strings.Contains(m.data, "Subject: awsweeper: run deleted 4 resources, 0 failed") {
+		t.Errorf("unexpected summary %+v", m)
+	}
+	select {
+	case m := <-mails:
+		t.Errorf("unexpected mail %+v", m)
+	default:
+	}
+}
+
+type fakeSNS struct {
+	snsiface.SNSAPI
+	published []*sns.PublishInput
+}
+
+func (f *fakeSNS) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	f.published = append(f.published, input)
+	return &sns.PublishOutput{MessageId: aws.String("id")}, nil
+}
+
+func TestSNSSink(t *testing.T) {
+	api := &fakeSNS{}
+	n := warning()
+	n.Account = strings.Repeat("1", 100)
+	sink := &SNSSink{API: api, TopicARN: "arn:aws:sns:eu-west-1:123456789012:sweeps"}
+	if err := sink.Send(n); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(api.published) != 1 {
+		t.Fatalf("expected one message, got %d", len(api.published))
+	}
+	p := api.published[0]
+	if aws.StringValue(p.TopicArn) != sink.TopicARN || len(aws.StringValue(p.Subject)) != snsSubjectLength ||
+		aws.StringValue(p.Message) != n.Text() || aws.StringValue(p.MessageAttributes["event"].StringValue) != EventWarning {
+		t.Errorf("unexpected message %v", p)
+	}
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// post posts a JSON body to a URL.
+func post(client *http.Client, url string, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// newClient returns the HTTP client of webhooks.
+func newClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// WebhookSink posts notifications as JSON to a URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookSink returns a sink posting to the URL.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url, Client: newClient()}
+}
+
+// Send posts the notification.
+func (s *WebhookSink) Send(n *Notification) error {
+	return post(s.Client, s.URL, n)
+}
+
+// SlackSink posts notifications as text to a Slack-compatible incoming webhook.
+type SlackSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewSlackSink returns a sink posting to the incoming webhook URL.
+func NewSlackSink(url string) *SlackSink {
+	return &SlackSink{URL: url, Client: newClient()}
+}
+
+// Send posts the text of the notification, with the resources as preformatted text.
+func (s *SlackSink) Send(n *Notification) error {
+	text := n.Text()
+	if i := strings.Index(text, "\n"); i >= 0 && strings.TrimSpace(text[i:]) != "" {
+		text = "*" + text[:i] + "*\n```" + strings.TrimSpace(text[i:]) + "```"
+	}
+
+	return post(s.Client, s.URL, map[string]string{"text": text})
+}
+
+// SMTPSink sends notifications by email.
+type SMTPSink struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	From string
+	To   []string
+	// Username and Password authenticate with PLAIN authentication, if set.
+	Username string
+	Password string
+	// NotifyOwners additionally sends warnings to each owner which is an email address, listing only their resources.
+	NotifyOwners bool
+}
+
+// Send sends the notification to the recipients and, for warnings, the resources of each owner to the owner.
+func (s *SMTPSink) Send(n *Notification) error {
+	if len(s.To) > 0 {
+		if err := s.send(s.To, n.Subject(), n.Text()); err != nil {
+			return err
+		}
+	}
+
+	if !s.NotifyOwners || n.Event != EventWarning {
+		return nil
+	}
+
+	for _, o := range n.Owners {
+		if !strings.Contains(o.Owner, "@") {
+			continue
+		}
+
+		owned := *n
+		owned.Owners = []Owner{o}
+		if err := s.send([]string{o.Owner}, owned.Subject(), owned.Text()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SMTPSink) send(to []string, subject, body string) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	return smtp.SendMail(s.Addr, auth, s.From, to, msg.Bytes())
+}
+
+// SNSSink publishes notifications to an SNS topic.
+type SNSSink struct {
+	API      snsiface.SNSAPI
+	TopicARN string
+}
+
+// snsSubjectLength is the maximum length of the subject of SNS messages.
+const snsSubjectLength = 100
+
+// Send publishes the text of the notification with its subject.
+func (s *SNSSink) Send(n *Notification) error {
+	subject := n.Subject()
+	if len(subject) > snsSubjectLength {
+		subject = subject[:snsSubjectLength]
+	}
+
+	_, err := s.API.Publish(&sns.PublishInput{
+		TopicArn: aws.String(s.TopicARN),
+		Subject:  aws.String(subject),
+		Message:  aws.String(n.Text()),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{
+			"event": {DataType: aws.String("String"), StringValue: aws.String(n.Event)},
+		},
+	})
+	return err
+}
@@ -0,0 +1,503 @@
+package serve
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// Statuses of a plan.
+const (
+	// PlanPlanning is the status while the dry run finding the resources to delete is in progress.
+	PlanPlanning = "planning"
+	// PlanPlanned is the status of a plan waiting for approval.
+	PlanPlanned  = "planned"
+	PlanApproved = "approved"
+	PlanApplying = "applying"
+	PlanApplied  = "applied"
+	// PlanFailed is the status of a plan which failed to be planned or applied.
+	PlanFailed = "failed"
+)
+
+// DefaultMaxPlans is the number of plans kept by the API.
+const DefaultMaxPlans = 100
+
+// maxConfigSize limits the size of submitted configs.
+const maxConfigSize = 1 << 20
+
+// Plan is what a sweep of a submitted config would delete, found by a dry run. Once approved, applying the plan
+// deletes these resources only, not resources matched by the config since.
+type Plan struct {
+	// ID is the ID of the run of the dry run.
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	Created    time.Time `json:"created"`
+	ConfigHash string    `json:"config_hash"`
+	// Resources are the resources to delete.
+	Resources []inventory.Item `json:"resources"`
+	Warnings  []string         `json:"warnings,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	// Report is the ID of the report of applying the plan.
+	Report string `json:"report,omitempty"`
+
+	cfg    *config.Config
+	events []Event
+	// changed is closed and replaced whenever an event is added or the status changes, to wake up the streams of
+	// events
+	changed chan struct{}
+}
+
+// done returns whether nothing is in progress for the plan, so that no more events are added until it is applied.
+func (p *Plan) done() bool {
+	return p.Status != PlanPlanning && p.Status != PlanApplying
+}
+
+// Event is a log event of planning or applying a plan, streamed to follow the progress.
+type Event struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// API lets clients submit configs, review and approve the resources they would delete and apply them. Requests are
+// authenticated by the tokens of the current config of the server, which enables the API.
+//
+//	POST /api/plans                plans the config in the body, returns 202 and the plan
+//	GET  /api/plans                lists the plans, the latest first
+//	GET  /api/plans/<id>           returns a plan
+//	POST /api/plans/<id>/approve   approves a planned plan
+//	POST /api/plans/<id>/apply     applies an approved plan, returns 202 and the plan
+//	GET  /api/plans/<id>/events    streams the events of the plan as JSON lines until nothing is in progress
+//	GET  /api/reports              lists the reports of the jobs and applied plans
+//	GET  /api/reports/<id>         returns a report
+type API struct {
+	Server *Server
+	// Sweep runs a wipe of the config, restricted to the resources with the given inventory keys unless nil, in
+	// the run of the logging context and returns its report.
+	Sweep func(cfg *config.Config, only map[string]bool) *Report
+	// MaxPlans is the number of plans kept, DefaultMaxPlans if 0.
+	MaxPlans int
+
+	mu sync.Mutex
+	// plans are sorted by creation, oldest first
+	plans []*Plan
+	// runs are the plans by the IDs of the runs planning and applying them, to collect their events
+	runs map[string]*Plan
+}
+
+// NewAPI returns the API of a server and collects the log events of its runs.
+func NewAPI(s *Server, sweep func(cfg *config.Config, only map[string]bool) *Report) *API {
+	a := &API{Server: s, Sweep: sweep, runs: make(map[string]*Plan)}
+	logrus.AddHook(a)
+	return a
+}
+
+// Levels returns all levels, the events are filtered by the level of the logs.
+func (a *API) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire adds log events of runs planning or applying a plan to the plan.
+func (a *API) Fire(e *logrus.Entry) error {
+	runID, ok := e.Data[logging.RunID].(string)
+	if !ok {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p := a.runs[runID]
+	if p == nil {
+		return nil
+	}
+
+	event := Event{Time: e.Time, Level: e.Level.String(), Message: e.Message}
+	for k, v := range e.Data {
+		if k == logging.RunID {
+			continue
+		}
+		if event.Fields == nil {
+			event.Fields = make(map[string]string)
+		}
+		event.Fields[k] = fmt.Sprint(v)
+	}
+
+	p.events = append(p.events, event)
+	a.changed(p)
+	return nil
+}
+
+// changed wakes up the streams of events of a plan.
+// The caller must hold the lock.
+func (a *API) changed(p *Plan) {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// enabled returns the config of the API, nil if the current config of the server doesn't enable it.
+func (a *API) enabled() *config.API {
+	cfg := a.Server.Config()
+	if cfg == nil || cfg.Serve == nil {
+		return nil
+	}
+	return cfg.Serve.API
+}
+
+// authorized returns whether the request has one of the tokens as bearer token.
+func authorized(r *http.Request, tokens []string) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+
+	given := []byte(strings.TrimPrefix(auth, "Bearer "))
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare(given, []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api := a.enabled()
+	if api == nil {
+		writeError(w, http.StatusNotFound, "the API is not enabled")
+		return
+	}
+	if !authorized(r, api.Tokens) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "a valid bearer token is required")
+		return
+	}
+
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/")
+	switch {
+	case path[0] == "plans" && len(path) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			a.listPlans(w, r)
+		case http.MethodPost:
+			a.createPlan(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	case path[0] == "plans" && len(path) == 2:
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		a.getPlan(w, path[1])
+	case path[0] == "plans" && len(path) == 3 && (path[2] == "approve" || path[2] == "apply"):
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		if path[2] == "approve" {
+			a.approve(w, path[1])
+		} else {
+			a.apply(w, path[1])
+		}
+	case path[0] == "plans" && len(path) == 3 && path[2] == "events":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		a.streamEvents(w, r, path[1])
+	case path[0] == "reports" && len(path) == 1:
+		a.Server.listReports(w, r)
+	case path[0] == "reports" && len(path) == 2:
+		a.Server.getReport(w, path[1])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// createPlan plans the filters submitted in the body of the request with the served config, so that its credentials,
+// audit log and notifications apply to deletions through the API as to those of the jobs.
+func (a *API) createPlan(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	served := a.Server.Config()
+	if served == nil {
+		writeError(w, http.StatusServiceUnavailable, "no config is loaded")
+		return
+	}
+
+	cfg, err := config.Submitted(served, data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := cfg.Hash()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p := &Plan{
+		ID:         logging.NewRunID(),
+		Status:     PlanPlanning,
+		Created:    time.Now(),
+		ConfigHash: hash,
+		cfg:        cfg,
+		changed:    make(chan struct{}),
+	}
+
+	a.mu.Lock()
+	a.runs[p.ID] = p
+	a.mu.Unlock()
+
+	if !a.Server.exclusive("plan "+p.ID, func() { a.plan(p) }) {
+		a.mu.Lock()
+		delete(a.runs, p.ID)
+		a.mu.Unlock()
+		writeError(w, http.StatusConflict, "another run is in progress")
+		return
+	}
+
+	a.mu.Lock()
+	a.plans = append(a.plans, p)
+	a.prune()
+	plan := *p
+	a.mu.Unlock()
+
+	writeJSON(w, http.StatusAccepted, &plan)
+}
+
+// prune removes the oldest plans beyond the maximum, unless in progress.
+// The caller must hold the lock.
+func (a *API) prune() {
+	max := a.MaxPlans
+	if max == 0 {
+		max = DefaultMaxPlans
+	}
+
+	var kept []*Plan
+	excess := len(a.plans) - max
+	for _, p := range a.plans {
+		if excess > 0 && p.done() {
+			excess--
+			continue
+		}
+		kept = append(kept, p)
+	}
+	a.plans = kept
+}
+
+// plan finds the resources the config of a plan would delete with a dry run.
+func (a *API) plan(p *Plan) {
+	defer logging.Push(logrus.Fields{logging.RunID: p.ID})()
+	logrus.Info("Planning sweep")
+
+	// warnings and summaries are sent when the plan is applied
+	cfg := *p.cfg
+	cfg.Options.DryRun = true
+	cfg.Notify = nil
+	report := a.Sweep(&cfg, nil)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.runs, p.ID)
+	p.Resources, p.Warnings, p.Error = report.Resources, report.Warnings, report.Error
+	p.Status = PlanPlanned
+	if report.Error != "" {
+		p.Status = PlanFailed
+	}
+	a.changed(p)
+}
+
+// approve approves a plan, so that it can be applied.
+func (a *API) approve(w http.ResponseWriter, id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p := a.get(id)
+	if p == nil {
+		writeError(w, http.StatusNotFound, "plan not found")
+		return
+	}
+	if p.Status != PlanPlanned {
+		writeError(w, http.StatusConflict, fmt.Sprintf("only planned plans can be approved, the plan is %s", p.Status))
+		return
+	}
+
+	p.Status = PlanApproved
+	a.changed(p)
+	writeJSON(w, http.StatusOK, p)
+}
+
+// apply deletes the resources of an approved plan in the background.
+func (a *API) apply(w http.ResponseWriter, id string) {
+	a.mu.Lock()
+	p := a.get(id)
+	if p == nil {
+		a.mu.Unlock()
+		writeError(w, http.StatusNotFound, "plan not found")
+		return
+	}
+	if p.Status != PlanApproved {
+		a.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("only approved plans can be applied, the plan is %s", p.Status))
+		return
+	}
+
+	// the status is set before starting the run, so that a plan can't be applied twice
+	runID := logging.NewRunID()
+	p.Status, p.Report = PlanApplying, runID
+	a.runs[runID] = p
+	a.changed(p)
+	a.mu.Unlock()
+
+	if !a.Server.exclusive("apply "+p.ID, func() { a.applyPlan(p, runID) }) {
+		a.mu.Lock()
+		p.Status, p.Report = PlanApproved, ""
+		delete(a.runs, runID)
+		a.changed(p)
+		a.mu.Unlock()
+		writeError(w, http.StatusConflict, "another run is in progress")
+		return
+	}
+
+	a.mu.Lock()
+	plan := *p
+	a.mu.Unlock()
+	writeJSON(w, http.StatusAccepted, &plan)
+}
+
+// applyPlan deletes the resources of a plan in a run of its own and keeps its report.
+func (a *API) applyPlan(p *Plan, runID string) {
+	defer logging.Push(logrus.Fields{logging.RunID: runID, "plan": p.ID})()
+	logrus.Info("Applying plan")
+
+	only := make(map[string]bool, len(p.Resources))
+	for _, i := range p.Resources {
+		only[i.Key()] = true
+	}
+
+	cfg := *p.cfg
+	cfg.Options.DryRun = false
+	report := a.Sweep(&cfg, only)
+	report.Plan = p.ID
+	if err := a.Server.Reports.Add(report); err != nil {
+		logrus.WithError(err).Error("Failed to save report")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.runs, runID)
+	p.Status = PlanApplied
+	if report.Error != "" {
+		p.Status = PlanFailed
+		p.Error = report.Error
+	}
+	a.changed(p)
+}
+
+// get returns a plan, nil if it is not kept.
+// The caller must hold the lock.
+func (a *API) get(id string) *Plan {
+	for _, p := range a.plans {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (a *API) listPlans(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	plans := make([]Plan, len(a.plans))
+	for i, p := range a.plans {
+		plans[i] = *p
+	}
+	a.mu.Unlock()
+
+	sort.SliceStable(plans, func(i, j int) bool { return plans[i].Created.After(plans[j].Created) })
+	writeJSON(w, http.StatusOK, plans)
+}
+
+func (a *API) getPlan(w http.ResponseWriter, id string) {
+	a.mu.Lock()
+	p := a.get(id)
+	var plan Plan
+	if p != nil {
+		plan = *p
+	}
+	a.mu.Unlock()
+
+	if p == nil {
+		writeError(w, http.StatusNotFound, "plan not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, &plan)
+}
+
+// streamEvents writes the events of a plan as JSON lines as they happen, until nothing is in progress for the plan
+// or the client goes away.
+func (a *API) streamEvents(w http.ResponseWriter, r *http.Request, id string) {
+	a.mu.Lock()
+	p := a.get(id)
+	a.mu.Unlock()
+	if p == nil {
+		writeError(w, http.StatusNotFound, "plan not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	sent := 0
+	for {
+		a.mu.Lock()
+		events := p.events[sent:]
+		done := p.done()
+		changed := p.changed
+		a.mu.Unlock()
+
+		for _, e := range events {
+			if err := encoder.Encode(e); err != nil {
+				return
+			}
+		}
+		sent += len(events)
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if done {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
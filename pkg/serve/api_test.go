@@ -0,0 +1,218 @@
+package serve
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+const token = "0123456789abcdef"
+
+// apiServer returns a server with the API enabled, whose sweeps find a table and a bucket, and records the
+// configs and resources to delete of the sweeps.
+func apiServer(t *testing.T) (*Server, *httptest.Server, *[]map[string]bool) {
+	cfg := jobs(true)
+	cfg.Options.AuditLog = "audit.log"
+	cfg.Serve.API = &config.API{Tokens: []string{token}}
+
+	s := &Server{
+		Load:    func() (*config.Config, error) { return cfg, nil },
+		Reports: &Reports{},
+	}
+	if err := s.Reload(time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	var sweeps []map[string]bool
+	s.API = NewAPI(s, func(cfg *config.Config, only map[string]bool) *Report {
+		if cfg.Options.AuditLog != "audit.log" {
+			t.Errorf("expected the audit log of the served config, got %q", cfg.Options.AuditLog)
+		}
+		sweeps = append(sweeps, only)
+		logrus.WithField(logging.Type, "dynamodb_table").Info("Sweeping region")
+
+		report := &Report{ID: logging.Get(logging.RunID).(string), Command: config.JobWipe, DryRun: cfg.Options.DryRun}
+		for _, i := range []inventory.Item{
+			{Region: "eu-west-1", ResourceType: "dynamodb_table", ID: "table"},
+			{Region: "eu-west-1", ResourceType: "s3_bucket", ID: "bucket"},
+		} {
+			if only == nil || only[i.Key()] {
+				report.Resources = append(report.Resources, i)
+			}
+		}
+		return report
+	})
+
+	server := httptest.NewServer(s.Handler())
+	return s, server, &sweeps
+}
+
+// call sends a request with the token to the API and decodes the response into v.
+func call(t *testing.T, method, url, body string, v interface{}) int {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		json.NewDecoder(resp.Body).Decode(v)
+	}
+	return resp.StatusCode
+}
+
+const submitted = `options:
+  regions: [eu-west-1]
+filters:
+  dynamodb_table:
+    - ids: ["^table$"]
+`
+
+func TestAPI_PlanAndApply(t *testing.T) {
+	s, server, sweeps := apiServer(t)
+	defer server.Close()
+
+	var plan Plan
+	if code := call(t, http.MethodPost, server.URL+"/api/plans", submitted, &plan); code != http.StatusAccepted || plan.Status != PlanPlanning {
+		t.Fatalf("unexpected response %d %+v", code, plan)
+	}
+	s.Wait()
+
+	if code := call(t, http.MethodGet, server.URL+"/api/plans/"+plan.ID, "", &plan); code != http.StatusOK ||
+		plan.Status != PlanPlanned || len(plan.Resources) != 2 || plan.ConfigHash == "" {
+		t.Fatalf("unexpected plan %d %+v", code, plan)
+	}
+	if (*sweeps)[0] != nil {
+		t.Error("expected planning to select all resources")
+	}
+
+	if code := call(t, http.MethodPost, server.URL+"/api/plans/"+plan.ID+"/apply", "", nil); code != http.StatusConflict {
+		t.Errorf("expected a plan to require approval, got %d", code)
+	}
+	if code := call(t, http.MethodPost, server.URL+"/api/plans/"+plan.ID+"/approve", "", &plan); code != http.StatusOK || plan.Status != PlanApproved {
+		t.Fatalf("unexpected approval %d %+v", code, plan)
+	}
+
+	// resources matched since planning are not deleted
+	s.API.mu.Lock()
+	s.API.get(plan.ID).Resources = plan.Resources[:1]
+	s.API.mu.Unlock()
+
+	if code := call(t, http.MethodPost, server.URL+"/api/plans/"+plan.ID+"/apply", "", &plan); code != http.StatusAccepted || plan.Status != PlanApplying {
+		t.Fatalf("unexpected response %d %+v", code, plan)
+	}
+	s.Wait()
+
+	call(t, http.MethodGet, server.URL+"/api/plans/"+plan.ID, "", &plan)
+	var report Report
+	if code := call(t, http.MethodGet, server.URL+"/api/reports/"+plan.Report, "", &report); code != http.StatusOK ||
+		plan.Status != PlanApplied || report.Plan != plan.ID || report.DryRun || len(report.Resources) != 1 {
+		t.Errorf("unexpected plan %+v and report %d %+v", plan, code, report)
+	}
+	if only := (*sweeps)[1]; len(only) != 1 || !only["/eu-west-1/dynamodb_table/table"] {
+		t.Errorf("expected only the planned table to be deleted, got %v", only)
+	}
+
+	if code := call(t, http.MethodPost, server.URL+"/api/plans/"+plan.ID+"/apply", "", nil); code != http.StatusConflict {
+		t.Errorf("expected a plan to be applied once, got %d", code)
+	}
+}
+
+func TestAPI_Events(t *testing.T) {
+	s, server, _ := apiServer(t)
+	defer server.Close()
+
+	var plan Plan
+	call(t, http.MethodPost, server.URL+"/api/plans", submitted, &plan)
+	s.Wait()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/plans/"+plan.ID+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var messages []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, e.Message)
+		if e.Message == "Sweeping region" && e.Fields[logging.Type] != "dynamodb_table" {
+			t.Errorf("expected the fields of the event, got %+v", e)
+		}
+	}
+
+	if strings.Join(messages, ",") != "Planning sweep,Sweeping region" {
+		t.Errorf("unexpected events %v", messages)
+	}
+}
+
+func TestAPI_Errors(t *testing.T) {
+	s, server, _ := apiServer(t)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/plans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected requests without token to be unauthorized, got %d", resp.StatusCode)
+	}
+
+	for _, body := range []string{
+		"options:\n  regions: []\n",
+		"include: [/etc/passwd]",
+		"options:\n  regions: [${AWS_REGION}]\n",
+		"options:\n  endpoints:\n    default: https://attacker.example.com\n",
+		"options:\n  snapshot-dir: /etc\n",
+		"terraform:\n  states: [/root/.aws/credentials]\n",
+		"accounts:\n  ids: [\"111111111111\"]\n",
+	} {
+		if code := call(t, http.MethodPost, server.URL+"/api/plans", body, nil); code != http.StatusBadRequest {
+			t.Errorf("expected %q to be rejected, got %d", body, code)
+		}
+	}
+
+	if code := call(t, http.MethodGet, server.URL+"/api/plans/unknown", "", nil); code != http.StatusNotFound {
+		t.Errorf("expected an unknown plan not to be found, got %d", code)
+	}
+	if code := call(t, http.MethodDelete, server.URL+"/api/plans", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("expected an unsupported method not to be allowed, got %d", code)
+	}
+
+	// sweeps don't overlap with jobs
+	release := make(chan struct{})
+	s.exclusive("job", func() { <-release })
+	if code := call(t, http.MethodPost, server.URL+"/api/plans", submitted, nil); code != http.StatusConflict {
+		t.Errorf("expected a conflict while a job runs, got %d", code)
+	}
+	close(release)
+	s.Wait()
+
+	s.Load = func() (*config.Config, error) { return jobs(false), nil }
+	s.Reload(time.Now())
+	if code := call(t, http.MethodGet, server.URL+"/api/plans", "", nil); code != http.StatusNotFound {
+		t.Errorf("expected the API to be disabled by the reloaded config, got %d", code)
+	}
+}
@@ -0,0 +1,152 @@
+package serve
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/spf13/afero"
+)
+
+// Report is the result of a run of a job or of applying a plan.
+type Report struct {
+	// ID is the ID of the run.
+	ID string `json:"id"`
+	// Job is the name of the job, empty for runs of the API.
+	Job string `json:"job,omitempty"`
+	// Plan is the ID of the plan applied by the run, if any.
+	Plan    string    `json:"plan,omitempty"`
+	Command string    `json:"command"`
+	DryRun  bool      `json:"dry_run"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	// Resources are the resources deleted (or stopped, started) by the run.
+	Resources []inventory.Item `json:"resources"`
+	Warnings  []string         `json:"warnings,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// Reports keeps the reports of the last runs in memory and, if Dir is set, as JSON files in a directory.
+type Reports struct {
+	Dir string
+	Max int
+
+	mu sync.Mutex
+	// reports are sorted by start, oldest first
+	reports []*Report
+}
+
+// NewReports returns the reports kept in dir, up to max.
+func NewReports(dir string, max int) (*Reports, error) {
+	r := &Reports{Dir: dir, Max: max}
+	if dir == "" {
+		return r, nil
+	}
+
+	files, err := afero.ReadDir(config.AppFs, dir)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+
+		data, err := afero.ReadFile(config.AppFs, filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var report Report
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, err
+		}
+		r.reports = append(r.reports, &report)
+	}
+
+	sort.SliceStable(r.reports, func(i, j int) bool { return r.reports[i].Start.Before(r.reports[j].Start) })
+	return r, r.prune()
+}
+
+// Add adds a report, removing the oldest ones beyond the maximum.
+func (r *Reports) Add(report *Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reports = append(r.reports, report)
+	if r.Dir != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err := config.AppFs.MkdirAll(r.Dir, 0755); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(config.AppFs, r.filename(report), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return r.prune()
+}
+
+// prune removes the oldest reports beyond the maximum.
+// The caller must hold the lock, if shared.
+func (r *Reports) prune() error {
+	if r.Max <= 0 || len(r.reports) <= r.Max {
+		return nil
+	}
+
+	removed := r.reports[:len(r.reports)-r.Max]
+	r.reports = append([]*Report(nil), r.reports[len(r.reports)-r.Max:]...)
+	if r.Dir == "" {
+		return nil
+	}
+
+	for _, report := range removed {
+		if err := config.AppFs.Remove(r.filename(report)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reports) filename(report *Report) string {
+	return filepath.Join(r.Dir, report.ID+".json")
+}
+
+// List returns the reports, the latest first.
+func (r *Reports) List() []*Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reports := make([]*Report, len(r.reports))
+	for i, report := range r.reports {
+		reports[len(r.reports)-1-i] = report
+	}
+	return reports
+}
+
+// Get returns the report of a run, nil if it is not kept.
+func (r *Reports) Get(id string) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, report := range r.reports {
+		if report.ID == id {
+			return report
+		}
+	}
+	return nil
+}
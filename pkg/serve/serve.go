@@ -0,0 +1,346 @@
+// Package serve runs the jobs of a config on their cron schedules, one at a time, reloads the config when it changes
+// and serves the health of the scheduler, the reports of the last runs and the metrics over HTTP.
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/cron"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultReloadInterval is how often the config is reloaded unless configured otherwise.
+const DefaultReloadInterval = 30 * time.Second
+
+// tickInterval is how often due jobs are checked for.
+const tickInterval = time.Second
+
+// Server schedules the jobs of a config.
+type Server struct {
+	// Load loads the config. It is called on start and periodically to pick up changes.
+	Load func() (*config.Config, error)
+	// Run runs a job with the config and returns its report.
+	Run func(cfg *config.Config, job config.Job) *Report
+	// Reports keeps the reports of the runs.
+	Reports *Reports
+	// ReloadInterval is how often the config is reloaded, DefaultReloadInterval if 0.
+	ReloadInterval time.Duration
+	// API is served on /api/ if set and enabled by the config.
+	API *API
+
+	mu   sync.Mutex
+	cfg  *config.Config
+	hash string
+	// next is when each job is due next, by name
+	next map[string]time.Time
+	// running is the name of the job being run, empty if none
+	running    string
+	reloadErr  error
+	reloadedAt time.Time
+	wg         sync.WaitGroup
+}
+
+// Config returns the current config.
+func (s *Server) Config() *config.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// Reload loads the config and, if it changed, schedules its jobs from now on. If the config can't be loaded, the
+// previous one is kept and the error is returned.
+func (s *Server) Reload(now time.Time) error {
+	cfg, err := s.Load()
+	if err == nil && cfg.Serve == nil {
+		err = errNoJobs
+	}
+
+	var hash string
+	if err == nil {
+		hash, err = cfg.Hash()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadErr = err
+	if err != nil {
+		metrics.ConfigReloads.Inc("failure")
+		return err
+	}
+	if hash == s.hash {
+		return nil
+	}
+
+	next := make(map[string]time.Time)
+	for _, job := range cfg.Serve.Jobs {
+		due, err := nextRun(job, now)
+		if err != nil {
+			s.reloadErr = err
+			metrics.ConfigReloads.Inc("failure")
+			return err
+		}
+		next[job.Name] = due
+	}
+
+	if s.cfg != nil {
+		logrus.WithField("config_hash", hash).Info("Reloaded changed config")
+		metrics.ConfigReloads.Inc("success")
+		if changed := restartRequired(s.cfg.Serve, cfg.Serve); len(changed) > 0 {
+			logrus.WithField("settings", changed).Warn("Changed serve settings require a restart. Using the previous ones meanwhile")
+		}
+	}
+	s.cfg, s.hash, s.next, s.reloadedAt = cfg, hash, next, now
+	return nil
+}
+
+type serveError string
+
+func (e serveError) Error() string { return string(e) }
+
+// errNoJobs is returned for configs without a serve section.
+const errNoJobs = serveError("the config has no serve section with jobs")
+
+// restartRequired returns the changed settings of the serve section which are only applied on start.
+func restartRequired(previous, current *config.Serve) []string {
+	var changed []string
+	if previous.Listen != current.Listen {
+		changed = append(changed, "listen")
+	}
+	if previous.Reports != current.Reports {
+		changed = append(changed, "reports")
+	}
+	if previous.ReportDir != current.ReportDir {
+		changed = append(changed, "report-dir")
+	}
+	return changed
+}
+
+// nextRun returns when a job is due after now.
+func nextRun(job config.Job, now time.Time) (time.Time, error) {
+	schedule, err := cron.Parse(job.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc, err := time.LoadLocation(job.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return schedule.Next(now.In(loc)), nil
+}
+
+// tick starts the jobs due at now. While a job runs, due jobs are skipped.
+func (s *Server) tick(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg == nil {
+		return
+	}
+
+	for _, job := range s.cfg.Serve.Jobs {
+		due := s.next[job.Name]
+		if due.IsZero() || now.Before(due) {
+			continue
+		}
+		s.next[job.Name], _ = nextRun(job, now)
+
+		if s.running != "" {
+			logrus.WithFields(logrus.Fields{
+				"job":     job.Name,
+				"running": s.running,
+			}).Warn("Previous run is still in progress. Skipping")
+			metrics.SkippedRuns.Inc(job.Name)
+			continue
+		}
+
+		s.start(s.cfg, job)
+	}
+}
+
+// start runs a job in the background.
+// The caller must hold the lock.
+func (s *Server) start(cfg *config.Config, job config.Job) {
+	s.goRun(job.Name, func() {
+		logrus.WithField("job", job.Name).Info("Running job")
+		report := s.Run(cfg, job)
+		if err := s.Reports.Add(report); err != nil {
+			logrus.WithError(err).WithField("job", job.Name).Error("Failed to save report")
+		}
+	})
+}
+
+// exclusive runs fn in the background unless a job or another run is in progress, in which case it returns false.
+// Runs of the API share it with the jobs, so that only one sweep runs at a time.
+func (s *Server) exclusive(name string, fn func()) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running != "" {
+		return false
+	}
+
+	s.goRun(name, fn)
+	return true
+}
+
+// goRun runs fn in the background as the running run.
+// The caller must hold the lock.
+func (s *Server) goRun(name string, fn func()) {
+	s.running = name
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		fn()
+
+		s.mu.Lock()
+		s.running = ""
+		s.mu.Unlock()
+	}()
+}
+
+// Wait waits for the running job, if any, to finish.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// Schedule loads the config and runs its jobs on their schedules until the context is done, reloading the config
+// periodically. It then waits for the running job to finish.
+func (s *Server) Schedule(ctx context.Context) error {
+	if err := s.Reload(time.Now()); err != nil {
+		return err
+	}
+
+	interval := s.ReloadInterval
+	if interval == 0 {
+		interval = DefaultReloadInterval
+	}
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	reload := time.NewTicker(interval)
+	defer reload.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.Wait()
+			return nil
+		case now := <-ticker.C:
+			s.tick(now)
+		case now := <-reload.C:
+			if err := s.Reload(now); err != nil {
+				logrus.WithError(err).Error("Failed to reload config. Keeping the previous one")
+			}
+		}
+	}
+}
+
+// Status is the health of the scheduler.
+type Status struct {
+	Status string `json:"status"`
+	// Running is the job being run, if any.
+	Running    string    `json:"running,omitempty"`
+	ConfigHash string    `json:"config_hash"`
+	ReloadedAt time.Time `json:"reloaded_at"`
+	// ReloadError is why the last reload failed, the previous config is used meanwhile.
+	ReloadError string      `json:"reload_error,omitempty"`
+	Jobs        []JobStatus `json:"jobs"`
+}
+
+// JobStatus is when a job is due next.
+type JobStatus struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Command  string    `json:"command"`
+	Next     time.Time `json:"next"`
+}
+
+// Status returns the health of the scheduler. It is degraded while the config can't be reloaded.
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{Status: "ok", Running: s.running, ConfigHash: s.hash, ReloadedAt: s.reloadedAt}
+	if s.reloadErr != nil {
+		status.Status = "degraded"
+		status.ReloadError = s.reloadErr.Error()
+	}
+
+	if s.cfg != nil {
+		for _, job := range s.cfg.Serve.Jobs {
+			status.Jobs = append(status.Jobs, JobStatus{
+				Name:     job.Name,
+				Schedule: job.Schedule,
+				Command:  job.Command,
+				Next:     s.next[job.Name],
+			})
+		}
+		sort.Slice(status.Jobs, func(i, j int) bool { return status.Jobs[i].Name < status.Jobs[j].Name })
+	}
+
+	return status
+}
+
+// Handler serves the health on /healthz, the reports on /reports and /reports/<id>, the metrics on /metrics and the
+// API on /api/.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	if s.API != nil {
+		mux.Handle("/api/", s.API)
+	}
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		status := s.Status()
+		code := http.StatusOK
+		if s.Config() == nil {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, status)
+	})
+	mux.HandleFunc("/reports", s.listReports)
+	mux.HandleFunc("/reports/", func(w http.ResponseWriter, r *http.Request) {
+		s.getReport(w, strings.TrimPrefix(r.URL.Path, "/reports/"))
+	})
+
+	return mux
+}
+
+func (s *Server) listReports(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Reports.List())
+}
+
+func (s *Server) getReport(w http.ResponseWriter, id string) {
+	report := s.Reports.Get(id)
+	if report == nil {
+		writeError(w, http.StatusNotFound, "report not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// writeJSON writes v as JSON response with the status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// writeError writes an error as JSON response with the status code.
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
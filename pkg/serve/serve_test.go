@@ -0,0 +1,204 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/spf13/afero"
+)
+
+func TestReports(t *testing.T) {
+	config.AppFs = afero.NewMemMapFs()
+	defer func() { config.AppFs = afero.NewOsFs() }()
+
+	start := time.Date(2020, 1, 15, 2, 0, 0, 0, time.UTC)
+	reports, err := NewReports("reports", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		if err := reports.Add(&Report{ID: id, Start: start.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if exists, _ := afero.Exists(config.AppFs, "reports/a.json"); exists || reports.Get("a") != nil {
+		t.Error("expected the oldest report to be removed")
+	}
+
+	// reports survive restarts
+	reports, err = NewReports("reports", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := reports.List()
+	if len(list) != 2 || list[0].ID != "c" || list[1].ID != "b" || !list[1].Start.Equal(start.Add(time.Hour)) {
+		t.Errorf("expected the reports c and b, got %+v", list)
+	}
+}
+
+// jobs returns a config with a job due every minute and another one due every hour.
+func jobs(dryRun bool) *config.Config {
+	return &config.Config{
+		Options: config.Options{DryRun: dryRun},
+		Serve: &config.Serve{Jobs: []config.Job{
+			{Name: "minutely", Schedule: "* * * * *", Command: config.JobWipe},
+			{Name: "hourly", Schedule: "0 * * * *", Command: config.JobStop},
+		}},
+	}
+}
+
+func TestServer_Tick(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var ran []string
+	s := &Server{
+		Load: func() (*config.Config, error) { return jobs(true), nil },
+		Run: func(cfg *config.Config, job config.Job) *Report {
+			mu.Lock()
+			ran = append(ran, job.Name)
+			mu.Unlock()
+			<-release
+			return &Report{ID: job.Name, Job: job.Name}
+		},
+		Reports: &Reports{},
+	}
+
+	now := time.Date(2020, 1, 15, 10, 59, 30, 0, time.UTC)
+	if err := s.Reload(now); err != nil {
+		t.Fatal(err)
+	}
+
+	s.tick(now.Add(10 * time.Second))
+	skipped := metrics.SkippedRuns.Value("hourly")
+	// both are due at 11:00, the hourly job is skipped while the minutely one runs
+	s.tick(now.Add(30 * time.Second))
+	if s.Status().Running != "minutely" {
+		t.Errorf("expected the minutely job to run, got %+v", s.Status())
+	}
+	if metrics.SkippedRuns.Value("hourly") != skipped+1 {
+		t.Error("expected the overlapping run to be counted as skipped")
+	}
+
+	close(release)
+	s.Wait()
+	s.tick(now.Add(90 * time.Second))
+	s.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 2 || ran[0] != "minutely" || ran[1] != "minutely" {
+		t.Errorf("expected the minutely job to run twice, got %v", ran)
+	}
+	if list := s.Reports.List(); len(list) != 2 {
+		t.Errorf("expected the reports of both runs, got %+v", list)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	cfg, loadErr := jobs(true), error(nil)
+	s := &Server{
+		Load:    func() (*config.Config, error) { return cfg, loadErr },
+		Reports: &Reports{},
+	}
+
+	now := time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC)
+	if err := s.Reload(now); err != nil {
+		t.Fatal(err)
+	}
+	hash := s.Status().ConfigHash
+
+	reloads := metrics.ConfigReloads.Value("success")
+	s.Reload(now)
+	if metrics.ConfigReloads.Value("success") != reloads {
+		t.Error("expected an unchanged config not to be reloaded")
+	}
+
+	cfg = jobs(false)
+	if err := s.Reload(now); err != nil {
+		t.Fatal(err)
+	}
+	if metrics.ConfigReloads.Value("success") != reloads+1 || s.Status().ConfigHash == hash || s.Config().Options.DryRun {
+		t.Error("expected the changed config to be reloaded")
+	}
+
+	loadErr = os.ErrNotExist
+	if err := s.Reload(now); err == nil {
+		t.Fatal("expected an error")
+	}
+	if status := s.Status(); status.Status != "degraded" || s.Config() == nil {
+		t.Errorf("expected the previous config to be kept, got %+v", status)
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	previous := &config.Serve{Listen: ":8080", Reports: 10, ReportDir: "reports"}
+
+	if changed := restartRequired(previous, jobs(false).Serve); !reflect.DeepEqual(changed, []string{"listen", "reports", "report-dir"}) {
+		t.Errorf("expected all settings applied on start to be changed, got %v", changed)
+	}
+
+	current := jobs(false).Serve
+	current.Listen, current.Reports, current.ReportDir = ":8080", 10, "reports"
+	if changed := restartRequired(previous, current); len(changed) > 0 {
+		t.Errorf("expected changed jobs not to require a restart, got %v", changed)
+	}
+}
+
+func TestServer_Handler(t *testing.T) {
+	s := &Server{
+		Load:    func() (*config.Config, error) { return jobs(true), nil },
+		Reports: &Reports{},
+	}
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	get := func(path string, v interface{}) int {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if v != nil {
+			json.NewDecoder(resp.Body).Decode(v)
+		}
+		return resp.StatusCode
+	}
+
+	if code := get("/healthz", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("expected to be unhealthy before the config is loaded, got %d", code)
+	}
+
+	s.Reload(time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC))
+	s.Reports.Add(&Report{ID: "run", Job: "minutely"})
+
+	var status Status
+	if code := get("/healthz", &status); code != http.StatusOK || status.Status != "ok" || len(status.Jobs) != 2 ||
+		status.Jobs[1].Name != "minutely" || status.Jobs[1].Next.Format("15:04") != "10:31" {
+		t.Errorf("unexpected health %d %+v", code, status)
+	}
+
+	var reports []Report
+	if code := get("/reports", &reports); code != http.StatusOK || len(reports) != 1 {
+		t.Errorf("unexpected reports %d %+v", code, reports)
+	}
+
+	var report Report
+	if code := get("/reports/run", &report); code != http.StatusOK || report.Job != "minutely" {
+		t.Errorf("unexpected report %d %+v", code, report)
+	}
+	if code := get("/reports/unknown", nil); code != http.StatusNotFound {
+		t.Errorf("expected an unknown report not to be found, got %d", code)
+	}
+	if code := get("/metrics", nil); code != http.StatusOK {
+		t.Errorf("expected metrics to be served, got %d", code)
+	}
+}
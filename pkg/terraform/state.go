@@ -0,0 +1,215 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	awsweeper "github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/spf13/afero"
+)
+
+// resourceTypes maps the types of the Terraform AWS provider to the supported resource types.
+var resourceTypes = map[string]awsweeper.ResourceType{
+	"aws_instance":                         "ec2",
+	"aws_s3_bucket":                        "s3_bucket",
+	"aws_dynamodb_table":                   "dynamodb_table",
+	"aws_elasticsearch_domain":             "elasticsearch_domain",
+	"aws_kinesis_stream":                   "kinesis_data_stream",
+	"aws_kinesis_firehose_delivery_stream": "firehose",
+	"aws_db_instance":                      "rds_instance",
+	"aws_rds_cluster":                      "rds_cluster",
+	"aws_medialive_input":                  "medialive_input",
+	"aws_medialive_channel":                "medialive_channel",
+}
+
+// identifyingAttributes are the attributes of a Terraform resource which may be the ID or name of an AWS resource.
+var identifyingAttributes = []string{"id", "arn", "name", "bucket", "identifier", "cluster_identifier", "domain_name", "table_name"}
+
+// Managed is the set of resources managed by Terraform.
+type Managed struct {
+	ids   map[string]bool
+	types map[awsweeper.ResourceType]bool
+}
+
+// NewManaged returns an empty set of managed resources.
+func NewManaged() *Managed {
+	return &Managed{
+		ids:   make(map[string]bool),
+		types: make(map[awsweeper.ResourceType]bool),
+	}
+}
+
+// Contains reports whether the resource is managed by Terraform, identified by its ID or name.
+func (m *Managed) Contains(r awsweeper.IResource) bool {
+	if m.ids[r.GetID()] {
+		return true
+	}
+
+	return r.GetName() != "" && m.ids[r.GetName()]
+}
+
+// ManagesType reports whether Terraform manages resources of the type.
+func (m *Managed) ManagesType(resourceType awsweeper.ResourceType) bool {
+	return m.types[resourceType]
+}
+
+// Len returns the number of managed resources.
+func (m *Managed) Len() int {
+	return len(m.ids)
+}
+
+// state covers the formats of Terraform 0.11 (version 3) and 0.12 (version 4).
+type state struct {
+	Version int `json:"version"`
+
+	// version 4
+	Resources []struct {
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Instances []struct {
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+
+	// version 3
+	Modules []struct {
+		Resources map[string]struct {
+			Type    string `json:"type"`
+			Primary struct {
+				ID         string            `json:"id"`
+				Attributes map[string]string `json:"attributes"`
+			} `json:"primary"`
+		} `json:"resources"`
+	} `json:"modules"`
+}
+
+// Add adds the managed resources of a Terraform state.
+func (m *Managed) Add(data []byte) error {
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	switch s.Version {
+	case 4:
+		for _, r := range s.Resources {
+			if r.Mode != "managed" {
+				continue
+			}
+			for _, i := range r.Instances {
+				attributes := make(map[string]string)
+				for k, v := range i.Attributes {
+					if str, ok := v.(string); ok {
+						attributes[k] = str
+					}
+				}
+				m.add(r.Type, attributes)
+			}
+		}
+	case 3:
+		for _, module := range s.Modules {
+			for name, r := range module.Resources {
+				if strings.HasPrefix(name, "data.") {
+					continue
+				}
+				attributes := map[string]string{"id": r.Primary.ID}
+				for k, v := range r.Primary.Attributes {
+					attributes[k] = v
+				}
+				m.add(r.Type, attributes)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported Terraform state version %d", s.Version)
+	}
+
+	return nil
+}
+
+func (m *Managed) add(terraformType string, attributes map[string]string) {
+	if resourceType, ok := resourceTypes[terraformType]; ok {
+		m.types[resourceType] = true
+	}
+
+	for _, a := range identifyingAttributes {
+		if v := attributes[a]; v != "" {
+			m.ids[v] = true
+		}
+	}
+}
+
+// Loader reads Terraform states from local files or S3.
+type Loader struct {
+	// S3 returns a client of the region of the bucket. If nil, only local states can be read.
+	S3 func(bucket string) (s3iface.S3API, error)
+}
+
+// NewLoader returns a loader reading states from S3 with the given session.
+func NewLoader(p client.ConfigProvider, cfgs ...*aws.Config) *Loader {
+	return &Loader{
+		S3: func(bucket string) (s3iface.S3API, error) {
+			region, err := s3manager.GetBucketRegionWithClient(aws.BackgroundContext(), s3.New(p, cfgs...), bucket)
+			if err != nil {
+				return nil, err
+			}
+
+			return s3.New(p, append(cfgs, &aws.Config{Region: aws.String(region)})...), nil
+		},
+	}
+}
+
+// Load returns the resources managed by Terraform according to the states, local paths or s3://bucket/key.
+func (l *Loader) Load(states []string) (*Managed, error) {
+	managed := NewManaged()
+	for _, location := range states {
+		data, err := l.read(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Terraform state %s: %v", location, err)
+		}
+
+		if err := managed.Add(data); err != nil {
+			return nil, fmt.Errorf("failed to parse Terraform state %s: %v", location, err)
+		}
+	}
+
+	return managed, nil
+}
+
+func (l *Loader) read(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		return afero.ReadFile(config.AppFs, location)
+	}
+
+	bucketAndKey := strings.SplitN(strings.TrimPrefix(location, "s3://"), "/", 2)
+	if len(bucketAndKey) != 2 || bucketAndKey[0] == "" || bucketAndKey[1] == "" {
+		return nil, fmt.Errorf("expected s3://bucket/key")
+	}
+
+	if l.S3 == nil {
+		return nil, fmt.Errorf("no S3 client configured")
+	}
+
+	api, err := l.S3(bucketAndKey[0])
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := api.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucketAndKey[0]),
+		Key:    aws.String(bucketAndKey[1]),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	return ioutil.ReadAll(output.Body)
+}
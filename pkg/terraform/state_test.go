@@ -0,0 +1,105 @@
+package terraform
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	awsweeper "github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/spf13/afero"
+)
+
+const stateV4 = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [{"attributes": {"id": "i-1", "arn": "arn:aws:ec2:eu-west-1:111111111111:instance/i-1", "ebs_optimized": false}}]
+    },
+    {
+      "mode": "data",
+      "type": "aws_s3_bucket",
+      "name": "shared",
+      "instances": [{"attributes": {"id": "shared-bucket"}}]
+    }
+  ]
+}`
+
+const stateV3 = `{
+  "version": 3,
+  "modules": [
+    {
+      "resources": {
+        "aws_s3_bucket.logs": {"type": "aws_s3_bucket", "primary": {"id": "logs", "attributes": {"bucket": "logs"}}},
+        "data.aws_db_instance.db": {"type": "aws_db_instance", "primary": {"id": "db"}}
+      }
+    }
+  ]
+}`
+
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string]string
+}
+
+func (f *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewBufferString(f.objects[*input.Bucket+"/"+*input.Key])),
+	}, nil
+}
+
+func TestLoad(t *testing.T) {
+	defer func() { config.AppFs = afero.NewOsFs() }()
+	config.AppFs = afero.NewMemMapFs()
+	if err := afero.WriteFile(config.AppFs, "terraform.tfstate", []byte(stateV4), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := &Loader{
+		S3: func(bucket string) (s3iface.S3API, error) {
+			return &fakeS3{objects: map[string]string{"states/env/terraform.tfstate": stateV3}}, nil
+		},
+	}
+
+	managed, err := loader.Load([]string{"terraform.tfstate", "s3://states/env/terraform.tfstate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		resource awsweeper.IResource
+		managed  bool
+	}{
+		{&awsweeper.Instance{ID: aws.String("i-1")}, true},
+		{&awsweeper.Instance{ID: aws.String("i-2")}, false},
+		{&awsweeper.S3Bucket{ID: aws.String("logs")}, true},
+		{&awsweeper.S3Bucket{ID: aws.String("shared-bucket")}, false},
+		{&awsweeper.RDSInstance{ID: aws.String("db")}, false},
+	}
+
+	for _, tc := range tests {
+		if managed.Contains(tc.resource) != tc.managed {
+			t.Errorf("expected %s to be managed: %t", tc.resource.GetID(), tc.managed)
+		}
+	}
+
+	for resourceType, expected := range map[awsweeper.ResourceType]bool{"ec2": true, "s3_bucket": true, "rds_instance": false} {
+		if managed.ManagesType(resourceType) != expected {
+			t.Errorf("expected %s to be managed: %t", resourceType, expected)
+		}
+	}
+
+	if _, err := loader.Load([]string{"s3://states"}); err == nil {
+		t.Error("expected an error for an S3 location without key")
+	}
+
+	if err := NewManaged().Add([]byte(`{"version": 2}`)); err == nil {
+		t.Error("expected an error for an unsupported state version")
+	}
+}
@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultEndpoint is the OTLP/HTTP endpoint of a collector running locally.
+const DefaultEndpoint = "http://localhost:4318"
+
+// The OTLP/JSON encoding of spans, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	scope struct {
+		Name string `json:"name"`
+	}
+
+	otlpSpan struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+
+	status struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+
+	anyValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+		BoolValue   *bool   `json:"boolValue,omitempty"`
+	}
+)
+
+func attribute(a Attribute) keyValue {
+	kv := keyValue{Key: a.Key}
+	switch v := a.Value.(type) {
+	case int64:
+		i := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &i
+	case bool:
+		kv.Value.BoolValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
+
+// Encode returns the OTLP/JSON export request of spans.
+func Encode(spans []*Span) ([]byte, error) {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		e := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: unixNano(s.StartTime),
+			EndTimeUnixNano:   unixNano(s.EndTime),
+			Status:            status{Code: s.StatusCode, Message: s.StatusMessage},
+		}
+		for _, a := range s.Attributes {
+			e.Attributes = append(e.Attributes, attribute(a))
+		}
+		encoded = append(encoded, e)
+	}
+
+	return json.Marshal(exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: []keyValue{attribute(String("service.name", ServiceName))}},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: ServiceName}, Spans: encoded}},
+	}}})
+}
+
+// OTLPExporter posts spans to the OTLP/HTTP traces endpoint of a collector.
+type OTLPExporter struct {
+	// Endpoint is the base URL of the collector, spans are posted to its /v1/traces path.
+	Endpoint string
+	Client   *http.Client
+}
+
+// NewOTLPExporter returns an exporter posting spans to the collector at endpoint. If empty, the endpoint is taken
+// from the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or defaults to DefaultEndpoint.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
+	return &OTLPExporter{
+		Endpoint: endpoint,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Export posts the spans to the collector.
+func (e *OTLPExporter) Export(spans []*Span) error {
+	body, err := Encode(spans)
+	if err != nil {
+		return err
+	}
+
+	u := strings.TrimSuffix(e.Endpoint, "/") + "/v1/traces"
+	resp, err := e.Client.Post(u, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("collector returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// WriterExporter writes spans to a writer (e.g. stdout), one OTLP/JSON export request per line.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter returns an exporter writing spans to w.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// Export writes the spans.
+func (e *WriterExporter) Export(spans []*Span) error {
+	b, err := Encode(spans)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+type spanKey struct{}
+
+// Instrument records a client span for every request sent with the handlers (e.g. of a session), as child of
+// the active span. Retries of a request are part of its span.
+func Instrument(h *request.Handlers) {
+	h.Build.PushFrontNamed(request.NamedHandler{
+		Name: "awsweeper.tracing.Start",
+		Fn: func(r *request.Request) {
+			span := StartClient(r.ClientInfo.ServiceName+"."+r.Operation.Name,
+				String("rpc.system", "aws-api"),
+				String("rpc.service", r.ClientInfo.ServiceName),
+				String("rpc.method", r.Operation.Name),
+				String("cloud.region", aws.StringValue(r.Config.Region)),
+			)
+			if span != nil {
+				r.SetContext(context.WithValue(r.Context(), spanKey{}, span))
+			}
+		},
+	})
+
+	h.Complete.PushBackNamed(request.NamedHandler{
+		Name: "awsweeper.tracing.End",
+		Fn: func(r *request.Request) {
+			span, ok := r.Context().Value(spanKey{}).(*Span)
+			if !ok {
+				return
+			}
+
+			span.SetAttributes(Int("aws.retries", r.RetryCount))
+			if r.HTTPResponse != nil {
+				span.SetAttributes(Int("http.status_code", r.HTTPResponse.StatusCode))
+			}
+			if r.RequestID != "" {
+				span.SetAttributes(String("aws.request_id", r.RequestID))
+			}
+			if aerr, ok := r.Error.(awserr.Error); ok {
+				span.SetAttributes(String("aws.error_code", aerr.Code()))
+			}
+			span.SetError(r.Error)
+			span.End()
+		},
+	})
+}
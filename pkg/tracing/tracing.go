@@ -0,0 +1,229 @@
+// Package tracing records OpenTelemetry spans of sweeps and exports them in the OTLP/JSON encoding, either to a
+// collector over HTTP or to a writer such as stdout.
+//
+// Sweeps run sequentially, so spans are nested by the order they are started in: a span started while another one
+// is active becomes its child. Without an exporter, no spans are recorded and Start returns nil, on which all
+// methods of Span are no-ops.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ServiceName is the name of the service spans are exported as.
+const ServiceName = "awsweeper"
+
+// batchSize is the number of ended spans which are exported at once, before Flush is called.
+const batchSize = 512
+
+// Kinds of spans.
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+// Status codes of spans.
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is a stage of a sweep, e.g. listing the resources of a type or deleting a resource.
+type Span struct {
+	TraceID       string
+	SpanID        string
+	ParentSpanID  string
+	Name          string
+	Kind          int
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    []Attribute
+	StatusCode    int
+	StatusMessage string
+}
+
+// Exporter sends ended spans to a tracing backend.
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+var (
+	mu       sync.Mutex
+	exporter Exporter
+	// active are the spans which have been started but not ended, the last one is the parent of new spans
+	active []*Span
+	ended  []*Span
+)
+
+// SetExporter sets the exporter of spans and returns the previous one. A nil exporter disables tracing.
+func SetExporter(e Exporter) Exporter {
+	mu.Lock()
+	defer mu.Unlock()
+
+	previous := exporter
+	exporter = e
+	active = nil
+	ended = nil
+	return previous
+}
+
+// Start starts a span as child of the active span, or of a new trace if there is none, and makes it the active
+// span until it is ended.
+func Start(name string, attrs ...Attribute) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+
+	s := start(name, KindInternal, attrs)
+	if s != nil {
+		active = append(active, s)
+	}
+	return s
+}
+
+// StartClient starts a span of a request to a remote service as child of the active span. Unlike Start, the span
+// never becomes the active span, so requests sent concurrently do not nest.
+func StartClient(name string, attrs ...Attribute) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return start(name, KindClient, attrs)
+}
+
+// start returns a new span, nil if tracing is disabled.
+// The caller must hold the lock.
+func start(name string, kind int, attrs []Attribute) *Span {
+	if exporter == nil {
+		return nil
+	}
+
+	s := &Span{
+		SpanID:     newID(8),
+		Name:       name,
+		Kind:       kind,
+		StartTime:  time.Now(),
+		Attributes: attrs,
+	}
+
+	if len(active) > 0 {
+		parent := active[len(active)-1]
+		s.TraceID = parent.TraceID
+		s.ParentSpanID = parent.SpanID
+	} else {
+		s.TraceID = newID(16)
+	}
+
+	return s
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+// SetError sets the status of the span to the error, if not nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	s.StatusCode = StatusError
+	s.StatusMessage = err.Error()
+}
+
+// End ends the span. Spans started after it which are still active are ended as well.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !s.EndTime.IsZero() {
+		return
+	}
+
+	now := time.Now()
+	for i := len(active) - 1; i >= 0; i-- {
+		if active[i] == s {
+			for _, child := range active[i+1:] {
+				child.EndTime = now
+				ended = append(ended, child)
+			}
+			active = active[:i]
+			break
+		}
+	}
+
+	s.EndTime = now
+	ended = append(ended, s)
+
+	if len(ended) >= batchSize {
+		export()
+	}
+}
+
+// Flush exports the ended spans.
+func Flush() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return export()
+}
+
+// export exports and forgets the ended spans.
+// The caller must hold the lock.
+func export() error {
+	if exporter == nil || len(ended) == 0 {
+		return nil
+	}
+
+	spans := ended
+	ended = nil
+	return exporter.Export(spans)
+}
+
+func newID(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// unixNano formats a time like the OTLP/JSON encoding of 64 bit integers.
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,239 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// recorder keeps the exported spans.
+type recorder struct {
+	spans []*Span
+}
+
+func (r *recorder) Export(spans []*Span) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *recorder) byName(name string) *Span {
+	for _, s := range r.spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestStart_Disabled(t *testing.T) {
+	SetExporter(nil)
+
+	span := Start("disabled")
+	if span != nil {
+		t.Fatalf("expected no span without an exporter, got %v", span)
+	}
+	span.SetAttributes(String("k", "v"))
+	span.SetError(errors.New("failed"))
+	span.End()
+}
+
+func TestStart_Nesting(t *testing.T) {
+	rec := &recorder{}
+	SetExporter(rec)
+	defer SetExporter(nil)
+
+	root := Start("root")
+	child := Start("child", String("region", "eu-west-1"))
+	client := StartClient("client")
+	sibling := Start("sibling")
+	client.End()
+	// ending the root ends the spans still active
+	root.End()
+	child.End()
+
+	other := Start("other")
+	other.SetError(errors.New("failed"))
+	other.End()
+
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.spans) != 5 {
+		t.Fatalf("expected 5 spans, got %d", len(rec.spans))
+	}
+	if root.ParentSpanID != "" || len(root.TraceID) != 32 || len(root.SpanID) != 16 {
+		t.Errorf("unexpected root span %+v", root)
+	}
+	for _, s := range []*Span{child, client, sibling} {
+		if s.TraceID != root.TraceID {
+			t.Errorf("expected %s to be in the trace of root", s.Name)
+		}
+	}
+	if child.ParentSpanID != root.SpanID || client.ParentSpanID != child.SpanID || sibling.ParentSpanID != child.SpanID {
+		t.Errorf("unexpected parents: child %s, client %s, sibling %s", child.ParentSpanID, client.ParentSpanID, sibling.ParentSpanID)
+	}
+	if client.Kind != KindClient || root.Kind != KindInternal {
+		t.Errorf("unexpected kinds: root %d, client %d", root.Kind, client.Kind)
+	}
+	if child.EndTime.IsZero() || child.EndTime != root.EndTime {
+		t.Errorf("expected child to end with root")
+	}
+	if other.TraceID == root.TraceID || other.ParentSpanID != "" {
+		t.Errorf("expected other to start a new trace")
+	}
+	if other.StatusCode != StatusError || other.StatusMessage != "failed" {
+		t.Errorf("unexpected status %d %q", other.StatusCode, other.StatusMessage)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var path, contentType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	SetExporter(NewOTLPExporter(server.URL + "/"))
+	defer SetExporter(nil)
+
+	span := Start("list", String("resource.type", "s3_bucket"), Int("resources", 3), Bool("dry_run", true))
+	span.End()
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/v1/traces" || contentType != "application/json" {
+		t.Errorf("unexpected request to %s (%s)", path, contentType)
+	}
+
+	var req exportRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected request %s", body)
+	}
+	if service := req.ResourceSpans[0].Resource.Attributes[0]; service.Key != "service.name" || *service.Value.StringValue != ServiceName {
+		t.Errorf("unexpected resource %s", body)
+	}
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].TraceID != span.TraceID || spans[0].Name != "list" || spans[0].StartTimeUnixNano == "" {
+		t.Fatalf("unexpected spans %s", body)
+	}
+	for _, want := range []string{
+		`{"key":"resource.type","value":{"stringValue":"s3_bucket"}}`,
+		`{"key":"resources","value":{"intValue":"3"}}`,
+		`{"key":"dry_run","value":{"boolValue":true}}`,
+	} {
+		if !bytes.Contains(body, []byte(want)) {
+			t.Errorf("expected %s in %s", want, body)
+		}
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid spans", http.StatusBadRequest)
+	})
+	Start("failing").End()
+	if err := Flush(); err == nil {
+		t.Error("expected an error for a failed export")
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	var b bytes.Buffer
+	SetExporter(NewWriterExporter(&b))
+	defer SetExporter(nil)
+
+	Start("first").End()
+	Start("second").End()
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(b.Bytes()), []byte("\n"))
+	if len(lines) != 1 || !bytes.Contains(lines[0], []byte(`"name":"first"`)) || !bytes.Contains(lines[0], []byte(`"name":"second"`)) {
+		t.Errorf("expected one export request with both spans, got\n%s", b.String())
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	rec := &recorder{}
+	SetExporter(rec)
+	defer SetExporter(nil)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		MaxRetries:  aws.Int(1),
+	}))
+	Instrument(&sess.Handlers)
+
+	// the first call is throttled once before succeeding, the second one fails
+	attempts := 0
+	sess.Handlers.Send.Clear()
+	sess.Handlers.UnmarshalMeta.Clear()
+	sess.Handlers.Unmarshal.Clear()
+	sess.Handlers.UnmarshalError.Clear()
+	sess.Handlers.ValidateResponse.Clear()
+	sess.Handlers.Send.PushBack(func(r *request.Request) {
+		attempts++
+		r.HTTPResponse = &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(&bytes.Buffer{})}
+		switch attempts {
+		case 1:
+			r.HTTPResponse.StatusCode = http.StatusBadRequest
+			r.Error = awserr.New("ThrottlingException", "Rate exceeded", nil)
+		case 3:
+			r.HTTPResponse.StatusCode = http.StatusBadRequest
+			r.Error = awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Table not found", nil)
+		}
+	})
+	sess.Handlers.Retry.PushFront(func(r *request.Request) { r.RetryDelay = 0 })
+
+	parent := Start("list")
+	client := dynamodb.New(sess)
+	if _, err := client.ListTables(&dynamodb.ListTablesInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String("table")}); err == nil {
+		t.Fatal("expected DeleteTable to fail")
+	}
+	parent.End()
+	Flush()
+
+	list := rec.byName("dynamodb.ListTables")
+	if list == nil || list.ParentSpanID != parent.SpanID || list.Kind != KindClient || list.StatusCode != StatusUnset {
+		t.Fatalf("unexpected span of ListTables %+v", list)
+	}
+	if !hasAttribute(list, Int("aws.retries", 1)) || !hasAttribute(list, String("cloud.region", "eu-west-1")) {
+		t.Errorf("unexpected attributes %v", list.Attributes)
+	}
+
+	del := rec.byName("dynamodb.DeleteTable")
+	if del == nil || del.StatusCode != StatusError || !hasAttribute(del, String("aws.error_code", dynamodb.ErrCodeResourceNotFoundException)) {
+		t.Fatalf("unexpected span of DeleteTable %+v", del)
+	}
+}
+
+func hasAttribute(s *Span, want Attribute) bool {
+	for _, a := range s.Attributes {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
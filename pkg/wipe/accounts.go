@@ -0,0 +1,212 @@
+package wipe
+
+import (
+	"fmt"
+
+	"github.com/cmpsoares91/awsweeper/pkg/accounts"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// RunAccounts runs the sweep in every account selected by the accounts section of the config, assuming the
+// rendered role in each of them. Without an accounts section, only the account of the current credentials is swept.
+// The resources of all accounts are selected before any is deleted, so that a single warning lists them and the grace
+// period is waited once.
+func (c *Wiper) RunAccounts() (aws.IAccountRegionResourceTypeResources, []error, error) {
+	wipers, err := c.accountWipers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.Config.Accounts == nil {
+		return c.forEachAccount(wipers, "Sweeping account", (*Wiper).Run)
+	}
+
+	selections := make(map[*Wiper]*selection)
+	_, warnings, err := c.forEachAccount(wipers, "Selecting resources of account", func(w *Wiper) (aws.IRegionResourceTypeResources, []error, error) {
+		end := w.startSweep()
+		s, err := w.selectAll()
+		end(s.resources, s.warnings, err)
+		if err != nil {
+			return nil, s.warnings, err
+		}
+
+		selections[w] = s
+		// the warnings of the selection are reported with those of deleting the resources
+		return s.resources, nil, nil
+	})
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	items := make(map[aws.IResource]inventory.Item)
+	var selected []accountWiper
+	for _, a := range wipers {
+		if s, ok := selections[a.wiper]; ok {
+			for r, i := range s.items {
+				items[r] = i
+			}
+			selected = append(selected, a)
+		}
+	}
+	c.warn(items)
+
+	report, ws, err := c.forEachAccount(selected, "Sweeping account", func(w *Wiper) (resources aws.IRegionResourceTypeResources, warnings []error, err error) {
+		end := w.startSweep()
+		defer func() { end(resources, warnings, err) }()
+		return w.apply(selections[w])
+	})
+	return report, append(warnings, ws...), err
+}
+
+// ListAccounts lists the resources of the given types in every account selected by the accounts section of the config,
+// like RunAccounts, without deleting anything.
+func (c *Wiper) ListAccounts(resourceTypes ...aws.ResourceType) (aws.IAccountRegionResourceTypeResources, []error, error) {
+	wipers, err := c.accountWipers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.forEachAccount(wipers, "Listing account", func(w *Wiper) (aws.IRegionResourceTypeResources, []error, error) {
+		return w.List(resourceTypes...)
+	})
+}
+
+// resolver returns the resolver of the accounts, created using the configured credentials unless set.
+func (c *Wiper) resolver() (*accounts.Resolver, error) {
+	if c.Accounts != nil {
+		return c.Accounts, nil
+	}
+	sess, cfg, err := aws.NewSession(aws.DefaultRegion(c.Config.Options.PartitionID()), c.Config.Options.SessionOptions())
+	if err != nil {
+		return nil, err
+	}
+	return accounts.NewResolver(sess, cfg), nil
+}
+
+// resolveAccount sets the account of the config to the one of the current credentials if it is unknown, i.e. the
+// wiper doesn't run for an account of the accounts section, and overrides are scoped to accounts. Otherwise those
+// overrides would never match.
+func (c *Wiper) resolveAccount() error {
+	if c.Config.Options.Account != "" || !c.Config.HasAccountOverrides() {
+		return nil
+	}
+
+	resolver, err := c.resolver()
+	if err != nil {
+		return err
+	}
+
+	caller, err := resolver.CallerAccount()
+	if err != nil {
+		return err
+	}
+
+	// the config may be shared by the caller, so the account is set on a copy
+	cfg := *c.Config
+	cfg.Options.Account = caller
+	c.Config = &cfg
+	return nil
+}
+
+// accountWiper is the wiper of an account.
+type accountWiper struct {
+	account accounts.Account
+	wiper   *Wiper
+}
+
+// accountWipers returns a wiper configured for every account selected by the config, or for the account of the
+// current credentials without accounts section.
+func (c *Wiper) accountWipers() ([]accountWiper, error) {
+	resolver, err := c.resolver()
+	if err != nil {
+		return nil, err
+	}
+
+	caller, err := resolver.CallerAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	// Terraform states are read with the credentials of the caller, not of each account
+	managed, err := c.managed()
+	if err != nil {
+		return nil, err
+	}
+
+	newWiper := func(cfg config.Config) *Wiper {
+		return &Wiper{Config: &cfg, Managed: managed, Clients: c.Clients, Audit: c.Audit, Notifier: c.Notifier, Only: c.Only}
+	}
+
+	if c.Config.Accounts == nil {
+		cfg := *c.Config
+		cfg.Options.Account = caller
+		return []accountWiper{{account: accounts.Account{ID: caller}, wiper: newWiper(cfg)}}, nil
+	}
+
+	accs, err := resolver.Resolve(*c.Config.Accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	var wipers []accountWiper
+	for _, account := range accs {
+		account.Partition = c.Config.Options.PartitionID()
+		cfg := *c.Config
+		cfg.Options.Account = account.ID
+		if account.ID != caller {
+			role, err := accounts.RoleARN(c.Config.Accounts.RoleTemplate, account)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Options.AccountRole = role
+		}
+
+		wipers = append(wipers, accountWiper{account: account, wiper: newWiper(cfg)})
+	}
+
+	return wipers, nil
+}
+
+// forEachAccount calls run with the wiper of every account. Without accounts section, the results of the only account
+// are returned as they are. Otherwise a failing account is added to the warnings, unless the audit log can't be
+// written, which stops the remaining accounts.
+func (c *Wiper) forEachAccount(wipers []accountWiper, message string, run func(*Wiper) (aws.IRegionResourceTypeResources, []error, error)) (aws.IAccountRegionResourceTypeResources, []error, error) {
+	report := make(aws.IAccountRegionResourceTypeResources)
+	if c.Config.Accounts == nil {
+		account := wipers[0].account
+		resources, warnings, err := run(wipers[0].wiper)
+		report[account.ID] = resources
+		return report, warnings, err
+	}
+
+	var warnings []error
+	for _, a := range wipers {
+		account, wiper := a.account, a.wiper
+		logrus.WithFields(logrus.Fields{
+			logging.Account: account.ID,
+			"name":          account.Name,
+		}).Info(message)
+
+		resources, ws, err := run(wiper)
+		if wiper.auditErr != nil {
+			// the remaining accounts would delete resources without recording it
+			report[account.ID] = resources
+			return report, warnings, fmt.Errorf("account %s: %v", account.ID, err)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("Failed on account %s: %v", account.ID, err))
+			continue
+		}
+
+		for _, w := range ws {
+			warnings = append(warnings, fmt.Errorf("Account %s: %v", account.ID, w))
+		}
+		report[account.ID] = resources
+	}
+
+	return report, warnings, nil
+}
@@ -0,0 +1,72 @@
+package wipe
+
+import (
+	"github.com/cmpsoares91/awsweeper/pkg/audit"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// selectedBy remembers which filter selected resources for deletion, to be recorded in the audit log.
+func (c *Wiper) selectedBy(resources aws.IResources, fs filters.Filters) {
+	if c.Audit == nil {
+		return
+	}
+
+	for _, r := range resources {
+		var selected audit.Resource
+		if len(fs) > 0 {
+			f, err := fs.Match(r)
+			if err != nil || f == nil {
+				continue
+			}
+			selected.Filter = f.String()
+		}
+
+		// without filters or with an empty one
+		if selected.Filter == "" {
+			selected.Reason = "all resources of the type are deleted"
+		}
+		c.selected(r, selected)
+	}
+}
+
+// selected remembers why a resource was selected for deletion.
+func (c *Wiper) selected(r aws.IResource, selected audit.Resource) {
+	if c.Audit == nil {
+		return
+	}
+
+	if c.selection == nil {
+		c.selection = make(map[aws.IResource]audit.Resource)
+	}
+	c.selection[r] = selected
+}
+
+// snapshot returns the audit record of a resource with its attributes and tags, to be taken before deleting it.
+func (c *Wiper) snapshot(region aws.Region, resourceType aws.ResourceType, r aws.IResource) audit.Resource {
+	if err := r.EnsureLazyLoaded(); err != nil {
+		logrus.WithError(err).WithField(logging.ID, r.GetID()).Warn("Recording a resource without its attributes")
+	}
+	a := c.selection[r]
+	a.Item = inventory.NewItem(c.Config.Options.Account, region, resourceType, r)
+	return a
+}
+
+// recordResult appends the result of deleting a resource to the audit log. If that fails, the error is kept so that
+// no more resources are deleted and the run fails.
+func (c *Wiper) recordResult(a audit.Resource, result string, err error) {
+	a.Result = result
+	if err != nil {
+		a.Error = err.Error()
+	}
+
+	if err := c.Audit.Record(audit.Entry{Kind: audit.KindResource, Resource: &a}); err != nil {
+		logrus.WithError(err).Error("Failed to write the audit log")
+		if c.auditErr == nil {
+			c.auditErr = err
+		}
+	}
+}
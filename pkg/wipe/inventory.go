@@ -0,0 +1,55 @@
+package wipe
+
+import (
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// List returns all resources of the given types (all supported types if none are given) in the configured regions,
+// with their lazily loaded attributes (tags, creation date). Nothing is filtered or deleted.
+func (c *Wiper) List(resourceTypes ...aws.ResourceType) (aws.IRegionResourceTypeResources, []error, error) {
+	defer logging.Push(logrus.Fields{logging.Account: c.Config.Options.Account})()
+
+	var warnings []error
+	var inventory aws.IRegionResourceTypeResources = make(aws.IRegionResourceTypeResources)
+
+	if len(resourceTypes) == 0 {
+		resourceTypes = aws.SupportedResourceTypes()
+	}
+
+	regions, err := c.regions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, region := range regions {
+		restore := logging.Push(logrus.Fields{logging.Region: region})
+		logrus.Info("Listing region")
+		inventory[region] = make(aws.IResourceTypeResources)
+
+		if err := c.register(region); err != nil {
+			restore()
+			return nil, warnings, err
+		}
+
+		for _, resType := range resourceTypes {
+			logrus.WithFields(logrus.Fields{
+				logging.Type:   resType,
+				logging.Action: "list",
+			}).Info("Listing resources")
+			resources, err := list(resType)
+			if err != nil {
+				warnings = append(warnings, err)
+				continue
+			}
+			metrics.Discovered.Add(float64(len(resources)), region, string(resType))
+
+			inventory[region][resType] = loaded(resources, &warnings)
+		}
+		restore()
+	}
+
+	return inventory, warnings, nil
+}
@@ -0,0 +1,103 @@
+package wipe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// sleep waits for the grace period, replaced in tests.
+var sleep = time.Sleep
+
+// items returns the inventory items of the resources to wipe, with their tags, if notifications are sent. They are
+// taken before deleting the resources, whose tags can't be loaded afterwards.
+func (c *Wiper) items(resources aws.IRegionResourceTypeResources) map[aws.IResource]inventory.Item {
+	if !c.Notifier.Enabled(notify.EventWarning) && !c.Notifier.Enabled(notify.EventSummary) {
+		return nil
+	}
+
+	items := make(map[aws.IResource]inventory.Item)
+	for region, rtrs := range resources {
+		for resType, rs := range rtrs {
+			for _, r := range rs {
+				if err := r.EnsureLazyLoaded(); err != nil {
+					logrus.WithError(err).WithField(logging.ID, r.GetID()).Warn("Notifying about a resource without its attributes")
+				}
+				items[r] = inventory.NewItem(c.Config.Options.Account, region, resType, r)
+			}
+		}
+	}
+
+	return items
+}
+
+// notification returns a notification of the event with the items grouped by owner.
+func (c *Wiper) notification(event string, items []inventory.Item) *notify.Notification {
+	return &notify.Notification{
+		Event:   event,
+		RunID:   fmt.Sprint(logging.Get(logging.RunID)),
+		Account: c.Config.Options.Account,
+		DryRun:  c.Config.Options.DryRun,
+		Owners:  c.Notifier.Group(items),
+	}
+}
+
+// warn sends the warning listing the resources to wipe, if any. Unless in dry-run mode, it then waits for the
+// configured grace period before the resources are deleted.
+func (c *Wiper) warn(items map[aws.IResource]inventory.Item) {
+	if !c.Notifier.Enabled(notify.EventWarning) || len(items) == 0 {
+		return
+	}
+
+	var selected []inventory.Item
+	for _, i := range items {
+		selected = append(selected, i)
+	}
+
+	c.Notifier.Send(c.notification(notify.EventWarning, selected))
+
+	if c.Config.Notify == nil || c.Config.Notify.GracePeriod <= 0 || c.Config.Options.DryRun {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"grace_period": c.Config.Notify.GracePeriod,
+		"count":        len(selected),
+	}).Info("Sent the warning. Waiting for the grace period before deleting")
+	sleep(c.Config.Notify.GracePeriod)
+}
+
+// summarize sends the summary of a run with the deleted resources, unless nothing happened. A dry run lists all
+// resources it would have deleted.
+func (c *Wiper) summarize(items map[aws.IResource]inventory.Item, deleted aws.IResources, failed int, warnings []error, err error) {
+	if !c.Notifier.Enabled(notify.EventSummary) || (len(items) == 0 && len(warnings) == 0 && err == nil) {
+		return
+	}
+
+	var listed []inventory.Item
+	if c.Config.Options.DryRun {
+		for _, i := range items {
+			listed = append(listed, i)
+		}
+	} else {
+		for _, r := range deleted {
+			listed = append(listed, items[r])
+		}
+	}
+
+	n := c.notification(notify.EventSummary, listed)
+	n.Failed = failed
+	for _, w := range warnings {
+		n.Warnings = append(n.Warnings, w.Error())
+	}
+	if err != nil {
+		n.Error = err.Error()
+	}
+
+	c.Notifier.Send(n)
+}
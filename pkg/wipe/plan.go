@@ -0,0 +1,31 @@
+package wipe
+
+import (
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// restrict removes the resources of a region which are not in Only, e.g. created after a plan was approved.
+func (c *Wiper) restrict(region aws.Region, rtrs aws.IResourceTypeResources) {
+	if c.Only == nil {
+		return
+	}
+
+	for resType, rs := range rtrs {
+		var planned aws.IResources
+		for _, r := range rs {
+			if c.Only[inventory.NewItem(c.Config.Options.Account, region, resType, r).Key()] {
+				planned = append(planned, r)
+				continue
+			}
+
+			logrus.WithFields(logrus.Fields{
+				logging.Type: resType,
+				logging.ID:   r.GetID(),
+			}).Info("Resource is not in the plan. Skipping")
+		}
+		rtrs[resType] = planned
+	}
+}
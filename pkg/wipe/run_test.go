@@ -0,0 +1,758 @@
+package wipe
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/cmpsoares91/awsweeper/pkg/accounts"
+	"github.com/cmpsoares91/awsweeper/pkg/audit"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/aws/fake"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/cmpsoares91/awsweeper/pkg/notify"
+	"github.com/cmpsoares91/awsweeper/pkg/tracing"
+)
+
+func init() {
+	retryDelay = 0
+}
+
+func fakeWiper(backend *fake.Backend, fs map[aws.ResourceType]filters.Filters) *Wiper {
+	return &Wiper{
+		Config: &config.Config{
+			Options: config.Options{Regions: []string{"eu-west-1", "us-east-1"}},
+			Filters: fs,
+		},
+		Clients: backend.Clients,
+	}
+}
+
+// indexOf returns the index of the first call of an operation on a resource, -1 if it wasn't called.
+func indexOf(calls []string, call string) int {
+	for i, c := range calls {
+		if c == call {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRun_Filters(t *testing.T) {
+	backend := fake.New()
+	old := time.Now().Add(-30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	for _, region := range []string{"eu-west-1", "us-east-1"} {
+		backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: region, ID: "i-old", Created: old, Tags: map[string]string{"Name": "old"}})
+		// instances without a name are logged by ID
+		backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: region, ID: "i-untagged", Created: old})
+		backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: region, ID: "i-new", Tags: map[string]string{"Name": "new"}})
+		backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: region, ID: "ci-table", Tags: map[string]string{"team": "ci"}})
+		backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: region, ID: "prod-table", Tags: map[string]string{"team": "prod"}})
+	}
+	backend.Add(fake.Resource{Kind: fake.S3Bucket, Region: "eu-west-1", ID: "ci-eu-bucket"})
+	backend.Add(fake.Resource{Kind: fake.S3Bucket, Region: "us-east-1", ID: "ci-us-bucket"})
+	backend.Add(fake.Resource{Kind: fake.S3Bucket, Region: "us-east-1", ID: "prod-bucket"})
+
+	week := 7 * 24 * time.Hour
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{
+		"ec2":            {{Age: &filters.Age{OlderThan: &week}}},
+		"dynamodb_table": {{Tags: &filters.Tags{{"team": "^ci$"}}}},
+		"s3_bucket":      {{IDs: &[]string{"^ci-"}}},
+	})
+
+	resources, warnings, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	if resources.Len() != 8 {
+		t.Errorf("expected 8 wiped resources, got %d: %s", resources.Len(), resources.String())
+	}
+
+	for _, region := range []string{"eu-west-1", "us-east-1"} {
+		if ids := backend.IDs(fake.EC2Instance, region); !reflect.DeepEqual(ids, []string{"i-new"}) {
+			t.Errorf("%s: expected only i-new to remain, got %v", region, ids)
+		}
+		if ids := backend.IDs(fake.DynamoDBTable, region); !reflect.DeepEqual(ids, []string{"prod-table"}) {
+			t.Errorf("%s: expected only prod-table to remain, got %v", region, ids)
+		}
+	}
+
+	// buckets are listed in every region but only swept in their own
+	if ids := backend.IDs(fake.S3Bucket, "us-east-1"); !reflect.DeepEqual(ids, []string{"prod-bucket"}) {
+		t.Errorf("expected only prod-bucket to remain, got %v", ids)
+	}
+	if i := indexOf(backend.Calls(), "DeleteBucket ci-us-bucket"); i < 0 {
+		t.Error("ci-us-bucket has not been deleted")
+	}
+}
+
+func TestRun_DeletesEachRegionOnce(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "eu-table"})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "us-east-1", ID: "us-table"})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{}}})
+	if _, warnings, err := wiper.Run(); err != nil || len(warnings) > 0 {
+		t.Fatalf("unexpected warnings %v: %v", warnings, err)
+	}
+
+	// the resources of a region are not deleted again when sweeping the next one
+	deletes := make(map[string]int)
+	for _, c := range backend.Calls() {
+		if strings.HasPrefix(c, "DeleteTable ") {
+			deletes[strings.TrimPrefix(c, "DeleteTable ")]++
+		}
+	}
+	if !reflect.DeepEqual(deletes, map[string]int{"eu-table": 1, "us-table": 1}) {
+		t.Errorf("expected every table to be deleted once, got %v", deletes)
+	}
+}
+
+func TestRun_DryRun(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "table"})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{}}})
+	wiper.Config.Options.DryRun = true
+
+	resources, _, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resources.Len() != 1 {
+		t.Errorf("expected 1 matched resource, got %d", resources.Len())
+	}
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); len(ids) != 1 {
+		t.Errorf("expected table to remain in dry-run mode, got %v", ids)
+	}
+}
+
+func TestRun_Scheduled(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: "eu-west-1", ID: "i-dev", Tags: map[string]string{"Environment": "dev"}})
+	backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: "eu-west-1", ID: "i-ci", Tags: map[string]string{"Environment": "ci"}})
+
+	// the deletion filter matches all instances, but the scheduled ones are stopped instead
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"ec2": {
+		{Tags: &filters.Tags{{"Environment": "^dev$"}}, Schedule: &filters.Schedule{}},
+		{},
+	}})
+
+	if _, _, err := wiper.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if ids := backend.IDs(fake.EC2Instance, "eu-west-1"); !reflect.DeepEqual(ids, []string{"i-dev"}) {
+		t.Errorf("expected the scheduled instance to remain, got %v", ids)
+	}
+}
+
+func TestRun_Only(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "planned"})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "created-since"})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{}}})
+	wiper.Only = map[string]bool{"/eu-west-1/dynamodb_table/planned": true}
+
+	resources, _, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resources.Len() != 1 {
+		t.Errorf("expected only the planned resource to be wiped, got %s", resources.String())
+	}
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); !reflect.DeepEqual(ids, []string{"created-since"}) {
+		t.Errorf("expected created-since to remain, got %v", ids)
+	}
+}
+
+// fakeSTS returns the account of the caller.
+type fakeSTS struct {
+	stsiface.STSAPI
+	account string
+}
+
+func (f *fakeSTS) GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Account: awssdk.String(f.account)}, nil
+}
+
+func TestRun_AccountOverrides(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "table"})
+	backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: "eu-west-1", ID: "i-instance"})
+
+	// without an accounts section, overrides scoped to the account of the credentials apply
+	override := func(account string) *Wiper {
+		wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{IDs: &[]string{"^nothing$"}}}})
+		wiper.Config.Options.DryRun = true
+		wiper.Config.Overrides = []config.Override{{
+			Accounts: []string{"123456789012"},
+			Filters: map[aws.ResourceType]filters.Filters{
+				"dynamodb_table": {{}},
+				"ec2":            {{Schedule: &filters.Schedule{}}},
+			},
+		}}
+		wiper.Accounts = &accounts.Resolver{STS: &fakeSTS{account: account}}
+		return wiper
+	}
+
+	tests := []struct {
+		account string
+		matched int
+	}{
+		{account: "123456789012", matched: 1},
+		{account: "999999999999", matched: 0},
+	}
+
+	for _, tc := range tests {
+		wiper := override(tc.account)
+		resources, _, err := wiper.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resources.Len() != tc.matched {
+			t.Errorf("%s: expected %d resources to be wiped, got %s", tc.account, tc.matched, resources.String())
+		}
+		if wiper.Config.Options.Account != tc.account {
+			t.Errorf("expected the account of the caller, got %q", wiper.Config.Options.Account)
+		}
+
+		stopped, _, err := override(tc.account).Stop(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stopped.Len() != tc.matched {
+			t.Errorf("%s: expected %d resources to be stopped, got %s", tc.account, tc.matched, stopped.String())
+		}
+	}
+}
+
+func TestRun_Order(t *testing.T) {
+	backend := fake.New()
+	backend.DeleteDelay = 3
+	for _, r := range []fake.Resource{
+		{Kind: fake.MediaLiveInput, ID: "input"},
+		{Kind: fake.MediaLiveChannel, ID: "channel", DependsOn: []string{"input"}},
+		{Kind: fake.KinesisStream, ID: "stream"},
+		{Kind: fake.FirehoseDeliveryStream, ID: "delivery", DependsOn: []string{"stream"}},
+		{Kind: fake.RDSCluster, ID: "cluster"},
+		{Kind: fake.RDSInstance, ID: "member", DependsOn: []string{"cluster"}},
+		{Kind: fake.RDSInstance, ID: "db"},
+	} {
+		r.Region = "eu-west-1"
+		backend.Add(r)
+	}
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{
+		"medialive_input":     {{}},
+		"medialive_channel":   {{}},
+		"kinesis_data_stream": {{}},
+		"firehose":            {{}},
+		"rds_instance":        {{}},
+		"rds_cluster":         {{}},
+	})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+
+	resources, warnings, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cluster member is deleted with its cluster
+	if resources.Len() != 6 {
+		t.Errorf("expected 6 wiped resources, got %d: %s", resources.Len(), resources.String())
+	}
+
+	// the input and the stream can only be deleted once the channel and the delivery stream are gone, the cluster
+	// once its member is gone
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	calls := backend.Calls()
+	for _, order := range [][2]string{
+		{"DeleteChannel channel", "DeleteInput input"},
+		{"DeleteDeliveryStream delivery", "DeleteStream stream"},
+		{"DeleteDBInstance db", "DeleteDBCluster cluster"},
+		{"DeleteDBInstance member", "DeleteDBCluster cluster"},
+	} {
+		first, then := indexOf(calls, order[0]), indexOf(calls, order[1])
+		if first < 0 || then < 0 || first > then {
+			t.Errorf("expected %q before %q, got calls %v", order[0], order[1], calls)
+		}
+	}
+}
+
+func TestRun_Retries(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "throttled"})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "other"})
+	backend.Throttle("DeleteTable", deleteAttempts-1)
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{}}})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+
+	deleted := metrics.Deleted.Value("eu-west-1", "dynamodb_table")
+	_, warnings, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if got := metrics.Deleted.Value("eu-west-1", "dynamodb_table") - deleted; got != 2 {
+		t.Errorf("expected 2 deleted tables to be counted, got %v", got)
+	}
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); len(ids) > 0 {
+		t.Errorf("expected all tables to be deleted, got %v", ids)
+	}
+}
+
+func TestRun_LazyLoadFailure(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "keep", Tags: map[string]string{"keep": "yes"}})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "other"})
+	backend.Throttle("ListTagsOfResource", 1)
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{
+		"dynamodb_table": {{Not: &filters.Filters{{Tags: &filters.Tags{{"keep": "^yes$"}}}}}},
+	})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+
+	// the tags of keep can't be loaded, so it's skipped rather than deleted for not matching the "not" filter
+	_, warnings, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "keep") || !strings.Contains(warnings[0].Error(), "ThrottlingException") {
+		t.Errorf("expected a warning skipping keep, got %v", warnings)
+	}
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); len(ids) != 1 || ids[0] != "keep" {
+		t.Errorf("expected only keep to remain, got %v", ids)
+	}
+}
+
+func TestRun_FailedDeletion(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.MediaLiveInput, Region: "eu-west-1", ID: "input"})
+	backend.Add(fake.Resource{Kind: fake.MediaLiveChannel, Region: "eu-west-1", ID: "channel", DependsOn: []string{"input"}})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"medialive_input": {{}}})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+
+	labels := []string{"eu-west-1", "medialive_input"}
+	discovered, matched, failed := metrics.Discovered.Value(labels...), metrics.Matched.Value(labels...), metrics.Failed.Value(labels...)
+	_, warnings, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "ConflictException") {
+		t.Errorf("expected a warning about the input in use, got %v", warnings)
+	}
+
+	var attempts int
+	for _, c := range backend.Calls() {
+		if c == "DeleteInput input" {
+			attempts++
+		}
+	}
+	if attempts != deleteAttempts {
+		t.Errorf("expected %d attempts to delete the input, got %d", deleteAttempts, attempts)
+	}
+
+	if metrics.Discovered.Value(labels...)-discovered != 1 || metrics.Matched.Value(labels...)-matched != 1 ||
+		metrics.Failed.Value(labels...)-failed != 1 {
+		t.Error("expected the input to be counted as discovered, matched and failed")
+	}
+}
+
+func TestRun_BulkTags(t *testing.T) {
+	backend := fake.New()
+	backend.BulkTags = true
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "ci-table", Tags: map[string]string{"team": "ci"}})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "prod-table", Tags: map[string]string{"team": "prod"}})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{Tags: &filters.Tags{{"team": "^ci$"}}}}})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+
+	if _, _, err := wiper.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); !reflect.DeepEqual(ids, []string{"prod-table"}) {
+		t.Errorf("expected only prod-table to remain, got %v", ids)
+	}
+	for _, c := range backend.Calls() {
+		if strings.HasPrefix(c, "ListTagsOfResource") {
+			t.Errorf("expected tags to be fetched in bulk, got %q", c)
+		}
+	}
+}
+
+// spanRecorder keeps the exported spans.
+type spanRecorder struct {
+	spans []*tracing.Span
+}
+
+func (r *spanRecorder) Export(spans []*tracing.Span) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestRun_Tracing(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "ci-table", Tags: map[string]string{"team": "ci"}})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "prod-table", Tags: map[string]string{"team": "prod"}})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{Tags: &filters.Tags{{"team": "^ci$"}}}}})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+
+	rec := &spanRecorder{}
+	tracing.SetExporter(rec)
+	defer tracing.SetExporter(nil)
+
+	if _, _, err := wiper.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracing.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	byID := make(map[string]*tracing.Span)
+	var stages []string
+	for _, s := range rec.spans {
+		byID[s.SpanID] = s
+	}
+	for _, s := range rec.spans {
+		stage := s.Name
+		for p := byID[s.ParentSpanID]; p != nil; p = byID[p.ParentSpanID] {
+			stage = p.Name + "/" + stage
+		}
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+
+	want := []string{
+		"sweep",
+		"sweep/region",
+		"sweep/region/filter",
+		"sweep/region/filter/lazy-load",
+		"sweep/region/filter/lazy-load",
+		"sweep/region/list",
+		"sweep/region/setup",
+		"sweep/wipe",
+		"sweep/wipe/delete",
+	}
+	if !reflect.DeepEqual(stages, want) {
+		t.Errorf("expected spans\n%v\ngot\n%v", want, stages)
+	}
+
+	for _, s := range rec.spans {
+		if s.Name == "delete" {
+			for _, want := range []tracing.Attribute{
+				tracing.String("resource.type", "dynamodb_table"),
+				tracing.String("resource.id", "ci-table"),
+				tracing.Int("attempt", 1),
+			} {
+				found := false
+				for _, a := range s.Attributes {
+					found = found || a == want
+				}
+				if !found {
+					t.Errorf("expected attribute %v of the delete span, got %v", want, s.Attributes)
+				}
+			}
+		}
+	}
+}
+
+// memSink keeps an audit log in memory.
+type memSink struct {
+	bytes.Buffer
+}
+
+func (s *memSink) Read() ([]byte, error) { return s.Bytes(), nil }
+
+func (s *memSink) Append(line []byte) error {
+	_, err := s.Write(line)
+	return err
+}
+
+func (s *memSink) Close() error { return nil }
+
+// failingSink fails to append lines.
+type failingSink struct {
+	memSink
+}
+
+func (s *failingSink) Append(line []byte) error {
+	return errors.New("no space left on device")
+}
+
+func TestRun_Audit(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "ci-table", Tags: map[string]string{"team": "ci"}})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "prod-table", Tags: map[string]string{"team": "prod"}})
+	backend.Add(fake.Resource{Kind: fake.MediaLiveInput, Region: "eu-west-1", ID: "input"})
+	backend.Add(fake.Resource{Kind: fake.MediaLiveChannel, Region: "eu-west-1", ID: "channel", DependsOn: []string{"input"}})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{
+		"dynamodb_table":  {{IDs: &[]string{"^prod-"}, Tags: &filters.Tags{{"team": "^prod$"}}}, {Tags: &filters.Tags{{"team": "^ci$"}}}},
+		"medialive_input": {{}},
+	})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+	wiper.Config.Options.Account = "123456789012"
+
+	sink := &memSink{}
+	log, err := audit.Open("run", sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiper.Audit = log
+
+	if _, _, err := wiper.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.End(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := audit.Verify(sink.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := make(map[string]*audit.Resource)
+	for _, e := range entries {
+		if e.RunID != "run" {
+			t.Errorf("expected the run ID in all entries, got %+v", e)
+		}
+		if e.Kind != audit.KindResource {
+			continue
+		}
+		// the intent to delete a resource is recorded once, before its result
+		if previous := results[e.Resource.ID]; (previous == nil) != (e.Resource.Result == audit.ResultDeleting) {
+			t.Errorf("expected the intent to delete %s to be recorded once before its result, got %+v", e.Resource.ID, e.Resource)
+		}
+		results[e.Resource.ID] = e.Resource
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 audited resources, got %v", results)
+	}
+
+	table := results["ci-table"]
+	if table.Result != audit.ResultDeleted || table.Account != "123456789012" || table.Region != "eu-west-1" ||
+		table.Tags["team"] != "ci" || !strings.Contains(table.Filter, "team=^ci$") {
+		t.Errorf("unexpected record of ci-table %+v", table)
+	}
+	if prod := results["prod-table"]; prod.Result != audit.ResultDeleted || !strings.Contains(prod.Filter, "IDS:[^prod-]") {
+		t.Errorf("expected prod-table to be matched by the first filter, got %+v", prod)
+	}
+	if input := results["input"]; input.Result != audit.ResultFailed || !strings.Contains(input.Error, "ConflictException") ||
+		input.Reason == "" {
+		t.Errorf("unexpected record of input %+v", input)
+	}
+
+	if end := entries[len(entries)-1]; end.Kind != audit.KindEnd || end.Deleted != 2 || end.Failed != 1 {
+		t.Errorf("unexpected end entry %+v", end)
+	}
+}
+
+// notificationRecorder keeps the sent notifications with the calls to AWS made before each.
+type notificationRecorder struct {
+	backend       *fake.Backend
+	notifications []*notify.Notification
+	calls         []int
+}
+
+func (r *notificationRecorder) Send(n *notify.Notification) error {
+	r.notifications = append(r.notifications, n)
+	r.calls = append(r.calls, len(r.backend.Calls()))
+	return nil
+}
+
+func TestRun_AuditFailure(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "first"})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "second"})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{}}})
+	log, err := audit.Open("run", &failingSink{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiper.Audit = log
+	rec := &notificationRecorder{backend: backend}
+	wiper.Notifier = &notify.Notifier{Sinks: []notify.Sink{rec}}
+
+	// the intent to delete a resource is recorded before deleting it
+	_, _, err = wiper.Run()
+	if err == nil || !strings.Contains(err.Error(), "no space left on device") {
+		t.Errorf("expected the run to fail, got %v", err)
+	}
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); len(ids) != 2 {
+		t.Errorf("expected no resource to be deleted without a record, got %v", ids)
+	}
+
+	// the summary doesn't claim the resources which weren't attempted to be deleted
+	if len(rec.notifications) != 2 {
+		t.Fatalf("expected a warning and a summary, got %d notifications", len(rec.notifications))
+	}
+	if summary := rec.notifications[1]; summary.Count() != 0 || summary.Failed != 0 || summary.Error == "" {
+		t.Errorf("expected a failed summary without deleted resources, got %+v", summary)
+	}
+}
+
+func TestRunAccounts_AuditFailure(t *testing.T) {
+	backend := fake.New()
+	for _, id := range []string{"first", "second", "third"} {
+		backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: id})
+	}
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"dynamodb_table": {{}}})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+	wiper.Config.Accounts = &config.Accounts{IDs: []string{"111111111111", "222222222222"}}
+	wiper.Accounts = &accounts.Resolver{STS: &fakeSTS{account: "999999999999"}}
+	log, err := audit.Open("run", &failingSink{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiper.Audit = log
+
+	// the accounts after the one failing to write the audit log are not swept
+	_, _, err = wiper.RunAccounts()
+	if err == nil || !strings.Contains(err.Error(), "no space left on device") || !strings.Contains(err.Error(), "111111111111") {
+		t.Errorf("expected the run to fail on the first account, got %v", err)
+	}
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); len(ids) != 3 {
+		t.Errorf("expected no resource to be deleted without a record, got %v", ids)
+	}
+}
+
+func TestRunAccounts_Notify(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "first"})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "second"})
+
+	// each account selects one of the tables
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{})
+	wiper.Config.Options.Regions = []string{"eu-west-1"}
+	wiper.Config.Overrides = []config.Override{
+		{Accounts: []string{"111111111111"}, Filters: map[aws.ResourceType]filters.Filters{"dynamodb_table": {{IDs: &[]string{"^first$"}}}}},
+		{Accounts: []string{"222222222222"}, Filters: map[aws.ResourceType]filters.Filters{"dynamodb_table": {{IDs: &[]string{"^second$"}}}}},
+	}
+	wiper.Config.Accounts = &config.Accounts{IDs: []string{"111111111111", "222222222222"}}
+	wiper.Accounts = &accounts.Resolver{STS: &fakeSTS{account: "999999999999"}}
+	rec := &notificationRecorder{backend: backend}
+	wiper.Notifier = &notify.Notifier{Sinks: []notify.Sink{rec}}
+	wiper.Config.Notify = &config.Notify{GracePeriod: time.Hour}
+
+	var waits int
+	var deletedBeforeWaiting bool
+	sleep = func(time.Duration) {
+		waits++
+		for _, c := range backend.Calls() {
+			deletedBeforeWaiting = deletedBeforeWaiting || strings.HasPrefix(c, "Delete")
+		}
+	}
+	defer func() { sleep = time.Sleep }()
+
+	report, _, err := wiper.RunAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range []string{"111111111111", "222222222222"} {
+		if resources := report[account]; resources.Len() != 1 {
+			t.Errorf("expected a table to be wiped in account %s, got %s", account, resources.String())
+		}
+	}
+	if ids := backend.IDs(fake.DynamoDBTable, "eu-west-1"); len(ids) != 0 {
+		t.Errorf("expected all tables to be deleted, got %v", ids)
+	}
+
+	// one warning about the resources of all accounts, followed by the summary of each account
+	if waits != 1 || deletedBeforeWaiting {
+		t.Errorf("expected to wait once before deleting the resources of any account, waited %d times", waits)
+	}
+	if len(rec.notifications) != 3 {
+		t.Fatalf("expected a warning and two summaries, got %d notifications", len(rec.notifications))
+	}
+	if warning := rec.notifications[0]; warning.Event != notify.EventWarning || warning.Count() != 2 {
+		t.Errorf("expected a warning about both tables, got %+v", warning)
+	}
+	for i, account := range []string{"111111111111", "222222222222"} {
+		if summary := rec.notifications[i+1]; summary.Event != notify.EventSummary || summary.Account != account || summary.Count() != 1 {
+			t.Errorf("expected the summary of account %s, got %+v", account, summary)
+		}
+	}
+}
+
+func TestRun_Notify(t *testing.T) {
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "eu-west-1", ID: "ci-table", Tags: map[string]string{"owner": "ci"}})
+	backend.Add(fake.Resource{Kind: fake.DynamoDBTable, Region: "us-east-1", ID: "ci-us-table", Tags: map[string]string{"owner": "ci"}})
+	backend.Add(fake.Resource{Kind: fake.MediaLiveInput, Region: "eu-west-1", ID: "input"})
+	backend.Add(fake.Resource{Kind: fake.MediaLiveChannel, Region: "eu-west-1", ID: "channel", DependsOn: []string{"input"}})
+
+	wiper := fakeWiper(backend, map[aws.ResourceType]filters.Filters{
+		"dynamodb_table":  {{IDs: &[]string{"^ci-"}}},
+		"medialive_input": {{}},
+	})
+	rec := &notificationRecorder{backend: backend}
+	wiper.Notifier = &notify.Notifier{Sinks: []notify.Sink{rec}}
+	wiper.Config.Notify = &config.Notify{GracePeriod: time.Hour}
+
+	var waited time.Duration
+	var warned, deletedBeforeWaiting bool
+	sleep = func(d time.Duration) {
+		waited, warned = d, len(rec.notifications) == 1
+		for _, c := range backend.Calls() {
+			deletedBeforeWaiting = deletedBeforeWaiting || strings.HasPrefix(c, "Delete")
+		}
+	}
+	defer func() { sleep = time.Sleep }()
+
+	if _, _, err := wiper.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if waited != time.Hour || !warned || deletedBeforeWaiting {
+		t.Errorf("expected to wait for the grace period after the warning and before deleting, waited %v", waited)
+	}
+
+	if len(rec.notifications) != 2 {
+		t.Fatalf("expected a warning and a summary, got %d notifications", len(rec.notifications))
+	}
+
+	warning, summary := rec.notifications[0], rec.notifications[1]
+	if warning.Event != notify.EventWarning || warning.Count() != 3 || len(warning.Owners) != 2 ||
+		warning.Owners[0].Owner != "ci" || len(warning.Owners[0].Resources) != 2 {
+		t.Errorf("unexpected warning %+v", warning)
+	}
+	for _, c := range backend.Calls()[:rec.calls[0]] {
+		if strings.HasPrefix(c, "Delete") {
+			t.Errorf("expected the warning to be sent before deleting resources, got %s before", c)
+		}
+	}
+
+	if summary.Event != notify.EventSummary || summary.Count() != 2 || summary.Failed != 1 || len(summary.Warnings) != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
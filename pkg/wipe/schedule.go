@@ -0,0 +1,193 @@
+package wipe
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// errSkipped is returned by a schedule action for resources it left untouched.
+var errSkipped = errors.New("skipped")
+
+// defaultScaleDownCapacity is the provisioned capacity tables are reduced to unless the schedule says otherwise.
+const defaultScaleDownCapacity = 1
+
+// Stop stops (or scales down) all resources matched by scheduled filters which are outside of their office hours.
+// The state before stopping is persisted so that Start can restore it. With force, office hours are ignored.
+func (c *Wiper) Stop(force bool) (aws.IRegionResourceTypeResources, []error, error) {
+	state, err := LoadState(c.Config.Options.StateFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stopped, warnings, err := c.schedule(func(region aws.Region, resType aws.ResourceType, r aws.IResource, s filters.Schedule) error {
+		officeHours, err := s.IsOfficeHours(time.Now())
+		if err != nil {
+			return err
+		}
+
+		if officeHours && !force {
+			logSchedule(resType, r, "stop").Debug("Within office hours. Not stopping")
+			return errSkipped
+		}
+
+		key := stateKey(region, resType, r.GetID())
+		if _, ok := state.Resources[key]; ok {
+			logSchedule(resType, r, "stop").Debug("Resource is already stopped")
+			return errSkipped
+		}
+
+		if c.Config.Options.DryRun {
+			return nil
+		}
+
+		rs, err := r.(aws.IStoppable).Stop(scaleDown(s))
+		if err != nil {
+			return err
+		}
+
+		state.Resources[key] = rs
+		return nil
+	})
+	return stopped, warnings, c.saveState(state, err)
+}
+
+// Start restores all resources matched by scheduled filters which are within their office hours
+// to the state persisted by Stop. With force, office hours are ignored.
+func (c *Wiper) Start(force bool) (aws.IRegionResourceTypeResources, []error, error) {
+	state, err := LoadState(c.Config.Options.StateFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	started, warnings, err := c.schedule(func(region aws.Region, resType aws.ResourceType, r aws.IResource, s filters.Schedule) error {
+		officeHours, err := s.IsOfficeHours(time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !officeHours && !force {
+			logSchedule(resType, r, "start").Debug("Outside of office hours. Not starting")
+			return errSkipped
+		}
+
+		key := stateKey(region, resType, r.GetID())
+		rs, ok := state.Resources[key]
+		if !ok {
+			logSchedule(resType, r, "start").Debug("Resource has not been stopped by awsweeper. Not starting")
+			return errSkipped
+		}
+
+		if c.Config.Options.DryRun {
+			return nil
+		}
+
+		if err := r.(aws.IStoppable).Start(rs); err != nil {
+			return err
+		}
+
+		delete(state.Resources, key)
+		return nil
+	})
+	return started, warnings, c.saveState(state, err)
+}
+
+// saveState saves the state unless in dry run. It is also saved if applying the schedules failed partway (err),
+// so that the resources stopped before the failure can still be started.
+func (c *Wiper) saveState(state *State, err error) error {
+	if c.Config.Options.DryRun {
+		return err
+	}
+
+	if saveErr := state.Save(c.Config.Options.StateFile); saveErr != nil {
+		if err != nil {
+			logrus.WithError(saveErr).Error("Failed to save the state")
+			return err
+		}
+		return saveErr
+	}
+
+	return err
+}
+
+// schedule applies all scheduled filters and calls action for every matched resource that can be stopped.
+// It returns the resources for which action succeeded, also those before an error.
+func (c *Wiper) schedule(action func(aws.Region, aws.ResourceType, aws.IResource, filters.Schedule) error) (aws.IRegionResourceTypeResources, []error, error) {
+	if err := c.resolveAccount(); err != nil {
+		return nil, nil, err
+	}
+	defer logging.Push(logrus.Fields{logging.Account: c.Config.Options.Account})()
+
+	var warnings []error
+	var resources aws.IRegionResourceTypeResources = make(aws.IRegionResourceTypeResources)
+
+	logrus.WithField("dry_run", c.Config.Options.DryRun).Info("Applying schedules")
+	regions, err := c.regions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, region := range regions {
+		restore := logging.Push(logrus.Fields{logging.Region: region})
+		logrus.Info("Applying schedules in region")
+		resources[region] = make(aws.IResourceTypeResources)
+
+		if err := c.register(region); err != nil {
+			restore()
+			return resources, warnings, err
+		}
+
+		for resType, fs := range c.Config.FiltersFor(c.Config.Options.Account, region) {
+			for _, f := range fs.Scheduled() {
+				var matched aws.IResources
+				c.getFilteredResources(region, resType, filters.Filters{f}, &matched, &warnings)
+
+				for _, r := range matched {
+					if _, ok := r.(aws.IStoppable); !ok {
+						warnings = append(warnings, fmt.Errorf("ResourceType (%v) can not be stopped", resType))
+						break
+					}
+
+					if err := action(region, resType, r, *f.Schedule); err == errSkipped {
+						continue
+					} else if err != nil {
+						logSchedule(resType, r, "schedule").WithError(err).Error("Failed to apply schedule to a resource")
+						warnings = append(warnings, err)
+						continue
+					}
+
+					resources[region][resType] = append(resources[region][resType], r)
+				}
+			}
+		}
+		restore()
+	}
+
+	return resources, warnings, nil
+}
+
+// logSchedule returns the logger of a schedule action (stop or start) on a resource.
+func logSchedule(resourceType aws.ResourceType, r aws.IResource, action string) *logrus.Entry {
+	return logrus.WithFields(logrus.Fields{
+		logging.Type:   resourceType,
+		logging.ID:     r.GetID(),
+		logging.Action: action,
+	})
+}
+
+func scaleDown(s filters.Schedule) aws.ScaleDown {
+	target := aws.ScaleDown{ReadCapacity: defaultScaleDownCapacity, WriteCapacity: defaultScaleDownCapacity}
+	if s.ReadCapacity != nil {
+		target.ReadCapacity = *s.ReadCapacity
+	}
+	if s.WriteCapacity != nil {
+		target.WriteCapacity = *s.WriteCapacity
+	}
+
+	return target
+}
@@ -0,0 +1,87 @@
+package wipe
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/aws/fake"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/spf13/afero"
+)
+
+func TestStopStart(t *testing.T) {
+	config.AppFs = afero.NewMemMapFs()
+	defer func() { config.AppFs = afero.NewOsFs() }()
+
+	backend := fake.New()
+	backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: "eu-west-1", ID: "i-running"})
+	backend.Add(fake.Resource{Kind: fake.EC2Instance, Region: "eu-west-1", ID: "i-stopped", Stopped: true})
+
+	wiper := func() *Wiper {
+		w := fakeWiper(backend, map[aws.ResourceType]filters.Filters{"ec2": {{Schedule: &filters.Schedule{}}}})
+		w.Config.Options.Regions = []string{"eu-west-1"}
+		w.Config.Options.StateFile = "state.json"
+		return w
+	}
+
+	stopped, _, err := wiper().Stop(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Len() != 2 {
+		t.Errorf("expected both instances to be stopped, got %s", stopped.String())
+	}
+
+	state, err := LoadState("state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]aws.ResourceState{
+		"eu-west-1/ec2/i-running": {"status": "running"},
+		"eu-west-1/ec2/i-stopped": {"status": "stopped"},
+	}
+	if !reflect.DeepEqual(state.Resources, expected) {
+		t.Errorf("expected the state before stopping to be persisted, got %v", state.Resources)
+	}
+
+	// stopped resources are not stopped again, so their state is kept
+	if stopped, _, err := wiper().Stop(true); err != nil || stopped.Len() != 0 {
+		t.Errorf("expected no instance to be stopped again, got %s: %v", stopped.String(), err)
+	}
+
+	started, _, err := wiper().Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Len() != 2 {
+		t.Errorf("expected both instances to be restored, got %s", started.String())
+	}
+
+	// only the instance running before is started
+	if indexOf(backend.Calls(), "StartInstances i-running") < 0 || indexOf(backend.Calls(), "StartInstances i-stopped") >= 0 {
+		t.Errorf("expected only i-running to be started, got %v", backend.Calls())
+	}
+
+	state, err = LoadState("state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Resources) != 0 {
+		t.Errorf("expected the restored resources to be removed from the state, got %v", state.Resources)
+	}
+}
+
+func TestLoadState_Missing(t *testing.T) {
+	config.AppFs = afero.NewMemMapFs()
+	defer func() { config.AppFs = afero.NewOsFs() }()
+
+	state, err := LoadState("missing.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Resources == nil || len(state.Resources) != 0 {
+		t.Errorf("expected an empty state, got %v", state.Resources)
+	}
+}
@@ -0,0 +1,121 @@
+package wipe
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/terraform"
+)
+
+func stackResources() aws.IResourceTypeResources {
+	return aws.IResourceTypeResources{
+		"ec2": {
+			&aws.Instance{ID: awssdk.String("i-1"), Tags: aws.Tags{aws.CloudFormationStackNameTag: "web"}},
+			&aws.Instance{ID: awssdk.String("i-2")},
+		},
+		aws.CloudFormationStackType: {
+			&aws.CloudFormationStack{ID: awssdk.String("web")},
+		},
+	}
+}
+
+func resourceIDs(rtrs aws.IResourceTypeResources) []string {
+	var ids []string
+	for _, resources := range rtrs {
+		for _, r := range resources {
+			ids = append(ids, r.GetID())
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestHandleStackResources(t *testing.T) {
+	tests := []struct {
+		policy   string
+		expected []string
+	}{
+		{"", []string{"i-1", "i-2", "web"}},
+		{config.StackResourcesSkip, []string{"i-2", "web"}},
+		{config.StackResourcesDeleteStack, []string{"i-2", "web"}},
+		{config.StackResourcesDelete, []string{"i-1", "i-2", "web"}},
+	}
+
+	for _, tc := range tests {
+		wiper := &Wiper{Config: &config.Config{Options: config.Options{StackResources: tc.policy}}}
+		rtrs := stackResources()
+
+		var warnings []error
+		wiper.handleStackResources(rtrs, nil, &warnings)
+		if len(warnings) > 0 {
+			t.Errorf("%s: unexpected warnings %v", tc.policy, warnings)
+		}
+
+		if ids := resourceIDs(rtrs); !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.policy, tc.expected, ids)
+		}
+	}
+}
+
+// fakeCloudFormation lists stacks.
+type fakeCloudFormation struct {
+	cloudformationiface.CloudFormationAPI
+	stacks []string
+}
+
+func (f *fakeCloudFormation) DescribeStacksPages(input *cloudformation.DescribeStacksInput, fn func(*cloudformation.DescribeStacksOutput, bool) bool) error {
+	output := &cloudformation.DescribeStacksOutput{}
+	for _, name := range f.stacks {
+		output.Stacks = append(output.Stacks, &cloudformation.Stack{
+			StackName:   awssdk.String(name),
+			StackStatus: awssdk.String(cloudformation.StackStatusCreateComplete),
+		})
+	}
+	fn(output, true)
+	return nil
+}
+
+func TestHandleStackResources_UnmatchedStack(t *testing.T) {
+	aws.NewWithClients(&aws.Clients{CloudFormation: &fakeCloudFormation{stacks: []string{"web", "api"}}})
+
+	managedStack := terraform.NewManaged()
+	if err := managedStack.Add([]byte(`{"version": 4, "resources": [{"mode": "managed", "type": "aws_cloudformation_stack",
+		"instances": [{"attributes": {"id": "web", "name": "web"}}]}]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		managed  *terraform.Managed
+		expected []string
+	}{
+		{name: "listed stack", expected: []string{"i-2", "web"}},
+		{name: "stack managed by Terraform", managed: managedStack, expected: []string{"i-2"}},
+	}
+
+	for _, tc := range tests {
+		wiper := &Wiper{Config: &config.Config{Options: config.Options{StackResources: config.StackResourcesDeleteStack}}}
+		if tc.managed != nil {
+			wiper.Config.Terraform = &config.Terraform{States: []string{"terraform.tfstate"}, Mode: config.TerraformProtect}
+		}
+		rtrs := stackResources()
+		// the filters only match the resources of the stack
+		delete(rtrs, aws.CloudFormationStackType)
+
+		var warnings []error
+		wiper.handleStackResources(rtrs, tc.managed, &warnings)
+		if len(warnings) > 0 {
+			t.Errorf("%s: unexpected warnings %v", tc.name, warnings)
+		}
+
+		if ids := resourceIDs(rtrs); !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, ids)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package wipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/spf13/afero"
+)
+
+// State is the persisted state of resources stopped by a scheduled scale-down.
+type State struct {
+	Resources map[string]aws.ResourceState `json:"resources"`
+}
+
+func stateKey(region aws.Region, resourceType aws.ResourceType, id string) string {
+	return fmt.Sprintf("%s/%s/%s", region, resourceType, id)
+}
+
+// LoadState reads the state file. A missing file results in an empty state.
+func LoadState(filename string) (*State, error) {
+	state := &State{Resources: make(map[string]aws.ResourceState)}
+
+	data, err := afero.ReadFile(config.AppFs, filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+
+	if state.Resources == nil {
+		state.Resources = make(map[string]aws.ResourceState)
+	}
+
+	return state, nil
+}
+
+// Save writes the state file.
+func (s *State) Save(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(config.AppFs, filename, data, 0600)
+}
@@ -1,68 +1,572 @@
 package wipe
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cmpsoares91/awsweeper/pkg/accounts"
+	"github.com/cmpsoares91/awsweeper/pkg/audit"
 	"github.com/cmpsoares91/awsweeper/pkg/aws"
 	"github.com/cmpsoares91/awsweeper/pkg/config"
 	"github.com/cmpsoares91/awsweeper/pkg/filters"
+	"github.com/cmpsoares91/awsweeper/pkg/inventory"
+	"github.com/cmpsoares91/awsweeper/pkg/logging"
+	"github.com/cmpsoares91/awsweeper/pkg/metrics"
+	"github.com/cmpsoares91/awsweeper/pkg/notify"
+	"github.com/cmpsoares91/awsweeper/pkg/terraform"
+	"github.com/cmpsoares91/awsweeper/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
 type Wiper struct {
 	Config *config.Config
+
+	// Accounts resolves the accounts to sweep. If nil, it is created using the configured credentials.
+	Accounts *accounts.Resolver
+
+	// Managed are the resources managed by Terraform. If nil, they are loaded from the configured Terraform states.
+	Managed *terraform.Managed
+
+	// Clients returns the service clients of a region. If nil, they are created using the configured session options.
+	Clients func(region string) *aws.Clients
+
+	// Audit records the deleted resources. If nil, nothing is recorded.
+	Audit *audit.Log
+
+	// Notifier sends a warning listing the resources to delete before deleting them and a summary of the run.
+	// If nil, nothing is sent.
+	Notifier *notify.Notifier
+
+	// Only restricts the deletion to the resources with these inventory keys, e.g. of an approved plan. If nil, all
+	// selected resources are deleted.
+	Only map[string]bool
+
+	// selection is why each resource to delete was selected, for the audit log.
+	selection map[aws.IResource]audit.Resource
+	// auditErr is the first error writing the audit log. No more resources are deleted once it is set.
+	auditErr error
+}
+
+// deleteAttempts is the number of times the deletion of a resource is attempted, e.g. while resources depending
+// on it are still being deleted or requests are throttled.
+const deleteAttempts = 3
+
+// retryDelay is waited before retrying failed deletions, multiplied by the number of the attempt.
+var retryDelay = 10 * time.Second
+
+// Run sweeps the account: it selects the resources to delete in all regions, sends the warning about them and
+// deletes them.
+func (c *Wiper) Run() (resources aws.IRegionResourceTypeResources, warnings []error, err error) {
+	if err := c.resolveAccount(); err != nil {
+		return nil, nil, err
+	}
+
+	end := c.startSweep()
+	defer func() { end(resources, warnings, err) }()
+
+	s, err := c.selectAll()
+	if err != nil {
+		return nil, s.warnings, err
+	}
+
+	c.warn(s.items)
+	return c.apply(s)
+}
+
+// selection are the resources selected in the regions of an account, with their inventory items to notify about.
+type selection struct {
+	regions   []string
+	resources aws.IRegionResourceTypeResources
+	items     map[aws.IResource]inventory.Item
+	warnings  []error
+}
+
+// startSweep starts the span and the logging fields of sweeping the account. The returned function ends them.
+func (c *Wiper) startSweep() func(resources aws.IRegionResourceTypeResources, warnings []error, err error) {
+	span := tracing.Start("sweep",
+		tracing.String("account", c.Config.Options.Account),
+		tracing.Bool("dry_run", c.Config.Options.DryRun),
+	)
+	restore := logging.Push(logrus.Fields{logging.Account: c.Config.Options.Account})
+
+	return func(resources aws.IRegionResourceTypeResources, warnings []error, err error) {
+		restore()
+		span.SetAttributes(tracing.Int("resources", resources.Len()), tracing.Int("warnings", len(warnings)))
+		span.SetError(err)
+		span.End()
+	}
+}
+
+// selectAll selects the resources to delete in all regions. If that fails, the summary of the run is sent.
+func (c *Wiper) selectAll() (s *selection, err error) {
+	s = &selection{resources: make(aws.IRegionResourceTypeResources)}
+	defer func() {
+		if err != nil {
+			c.summarize(nil, nil, 0, s.warnings, err)
+		}
+	}()
+
+	logrus.WithField("dry_run", c.Config.Options.DryRun).Info("Sweeping resources")
+	s.regions, err = c.regions()
+	if err != nil {
+		return s, err
+	}
+
+	managed, err := c.managed()
+	if err != nil {
+		return s, err
+	}
+
+	for _, region := range s.regions {
+		if err := c.selectRegion(region, managed, s.resources, &s.warnings); err != nil {
+			return s, err
+		}
+	}
+
+	s.items = c.items(s.resources)
+	return s, nil
+}
+
+// apply deletes the selected resources region by region and sends the summary of the run.
+func (c *Wiper) apply(s *selection) (resources aws.IRegionResourceTypeResources, warnings []error, err error) {
+	var deleted, failed aws.IResources
+	warnings = s.warnings
+	defer func() {
+		c.summarize(s.items, deleted, len(failed), warnings, err)
+	}()
+
+	for _, region := range s.regions {
+		d, f := c.wipe(region, s.resources[region], &warnings)
+		deleted, failed = append(deleted, d...), append(failed, f...)
+		if c.auditErr != nil {
+			return s.resources, warnings, fmt.Errorf("stopped deleting resources, failed to write the audit log: %v", c.auditErr)
+		}
+	}
+
+	return s.resources, warnings, nil
+}
+
+// selectRegion filters the resources of a region to wipe.
+func (c *Wiper) selectRegion(region string, managed *terraform.Managed, resourcesToWipe aws.IRegionResourceTypeResources, warnings *[]error) error {
+	span := tracing.Start("region", tracing.String("region", region))
+	defer span.End()
+	defer logging.Push(logrus.Fields{logging.Region: region})()
+
+	logrus.Info("Sweeping region")
+	resourcesToWipe[region] = make(aws.IResourceTypeResources)
+
+	if err := c.register(region); err != nil {
+		return err
+	}
+
+	for resType, filters := range c.Config.FiltersFor(c.Config.Options.Account, region) {
+		deletionFilters := filters.Deletion()
+		if len(filters) > 0 && len(deletionFilters) == 0 {
+			logrus.WithField(logging.Type, resType).Debug("Only scheduled filters configured. Skipping deletion")
+			continue
+		}
+
+		rs := resourcesToWipe[region][resType]
+		c.getFilteredResources(region, resType, deletionFilters, &rs, warnings)
+		rs = excludeScheduled(resType, filters.Scheduled(), rs, warnings)
+		if managed != nil {
+			rs = c.excludeManaged(managed, resType, rs)
+		}
+		resourcesToWipe[region][resType] = rs
+	}
+
+	c.handleStackResources(resourcesToWipe[region], managed, warnings)
+	c.restrict(region, resourcesToWipe[region])
+
+	logrus.WithField("count", resourcesToWipe.Len()).Info("Final number of filtered resources")
+	return nil
 }
 
-func (c *Wiper) Run() (aws.IRegionResourceTypeResources, []error, error) {
-	var warnings []error
-	var resourcesToWipe aws.IRegionResourceTypeResources = make(aws.IRegionResourceTypeResources)
+// register registers the resource types of a region with its service clients.
+func (c *Wiper) register(region string) error {
+	span := tracing.Start("setup", tracing.String("region", region))
+	defer span.End()
 
-	logrus.WithField("DryMode", c.Config.Options.DryRun).Info()
-	for _, region := range c.Config.Options.Regions {
-		logrus.WithField("Region", region).Info()
-		resourcesToWipe[region] = make(aws.IResourceTypeResources)
+	if c.Clients != nil {
+		aws.NewWithClients(c.Clients(region))
+		return nil
+	}
+
+	err := aws.New(region, c.Config.Options.SessionOptions())
+	span.SetError(err)
+	return err
+}
+
+// regions returns the configured regions without the excluded ones. The region "all" is resolved to all
+// regions of the partition which are enabled in the account.
+func (c *Wiper) regions() (regions []string, err error) {
+	opts := c.Config.Options
+	excluded := make(map[string]bool)
+	for _, r := range opts.ExcludeRegions {
+		excluded[r] = true
+	}
 
-		aws.New(region, c.Config.Options.MaxRetries, c.Config.Options.RoleToAssume)
-		for resType, filters := range c.Config.Filters {
-			rs := resourcesToWipe[region][resType]
-			c.getFilteredResources(resType, filters, &rs, &warnings)
-			resourcesToWipe[region][resType] = rs
+	for _, r := range opts.Regions {
+		candidates := []string{r}
+		if r == aws.AllRegions {
+			candidates, err = aws.ListRegions(opts.PartitionID(), opts.SessionOptions())
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		logrus.WithField("Number of Resources", resourcesToWipe.Len()).Info("Final number of filtered resources")
-		c.wipe(&resourcesToWipe)
+		for _, candidate := range candidates {
+			if !excluded[candidate] {
+				excluded[candidate] = true
+				regions = append(regions, candidate)
+			}
+		}
 	}
 
-	return resourcesToWipe, warnings, nil
+	logrus.WithField("regions", regions).Debug("Resolved regions")
+	return regions, nil
 }
 
-func (c *Wiper) getFilteredResources(resourceType aws.ResourceType, filters filters.Filters, rs *aws.IResources, warnings *[]error) {
-	logrus.WithField("Resource Type", resourceType).Info("Fetching resources")
+// loaded returns the resources whose attributes (e.g. tags) can be loaded, the others are added to the warnings.
+func loaded(resources aws.IResources, warnings *[]error) (loaded aws.IResources) {
+	for _, r := range resources {
+		if err := r.EnsureLazyLoaded(); err != nil {
+			*warnings = append(*warnings, fmt.Errorf("Skipping %s: %v", r.GetID(), err))
+			continue
+		}
+		loaded = append(loaded, r)
+	}
 
-	if candidateResources, err := aws.List(resourceType); err != nil {
+	return loaded
+}
+
+func (c *Wiper) getFilteredResources(region string, resourceType aws.ResourceType, filters filters.Filters, rs *aws.IResources, warnings *[]error) {
+	defer logging.Push(logrus.Fields{logging.Type: resourceType})()
+	logrus.WithField(logging.Action, "list").Info("Fetching resources")
+
+	candidateResources, err := list(resourceType)
+	if err != nil {
 		*warnings = append(*warnings, err)
-	} else {
-		logrus.WithField("Number of Resources", len(candidateResources)).Debug("Got candidate resources")
-		if deletableResources, err := filters.Apply(candidateResources); err != nil {
-			*warnings = append(*warnings, err)
-		} else {
-			*rs = append(*rs, deletableResources...)
-		}
-	}
-}
-
-// wipe does the actual deletion (in parallel) of a given (filtered) list of AWS resources.
-// (so we get retries, detaching of policies from some IAM resources before deletion, and other stuff for free).
-func (c *Wiper) wipe(rtrs *aws.IRegionResourceTypeResources) {
-	if c.Config.Options.DryRun == false {
-		for _, trs := range *rtrs {
-			for _, resources := range trs {
-				for _, resource := range resources {
-					if err := resource.Delete(); err != nil {
-						logrus.WithError(err).WithField("Resource", resource).Error("Failed to delete a resource")
-					}
-				}
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		logging.Action: "list",
+		"count":        len(candidateResources),
+	}).Debug("Got candidate resources")
+	metrics.Discovered.Add(float64(len(candidateResources)), region, string(resourceType))
+
+	span := tracing.Start("filter", tracing.String("resource.type", string(resourceType)))
+	defer span.End()
+
+	deletableResources, err := filters.Apply(candidateResources)
+	if err != nil {
+		span.SetError(err)
+		*warnings = append(*warnings, err)
+		return
+	}
+
+	// only resources whose attributes are known are selected, e.g. not those matched by a "not" filter because
+	// their tags couldn't be loaded
+	deletableResources = loaded(deletableResources, warnings)
+	span.SetAttributes(tracing.Int("resources", len(deletableResources)))
+	c.selectedBy(deletableResources, filters)
+	metrics.Matched.Add(float64(len(deletableResources)), region, string(resourceType))
+	*rs = append(*rs, deletableResources...)
+}
+
+// list lists the resources of a type in the registered region.
+func list(resourceType aws.ResourceType) (aws.IResources, error) {
+	span := tracing.Start("list", tracing.String("resource.type", string(resourceType)))
+	defer span.End()
+
+	resources, err := aws.List(resourceType)
+	span.SetAttributes(tracing.Int("resources", len(resources)))
+	span.SetError(err)
+	return resources, err
+}
+
+// managed returns the resources managed by Terraform, loading the configured states once.
+// Without Terraform states, it returns nil.
+func (c *Wiper) managed() (*terraform.Managed, error) {
+	if c.Managed != nil || c.Config.Terraform == nil {
+		return c.Managed, nil
+	}
+
+	sess, cfg, err := aws.NewSession(aws.DefaultRegion(c.Config.Options.PartitionID()), c.Config.Options.SessionOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	managed, err := terraform.NewLoader(sess, cfg).Load(c.Config.Terraform.States)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithField("count", managed.Len()).Info("Loaded resources managed by Terraform")
+	c.Managed = managed
+	return managed, nil
+}
+
+// excludeScheduled removes the resources matched by scheduled filters, which are stopped and started instead of
+// being deleted, even if a deletion filter of the same type matches them as well.
+func excludeScheduled(resourceType aws.ResourceType, scheduled filters.Filters, resources aws.IResources, warnings *[]error) aws.IResources {
+	if len(scheduled) == 0 || len(resources) == 0 {
+		return resources
+	}
+
+	matched, err := scheduled.Apply(resources)
+	if err != nil {
+		// rather keep all resources than delete scheduled ones
+		*warnings = append(*warnings, err)
+		return nil
+	}
+
+	isScheduled := make(map[aws.IResource]bool)
+	for _, r := range matched {
+		isScheduled[r] = true
+	}
+
+	var deletable aws.IResources
+	for _, r := range resources {
+		if isScheduled[r] {
+			logrus.WithFields(logrus.Fields{
+				logging.Type:   resourceType,
+				logging.ID:     r.GetID(),
+				logging.Action: "skip",
+			}).Info("Resource is matched by a scheduled filter. Skipping")
+			continue
+		}
+		deletable = append(deletable, r)
+	}
+
+	return deletable
+}
+
+// excludeManaged removes the resources managed by Terraform. In unmanaged mode, all resources of types
+// not managed by Terraform are removed as well.
+func (c *Wiper) excludeManaged(managed *terraform.Managed, resourceType aws.ResourceType, resources aws.IResources) aws.IResources {
+	if c.Config.Terraform.Mode == config.TerraformUnmanaged && !managed.ManagesType(resourceType) {
+		if len(resources) > 0 {
+			logrus.WithFields(logrus.Fields{
+				logging.Type:   resourceType,
+				logging.Action: "skip",
+			}).Info("Resource type is not managed by Terraform. Skipping")
+		}
+		return nil
+	}
+
+	var unmanaged aws.IResources
+	for _, r := range resources {
+		if managed.Contains(r) {
+			logrus.WithFields(logrus.Fields{
+				logging.Type:   resourceType,
+				logging.ID:     r.GetID(),
+				logging.Action: "skip",
+			}).Info("Resource is managed by Terraform. Skipping")
+			continue
+		}
+		unmanaged = append(unmanaged, r)
+	}
+
+	return unmanaged
+}
+
+// handleStackResources applies the configured policy to resources belonging to a CloudFormation stack: they are
+// skipped, or replaced by their stacks, unless they are to be deleted individually.
+func (c *Wiper) handleStackResources(rtrs aws.IResourceTypeResources, managed *terraform.Managed, warnings *[]error) {
+	// without a policy (e.g. of sweep-deployment), they are deleted individually as by default
+	policy := c.Config.Options.StackResources
+	if policy == "" || policy == config.StackResourcesDelete {
+		return
+	}
+
+	stacks := make(map[string]bool)
+	skipped := 0
+	for resType, resources := range rtrs {
+		if resType == aws.CloudFormationStackType {
+			continue
+		}
+
+		var standalone aws.IResources
+		for _, r := range loaded(resources, warnings) {
+			stack := ""
+			if tags := r.GetTags(); tags != nil {
+				stack = (*tags)[aws.CloudFormationStackNameTag]
+			}
+
+			if stack == "" {
+				standalone = append(standalone, r)
+				continue
+			}
+
+			logger := logrus.WithFields(logrus.Fields{
+				logging.Type:   resType,
+				logging.ID:     r.GetID(),
+				logging.Action: "skip",
+				"stack":        stack,
+			})
+			if policy == config.StackResourcesDeleteStack {
+				logger.Info("Resource belongs to a stack. Deleting the stack instead")
+				stacks[stack] = true
+			} else {
+				logger.Info("Resource belongs to a stack. Skipping")
+				skipped++
 			}
 		}
-	} else {
+		rtrs[resType] = standalone
+	}
+
+	if skipped > 0 {
+		logrus.WithField("count", skipped).Warn("Resources belonging to CloudFormation stacks were skipped. " +
+			"Set the stack-resources option to delete-stack or delete to delete them")
+	}
+
+	// stacks already matched by the filters are deleted anyway
+	for _, r := range rtrs[aws.CloudFormationStackType] {
+		delete(stacks, r.GetID())
+	}
+
+	if len(stacks) == 0 {
+		return
+	}
+
+	candidates, err := aws.List(aws.CloudFormationStackType)
+	if err != nil {
+		*warnings = append(*warnings, err)
+		return
+	}
+
+	var owning aws.IResources
+	for _, r := range candidates {
+		if stacks[r.GetID()] {
+			owning = append(owning, r)
+			delete(stacks, r.GetID())
+		}
+	}
+
+	// stacks managed by Terraform are kept like any other resource, even if they own resources to delete
+	if managed != nil {
+		owning = c.excludeManaged(managed, aws.CloudFormationStackType, owning)
+	}
+
+	for _, r := range owning {
+		c.selected(r, audit.Resource{Reason: "the stack owns resources matched by the filters"})
+		rtrs[aws.CloudFormationStackType] = append(rtrs[aws.CloudFormationStackType], r)
+	}
+
+	for stack := range stacks {
+		*warnings = append(*warnings, fmt.Errorf("CloudFormation stack %s owning resources to delete was not found", stack))
+	}
+}
+
+// wipe deletes the (filtered) resources of a region, those of the resource types with the highest priority first.
+// Failed deletions are retried after the other resources have been deleted, failing ones are added to the warnings.
+// It returns the deleted and the failed resources, resources it didn't attempt to delete are in neither.
+func (c *Wiper) wipe(region string, rtrs aws.IResourceTypeResources, warnings *[]error) (deleted, failed aws.IResources) {
+	span := tracing.Start("wipe", tracing.String("region", region))
+	defer span.End()
+	defer logging.Push(logrus.Fields{logging.Region: region})()
+
+	if c.Config.Options.DryRun {
 		logrus.Info("Skip deleting resources because DryRun mode is ON")
+		if c.Audit != nil {
+			for resType, resources := range rtrs {
+				for _, r := range resources {
+					c.recordResult(c.snapshot(region, resType, r), audit.ResultDryRun, nil)
+				}
+			}
+		}
+		return nil, nil
+	}
+
+	var resourceTypes []aws.ResourceType
+	for resType := range rtrs {
+		resourceTypes = append(resourceTypes, resType)
+	}
+	sort.Slice(resourceTypes, func(i, j int) bool {
+		pi, pj := aws.Priority(resourceTypes[i]), aws.Priority(resourceTypes[j])
+		if pi != pj {
+			return pi > pj
+		}
+		return resourceTypes[i] < resourceTypes[j]
+	})
+
+	var pending aws.IResources
+	typeOf := make(map[aws.IResource]aws.ResourceType)
+	snapshots := make(map[aws.IResource]audit.Resource)
+	for _, resType := range resourceTypes {
+		for _, resource := range rtrs[resType] {
+			pending = append(pending, resource)
+			typeOf[resource] = resType
+			if c.Audit != nil {
+				snapshots[resource] = c.snapshot(region, resType, resource)
+			}
+		}
+	}
+
+	errs := make(map[aws.IResource]error)
+	for attempt := 1; attempt <= deleteAttempts && len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			logrus.WithFields(logrus.Fields{
+				"attempt": attempt,
+				"count":   len(pending),
+			}).Info("Retrying failed deletions")
+			time.Sleep(time.Duration(attempt-1) * retryDelay)
+		}
+
+		var retry aws.IResources
+		for _, resource := range pending {
+			if c.Audit != nil && attempt == 1 {
+				c.recordResult(snapshots[resource], audit.ResultDeleting, nil)
+			}
+			// resources are only deleted as long as their deletion can be recorded
+			if c.auditErr != nil {
+				return deleted, nil
+			}
+
+			if err := deleteResource(typeOf[resource], resource, attempt); err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					logging.Type:   typeOf[resource],
+					logging.ID:     resource.GetID(),
+					logging.Action: "delete",
+					"attempt":      attempt,
+				}).Error("Failed to delete a resource")
+				errs[resource] = err
+				retry = append(retry, resource)
+				continue
+			}
+			deleted = append(deleted, resource)
+			metrics.Deleted.Inc(region, string(typeOf[resource]))
+			if c.Audit != nil {
+				c.recordResult(snapshots[resource], audit.ResultDeleted, nil)
+			}
+		}
+		pending = retry
+	}
+
+	for _, resource := range pending {
+		metrics.Failed.Inc(region, string(typeOf[resource]))
+		if c.Audit != nil {
+			c.recordResult(snapshots[resource], audit.ResultFailed, errs[resource])
+		}
+		*warnings = append(*warnings, fmt.Errorf("Failed to delete %s: %v", resource.GetID(), errs[resource]))
 	}
+
+	return deleted, pending
+}
+
+// deleteResource deletes a resource of a type.
+func deleteResource(resourceType aws.ResourceType, r aws.IResource, attempt int) error {
+	span := tracing.Start("delete",
+		tracing.String("resource.type", string(resourceType)),
+		tracing.String("resource.id", r.GetID()),
+		tracing.Int("attempt", attempt),
+	)
+	defer span.End()
+
+	err := r.Delete()
+	span.SetError(err)
+	return err
 }
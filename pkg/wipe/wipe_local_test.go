@@ -0,0 +1,104 @@
+package wipe
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/s3"
+	awsweeper "github.com/cmpsoares91/awsweeper/pkg/aws"
+	"github.com/cmpsoares91/awsweeper/pkg/config"
+	"github.com/cmpsoares91/awsweeper/pkg/filters"
+)
+
+// TestLocalRun runs a sweep against a local AWS stand-in such as LocalStack or moto server.
+// It is skipped unless AWSWEEPER_TEST_ENDPOINT is set (see make testlocal).
+func TestLocalRun(t *testing.T) {
+	endpoint := os.Getenv("AWSWEEPER_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("AWSWEEPER_TEST_ENDPOINT not set")
+	}
+
+	opts := config.Options{
+		Regions:          []string{"us-east-1"},
+		Endpoints:        map[string]string{"default": endpoint},
+		S3ForcePathStyle: true,
+	}
+
+	sess, cfg, err := awsweeper.NewSession("us-east-1", opts.SessionOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s3api := s3.New(sess, cfg)
+	ddbapi := dynamodb.New(sess, cfg)
+
+	for _, bucket := range []string{"awsweeper-test-bucket", "keep-bucket"} {
+		if _, err := s3api.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, table := range []string{"awsweeper-test-table", "keep-table"} {
+		_, err := ddbapi.CreateTable(&dynamodb.CreateTableInput{
+			TableName: aws.String(table),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				{AttributeName: aws.String("id"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+			},
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			},
+			BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer s3api.DeleteBucket(&s3.DeleteBucketInput{Bucket: aws.String("keep-bucket")})
+	defer ddbapi.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String("keep-table")})
+
+	ids := &[]string{"^awsweeper-test-"}
+	wiper := Wiper{
+		Config: &config.Config{
+			Options: opts,
+			Filters: map[awsweeper.ResourceType]filters.Filters{
+				"s3_bucket":      {{IDs: ids}},
+				"dynamodb_table": {{IDs: ids}},
+			},
+		},
+	}
+
+	resources, warnings, err := wiper.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	if resources.Len() != 2 {
+		t.Errorf("expected 2 wiped resources, got %d: %s", resources.Len(), resources.String())
+	}
+
+	buckets, err := s3api.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range buckets.Buckets {
+		if *b.Name == "awsweeper-test-bucket" {
+			t.Error("bucket awsweeper-test-bucket has not been deleted")
+		}
+	}
+
+	tables, err := ddbapi.ListTables(&dynamodb.ListTablesInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tables.TableNames) != 1 || *tables.TableNames[0] != "keep-table" {
+		t.Errorf("expected only keep-table to remain, got %v", aws.StringValueSlice(tables.TableNames))
+	}
+}